@@ -0,0 +1,285 @@
+// Package audit computes password audit statistics from cracked hashes.
+package audit
+
+import (
+	"encoding/hex"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Count is the number of passwords with a value, such as a length or mask.
+type Count struct {
+	Value   string  `json:"value"`
+	Count   int     `json:"count"`
+	Percent float64 `json:"percent"`
+}
+
+// Snapshot is the number of cracked digests at a point in time.
+type Snapshot struct {
+	Time           int64   `json:"time"`
+	Digests        int64   `json:"digests"`
+	Cracked        int64   `json:"cracked"`
+	CrackedPercent float64 `json:"cracked_percent"`
+}
+
+// NewSnapshot returns a snapshot of digests and cracked at t.
+func NewSnapshot(t, digests, cracked int64) Snapshot {
+	return Snapshot{Time: t, Digests: digests, Cracked: cracked, CrackedPercent: percent(int(cracked), int(digests))}
+}
+
+// Policy is the password policy cracked passwords are checked against.
+type Policy struct {
+	MinLength  int `json:"min_length"`
+	MinClasses int `json:"min_classes"`
+}
+
+// PolicyCheck is the number of passwords that failed a policy requirement.
+type PolicyCheck struct {
+	Name    string  `json:"name"`
+	Failed  int     `json:"failed"`
+	Percent float64 `json:"percent"`
+}
+
+// Report is a password audit.
+type Report struct {
+	Title          string        `json:"title"`
+	Generated      int64         `json:"generated"`
+	Digests        int64         `json:"digests"`
+	Cracked        int64         `json:"cracked"`
+	CrackedPercent float64       `json:"cracked_percent"`
+	Passwords      int           `json:"passwords"`
+	History        []Snapshot    `json:"history"`
+	Lengths        []Count       `json:"lengths"`
+	Charsets       []Count       `json:"charsets"`
+	BaseWords      []Count       `json:"base_words"`
+	Masks          []Count       `json:"masks"`
+	Reused         []Count       `json:"reused"`
+	ReusedAccounts int           `json:"reused_accounts"`
+	Policy         Policy        `json:"policy"`
+	Checks         []PolicyCheck `json:"checks"`
+	Compliant      int           `json:"compliant"`
+}
+
+// Analyzer collects statistics for cracked passwords.
+type Analyzer struct {
+	Policy Policy
+	// Top limits the base words, masks, and reused passwords in the report.
+	// Zero includes all of them.
+	Top int
+
+	passwords int
+	lengths   map[int]int
+	charsets  map[string]int
+	bases     map[string]int
+	masks     map[string]int
+	plains    map[string]int
+	short     int
+	weak      int
+}
+
+// NewAnalyzer returns an Analyzer that checks passwords against policy.
+func NewAnalyzer(policy Policy, top int) *Analyzer {
+	return &Analyzer{
+		Policy:   policy,
+		Top:      top,
+		lengths:  make(map[int]int),
+		charsets: make(map[string]int),
+		bases:    make(map[string]int),
+		masks:    make(map[string]int),
+		plains:   make(map[string]int),
+	}
+}
+
+// Add records a cracked password used by accounts accounts. A hash shared by
+// several accounts is added once with the number of accounts.
+func (a *Analyzer) Add(plain string, accounts int) {
+	if accounts < 1 {
+		accounts = 1
+	}
+	a.passwords += accounts
+	length := utf8.RuneCountInString(plain)
+	a.lengths[length] += accounts
+	classes := Classes(plain)
+	a.charsets[strings.Join(classes, "+")] += accounts
+	if base := BaseWord(plain); base != "" {
+		a.bases[base] += accounts
+	}
+	a.masks[Mask(plain)] += accounts
+	a.plains[plain] += accounts
+	if length < a.Policy.MinLength {
+		a.short += accounts
+	}
+	if len(classes) < a.Policy.MinClasses {
+		a.weak += accounts
+	}
+}
+
+// Report returns the statistics for every password added. The caller sets
+// the title, digest counts, and history.
+func (a *Analyzer) Report() Report {
+	r := Report{
+		Passwords: a.passwords,
+		Policy:    a.Policy,
+	}
+	for l, n := range a.lengths {
+		r.Lengths = append(r.Lengths, Count{Value: strconv.Itoa(l), Count: n})
+	}
+	sort.Slice(r.Lengths, func(i, j int) bool {
+		li, _ := strconv.Atoi(r.Lengths[i].Value)
+		lj, _ := strconv.Atoi(r.Lengths[j].Value)
+		return li < lj
+	})
+	r.Charsets = a.counts(a.charsets, 0)
+	r.BaseWords = a.counts(a.bases, a.Top)
+	r.Masks = a.counts(a.masks, a.Top)
+	reused := make(map[string]int)
+	for p, n := range a.plains {
+		if n > 1 {
+			reused[p] = n
+			r.ReusedAccounts += n
+		}
+	}
+	r.Reused = a.counts(reused, a.Top)
+	for i := range r.Lengths {
+		r.Lengths[i].Percent = percent(r.Lengths[i].Count, a.passwords)
+	}
+
+	if a.Policy.MinLength > 0 {
+		r.Checks = append(r.Checks, a.check("Shorter than "+strconv.Itoa(a.Policy.MinLength)+" characters", a.short))
+	}
+	if a.Policy.MinClasses > 0 {
+		r.Checks = append(r.Checks, a.check("Fewer than "+strconv.Itoa(a.Policy.MinClasses)+" character classes", a.weak))
+	}
+	r.Checks = append(r.Checks, a.check("Used by more than one account", r.ReusedAccounts))
+	for p, n := range a.plains {
+		if utf8.RuneCountInString(p) < a.Policy.MinLength || len(Classes(p)) < a.Policy.MinClasses || n > 1 {
+			continue
+		}
+		r.Compliant += n
+	}
+	return r
+}
+
+func (a *Analyzer) check(name string, failed int) PolicyCheck {
+	return PolicyCheck{Name: name, Failed: failed, Percent: percent(failed, a.passwords)}
+}
+
+// counts returns the values in m sorted by count, most common first, limited
+// to top values when top is not zero.
+func (a *Analyzer) counts(m map[string]int, top int) []Count {
+	counts := make([]Count, 0, len(m))
+	for v, n := range m {
+		counts = append(counts, Count{Value: v, Count: n, Percent: percent(n, a.passwords)})
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Count != counts[j].Count {
+			return counts[i].Count > counts[j].Count
+		}
+		return counts[i].Value < counts[j].Value
+	})
+	if top > 0 && len(counts) > top {
+		counts = counts[:top]
+	}
+	return counts
+}
+
+// SplitPlain splits a hash:plain line into the hash and the decoded plain.
+// fields is the number of ':' separated fields in the hash, e.g. 2 for a
+// salted mode written as hash:salt.
+func SplitPlain(line string, fields int) (hash, plain string, ok bool) {
+	i := 0
+	for n := 0; n < fields; n++ {
+		j := strings.IndexByte(line[i:], ':')
+		if j < 0 {
+			return "", "", false
+		}
+		i += j + 1
+	}
+	return line[:i-1], DecodePlain(line[i:]), true
+}
+
+// DecodePlain decodes a plain written as $HEX[...] by hashcat.
+func DecodePlain(plain string) string {
+	if !strings.HasPrefix(plain, "$HEX[") || !strings.HasSuffix(plain, "]") {
+		return plain
+	}
+	b, err := hex.DecodeString(plain[5 : len(plain)-1])
+	if err != nil {
+		return plain
+	}
+	return string(b)
+}
+
+// Classes returns the character classes used by plain in a fixed order.
+func Classes(plain string) []string {
+	var lower, upper, digit, special, other bool
+	for i := 0; i < len(plain); i++ {
+		switch c := plain[i]; {
+		case c >= 'a' && c <= 'z':
+			lower = true
+		case c >= 'A' && c <= 'Z':
+			upper = true
+		case c >= '0' && c <= '9':
+			digit = true
+		case c >= 0x20 && c <= 0x7e:
+			special = true
+		default:
+			other = true
+		}
+	}
+	var classes []string
+	for _, c := range []struct {
+		name string
+		used bool
+	}{{"lower", lower}, {"upper", upper}, {"digit", digit}, {"special", special}, {"other", other}} {
+		if c.used {
+			classes = append(classes, c.name)
+		}
+	}
+	return classes
+}
+
+// Mask returns the hashcat mask that matches plain, e.g. ?u?l?l?d.
+func Mask(plain string) string {
+	var b strings.Builder
+	for i := 0; i < len(plain); i++ {
+		switch c := plain[i]; {
+		case c >= 'a' && c <= 'z':
+			b.WriteString("?l")
+		case c >= 'A' && c <= 'Z':
+			b.WriteString("?u")
+		case c >= '0' && c <= '9':
+			b.WriteString("?d")
+		case c >= 0x20 && c <= 0x7e:
+			b.WriteString("?s")
+		default:
+			b.WriteString("?b")
+		}
+	}
+	return b.String()
+}
+
+var leet = strings.NewReplacer("4", "a", "@", "a", "3", "e", "1", "i", "!", "i", "0", "o", "$", "s", "5", "s", "7", "t")
+
+// BaseWord returns plain in lower case without the digits and symbols at
+// either end and with common substitutions reversed, e.g. P@ssw0rd1! is
+// password. Base words shorter than 3 letters are ignored.
+func BaseWord(plain string) string {
+	word := strings.TrimFunc(strings.ToLower(plain), func(r rune) bool {
+		return !(r >= 'a' && r <= 'z')
+	})
+	word = leet.Replace(word)
+	if len(word) < 3 {
+		return ""
+	}
+	return word
+}
+
+func percent(n, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(n) / float64(total) * 100
+}
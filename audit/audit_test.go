@@ -0,0 +1,51 @@
+package audit
+
+import (
+	"bytes"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestAnalyzer(t *testing.T) {
+	Convey("Given cracked passwords", t, func() {
+		a := NewAnalyzer(Policy{MinLength: 8, MinClasses: 3}, 10)
+		a.Add("P@ssw0rd1", 3)
+		a.Add("summer2020", 1)
+		a.Add("Tr0ub4dor&3x", 1)
+		r := a.Report()
+
+		Convey("Shared hashes are counted for each account", func() {
+			So(r.Passwords, ShouldEqual, 5)
+			So(r.BaseWords[0], ShouldResemble, Count{Value: "password", Count: 3, Percent: 60})
+			So(r.Reused, ShouldResemble, []Count{{Value: "P@ssw0rd1", Count: 3, Percent: 60}})
+		})
+
+		Convey("Masks, lengths, and classes are counted", func() {
+			So(r.Masks[0].Value, ShouldEqual, "?u?s?l?l?l?d?l?l?d")
+			So(r.Lengths[0], ShouldResemble, Count{Value: "9", Count: 3, Percent: 60})
+			So(r.Charsets, ShouldContain, Count{Value: "lower+digit", Count: 1, Percent: 20})
+		})
+
+		Convey("Policy failures are counted", func() {
+			So(r.Checks[1], ShouldResemble, PolicyCheck{Name: "Fewer than 3 character classes", Failed: 1, Percent: 20})
+			So(r.Compliant, ShouldEqual, 1)
+		})
+
+		Convey("The report renders as Markdown and HTML", func() {
+			r.Title = "Audit <test>"
+			var md, html bytes.Buffer
+			So(r.Markdown(&md), ShouldBeNil)
+			So(md.String(), ShouldContainSubstring, "| P@ssw0rd1 | 3 | 60.00% |")
+			So(r.HTML(&html), ShouldBeNil)
+			So(html.String(), ShouldContainSubstring, "<title>Audit &lt;test&gt;</title>")
+		})
+	})
+
+	Convey("Plains are split after the hash and decoded", t, func() {
+		hash, plain, ok := SplitPlain("5f4dcc3b5aa765d61d8327deb882cf99:salt:$HEX[613a62]", 2)
+		So(ok, ShouldBeTrue)
+		So(hash, ShouldEqual, "5f4dcc3b5aa765d61d8327deb882cf99:salt")
+		So(plain, ShouldEqual, "a:b")
+	})
+}
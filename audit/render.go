@@ -0,0 +1,122 @@
+package audit
+
+import (
+	htmltemplate "html/template"
+	"io"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// countTable is a titled table of counts passed to the counts template.
+type countTable struct {
+	Title  string
+	Column string
+	Counts []Count
+}
+
+var funcs = map[string]interface{}{
+	"table": func(title, column string, counts []Count) countTable {
+		return countTable{Title: title, Column: column, Counts: counts}
+	},
+	"date": func(t int64) string {
+		return time.Unix(t, 0).Format("2006-01-02 15:04")
+	},
+	"cell": func(s string) string {
+		return strings.NewReplacer("|", `\|`, "`", "'", "\n", " ").Replace(s)
+	},
+}
+
+var markdownTmpl = template.Must(template.New("markdown").Funcs(funcs).Parse(`# {{ .Title }}
+
+Generated {{ date .Generated }}.
+
+## Summary
+
+| | |
+|---|---|
+| Digests | {{ .Digests }} |
+| Cracked | {{ .Cracked }} ({{ printf "%0.2f" .CrackedPercent }}%) |
+| Passwords analyzed | {{ .Passwords }} |
+| Compliant with policy | {{ .Compliant }} |
+
+## Crack Ratio Over Time
+
+| Date | Cracked | Digests | Percent |
+|---|---:|---:|---:|
+{{ range .History }}| {{ date .Time }} | {{ .Cracked }} | {{ .Digests }} | {{ printf "%0.2f" .CrackedPercent }}% |
+{{ end }}
+## Policy Compliance
+
+| Check | Failed | Percent |
+|---|---:|---:|
+{{ range .Checks }}| {{ .Name }} | {{ .Failed }} | {{ printf "%0.2f" .Percent }}% |
+{{ end }}
+{{ template "counts" (table "Length Distribution" "Length" .Lengths) }}
+{{ template "counts" (table "Character Classes" "Classes" .Charsets) }}
+{{ template "counts" (table "Top Base Words" "Base Word" .BaseWords) }}
+{{ template "counts" (table "Top Masks" "Mask" .Masks) }}
+{{ template "counts" (table "Reused Passwords" "Password" .Reused) }}
+{{ define "counts" }}## {{ .Title }}
+
+| {{ .Column }} | Count | Percent |
+|---|---:|---:|
+{{ range .Counts }}| {{ cell .Value }} | {{ .Count }} | {{ printf "%0.2f" .Percent }}% |
+{{ end }}{{ end }}`))
+
+var htmlTmpl = htmltemplate.Must(htmltemplate.New("html").Funcs(funcs).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{ .Title }}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; margin-bottom: 2em; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: left; }
+td.n { text-align: right; }
+.bar { background: #4a90d9; height: 10px; }
+</style>
+</head>
+<body>
+<h1>{{ .Title }}</h1>
+<p>Generated {{ date .Generated }}.</p>
+<h2>Summary</h2>
+<table>
+<tr><th>Digests</th><td class="n">{{ .Digests }}</td></tr>
+<tr><th>Cracked</th><td class="n">{{ .Cracked }} ({{ printf "%0.2f" .CrackedPercent }}%)</td></tr>
+<tr><th>Passwords analyzed</th><td class="n">{{ .Passwords }}</td></tr>
+<tr><th>Compliant with policy</th><td class="n">{{ .Compliant }}</td></tr>
+</table>
+<h2>Crack Ratio Over Time</h2>
+<table>
+<tr><th>Date</th><th>Cracked</th><th>Digests</th><th>Percent</th><th></th></tr>
+{{ range .History }}<tr><td>{{ date .Time }}</td><td class="n">{{ .Cracked }}</td><td class="n">{{ .Digests }}</td><td class="n">{{ printf "%0.2f" .CrackedPercent }}%</td><td><div class="bar" style="width: {{ printf "%0.0f" .CrackedPercent }}px"></div></td></tr>
+{{ end }}</table>
+<h2>Policy Compliance</h2>
+<table>
+<tr><th>Check</th><th>Failed</th><th>Percent</th></tr>
+{{ range .Checks }}<tr><td>{{ .Name }}</td><td class="n">{{ .Failed }}</td><td class="n">{{ printf "%0.2f" .Percent }}%</td></tr>
+{{ end }}</table>
+{{ template "counts" (table "Length Distribution" "Length" .Lengths) }}
+{{ template "counts" (table "Character Classes" "Classes" .Charsets) }}
+{{ template "counts" (table "Top Base Words" "Base Word" .BaseWords) }}
+{{ template "counts" (table "Top Masks" "Mask" .Masks) }}
+{{ template "counts" (table "Reused Passwords" "Password" .Reused) }}
+</body>
+</html>
+{{ define "counts" }}<h2>{{ .Title }}</h2>
+<table>
+<tr><th>{{ .Column }}</th><th>Count</th><th>Percent</th></tr>
+{{ range .Counts }}<tr><td>{{ .Value }}</td><td class="n">{{ .Count }}</td><td class="n">{{ printf "%0.2f" .Percent }}%</td></tr>
+{{ end }}</table>
+{{ end }}`))
+
+// Markdown writes the report as a Markdown document.
+func (r Report) Markdown(w io.Writer) error {
+	return markdownTmpl.Execute(w, r)
+}
+
+// HTML writes the report as a standalone HTML page.
+func (r Report) HTML(w io.Writer) error {
+	return htmlTmpl.Execute(w, r)
+}
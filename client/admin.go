@@ -0,0 +1,67 @@
+package client
+
+import (
+	"context"
+	"fmt"
+
+	hashstack "github.com/stricture/hashstack-server-core-ng"
+)
+
+type impersonateRequest struct {
+	Username string `json:"username"`
+}
+
+// AdminProjects returns every project on the server.
+func (c *Client) AdminProjects(ctx context.Context) ([]hashstack.Project, error) {
+	var projects []hashstack.Project
+	err := c.getJSON(ctx, "/api/admin/projects", &projects)
+	return projects, err
+}
+
+// AdminJobs returns every active job on the server.
+func (c *Client) AdminJobs(ctx context.Context) ([]hashstack.Job, error) {
+	var jobs []hashstack.Job
+	err := c.getJSON(ctx, "/api/admin/jobs", &jobs)
+	return jobs, err
+}
+
+// AdminJob returns any job by project and job id.
+func (c *Client) AdminJob(ctx context.Context, projectID, id int64) (hashstack.Job, error) {
+	var job hashstack.Job
+	err := c.getJSON(ctx, fmt.Sprintf("/api/admin/projects/%d/jobs/%d", projectID, id), &job)
+	return job, err
+}
+
+// UpdateAdminJob modifies any job.
+func (c *Client) UpdateAdminJob(ctx context.Context, projectID, id int64, update JobUpdate) error {
+	return c.patchJSON(ctx, fmt.Sprintf("/api/admin/projects/%d/jobs/%d", projectID, id), update, nil)
+}
+
+// DeleteAdminJob deletes any job.
+func (c *Client) DeleteAdminJob(ctx context.Context, projectID, id int64) error {
+	return c.delete(ctx, fmt.Sprintf("/api/admin/projects/%d/jobs/%d", projectID, id))
+}
+
+// AdminTeams returns every team on the server.
+func (c *Client) AdminTeams(ctx context.Context) ([]hashstack.Team, error) {
+	var teams []hashstack.Team
+	err := c.getJSON(ctx, "/api/admin/teams", &teams)
+	return teams, err
+}
+
+// DeleteAgent removes an agent by id.
+func (c *Client) DeleteAgent(ctx context.Context, id string) error {
+	return c.delete(ctx, fmt.Sprintf("/api/admin/agents/%s", id))
+}
+
+// Impersonate returns an authentication token for another user.
+func (c *Client) Impersonate(ctx context.Context, username string) (string, error) {
+	var response tokenResponse
+	if err := c.postJSON(ctx, "/api/admin/impersonate", impersonateRequest{Username: username}, &response); err != nil {
+		if _, ok := err.(*JSONServerError); ok {
+			return "", new(InvalidResponseError)
+		}
+		return "", err
+	}
+	return response.Token, nil
+}
@@ -0,0 +1,78 @@
+package client
+
+import (
+	"context"
+	"fmt"
+
+	hashstack "github.com/stricture/hashstack-server-core-ng"
+)
+
+// Agents returns every agent in the cluster.
+func (c *Client) Agents(ctx context.Context) ([]hashstack.Agent, error) {
+	var agents []hashstack.Agent
+	err := c.getRangeJSON(ctx, "/api/agents", &agents)
+	return agents, err
+}
+
+// Agent returns an agent by UUID.
+func (c *Client) Agent(ctx context.Context, uuid string) (hashstack.Agent, error) {
+	var agent hashstack.Agent
+	err := c.getJSON(ctx, fmt.Sprintf("/api/agents/%s", uuid), &agent)
+	return agent, err
+}
+
+// AgentByID returns an agent by numeric id.
+func (c *Client) AgentByID(ctx context.Context, id int64) (hashstack.Agent, error) {
+	var agent hashstack.Agent
+	err := c.getJSON(ctx, fmt.Sprintf("/api/agents/%d", id), &agent)
+	return agent, err
+}
+
+// Stats returns cluster wide statistics.
+func (c *Client) Stats(ctx context.Context) (hashstack.ClusterStats, error) {
+	var stats hashstack.ClusterStats
+	err := c.getJSON(ctx, "/api/stats", &stats)
+	return stats, err
+}
+
+// Benchmark is the speed of a cluster device for a hash mode.
+type Benchmark struct {
+	AgentID  int64 `json:"agent_id"`
+	DeviceID int64 `json:"device_id"`
+	HashMode int   `json:"hash_mode"`
+	// Speed is in hashes per second.
+	Speed uint64 `json:"speed"`
+}
+
+// benchmarkAgent is an agent with the benchmarks reported by its devices.
+type benchmarkAgent struct {
+	ID      int64 `json:"id"`
+	Devices []struct {
+		ID         int64 `json:"id"`
+		Benchmarks []struct {
+			HashMode int    `json:"hash_mode"`
+			Speed    uint64 `json:"speed"`
+		} `json:"benchmarks"`
+	} `json:"devices"`
+}
+
+// Benchmarks returns the benchmark speed for mode of each device in the
+// cluster, read from the devices of every agent. Devices without a benchmark
+// for mode are left out.
+func (c *Client) Benchmarks(ctx context.Context, mode int) ([]Benchmark, error) {
+	var agents []benchmarkAgent
+	if err := c.getRangeJSON(ctx, "/api/agents", &agents); err != nil {
+		return nil, err
+	}
+	var benchmarks []Benchmark
+	for _, a := range agents {
+		for _, d := range a.Devices {
+			for _, b := range d.Benchmarks {
+				if b.HashMode == mode {
+					benchmarks = append(benchmarks, Benchmark{AgentID: a.ID, DeviceID: d.ID, HashMode: mode, Speed: b.Speed})
+				}
+			}
+		}
+	}
+	return benchmarks, nil
+}
@@ -0,0 +1,44 @@
+package client
+
+import (
+	"context"
+	"fmt"
+)
+
+// APIToken is a long-lived token owned by the current user. Token is only
+// returned when the token is created.
+type APIToken struct {
+	ID    int64  `json:"id"`
+	Name  string `json:"name"`
+	Token string `json:"token,omitempty"`
+	// ExpiresAt and LastUsedAt are unix times, or 0 for never.
+	ExpiresAt  int64 `json:"expires_at"`
+	LastUsedAt int64 `json:"last_used_at"`
+	CreatedAt  int64 `json:"created_at"`
+}
+
+// APITokenRequest is the body used to create an API token. An ExpiresAt of 0
+// creates a token that does not expire.
+type APITokenRequest struct {
+	Name      string `json:"name"`
+	ExpiresAt int64  `json:"expires_at,omitempty"`
+}
+
+// APITokens returns the API tokens of the current user.
+func (c *Client) APITokens(ctx context.Context) ([]APIToken, error) {
+	var tokens []APIToken
+	err := c.getRangeJSON(ctx, "/api/users/self/tokens", &tokens)
+	return tokens, err
+}
+
+// CreateAPIToken creates an API token for the current user.
+func (c *Client) CreateAPIToken(ctx context.Context, req APITokenRequest) (APIToken, error) {
+	var token APIToken
+	err := c.postJSON(ctx, "/api/users/self/tokens", req, &token)
+	return token, err
+}
+
+// RevokeAPIToken revokes an API token by id.
+func (c *Client) RevokeAPIToken(ctx context.Context, id int64) error {
+	return c.delete(ctx, fmt.Sprintf("/api/users/self/tokens/%d", id))
+}
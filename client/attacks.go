@@ -0,0 +1,74 @@
+package client
+
+import (
+	"context"
+	"fmt"
+
+	hashstack "github.com/stricture/hashstack-server-core-ng"
+)
+
+// AttackStep is a single step of an attack. Steps run in IDX order.
+type AttackStep struct {
+	IDX                   int    `json:"idx"`
+	AttackMode            int    `json:"attack_mode"`
+	WordlistID            int64  `json:"wordlist_id"`
+	WordlistCombinationID int64  `json:"wordlist_combination_id"`
+	RuleID                int64  `json:"rule_id"`
+	RuleBufLeft           string `json:"rule_buf_left"`
+	RuleBufRight          string `json:"rule_buf_right"`
+	Mask                  string `json:"mask"`
+	IsHexCharset          bool   `json:"is_hex_charset"`
+	MarkovThreshold       int    `json:"markov_threshold"`
+	MarkovHCStatFileID    int64  `json:"markov_hc_stat_file_id"`
+	CustomCharset1        string `json:"custom_charset1"`
+	CustomCharset2        string `json:"custom_charset2"`
+	CustomCharset3        string `json:"custom_charset3"`
+	CustomCharset4        string `json:"custom_charset4"`
+}
+
+// AttackRequest is the body used to create an attack.
+type AttackRequest struct {
+	Title string       `json:"title"`
+	Steps []AttackStep `json:"steps"`
+}
+
+// Attacks returns every attack on the server.
+func (c *Client) Attacks(ctx context.Context) ([]hashstack.Attack, error) {
+	var attacks []hashstack.Attack
+	err := c.getRangeJSON(ctx, "/api/attacks", &attacks)
+	return attacks, err
+}
+
+// Attack returns an attack by id.
+func (c *Client) Attack(ctx context.Context, id int64) (hashstack.Attack, error) {
+	var attack hashstack.Attack
+	err := c.getJSON(ctx, fmt.Sprintf("/api/attacks/%d", id), &attack)
+	return attack, err
+}
+
+// AttackByTitle returns the attack with title. The API does not support
+// filtering attacks, so every attack is requested and searched.
+func (c *Client) AttackByTitle(ctx context.Context, title string) (hashstack.Attack, error) {
+	attacks, err := c.Attacks(ctx)
+	if err != nil {
+		return hashstack.Attack{}, err
+	}
+	for _, a := range attacks {
+		if a.Title == title {
+			return a, nil
+		}
+	}
+	return hashstack.Attack{}, new(NotFoundError)
+}
+
+// CreateAttack creates a new attack.
+func (c *Client) CreateAttack(ctx context.Context, req AttackRequest) (hashstack.Attack, error) {
+	var attack hashstack.Attack
+	err := c.postJSON(ctx, "/api/attacks", req, &attack)
+	return attack, err
+}
+
+// DeleteAttack deletes an attack by id.
+func (c *Client) DeleteAttack(ctx context.Context, id int64) error {
+	return c.delete(ctx, fmt.Sprintf("/api/attacks/%d", id))
+}
@@ -0,0 +1,120 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+type tokenRequest struct {
+	Username     string `json:"username,omitempty"`
+	Password     string `json:"password,omitempty"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+}
+
+type tokenResponse struct {
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+}
+
+// Session is the result of a login. RefreshToken is only set by servers that
+// support exchanging it for a new token with Refresh.
+type Session struct {
+	Token        string
+	RefreshToken string
+}
+
+type serverVersion struct {
+	Version string `json:"version"`
+}
+
+// Login exchanges a username and password for a bearer token. The token is
+// returned and is not stored on the client.
+func (c *Client) Login(ctx context.Context, username, password string) (string, error) {
+	session, err := c.LoginSession(ctx, username, password)
+	return session.Token, err
+}
+
+// LoginSession exchanges a username and password for a bearer token and,
+// when the server supports it, a refresh token.
+func (c *Client) LoginSession(ctx context.Context, username, password string) (Session, error) {
+	return c.token(ctx, tokenRequest{Username: username, Password: password})
+}
+
+// Refresh exchanges a refresh token for a new session. Servers that do not
+// support refresh tokens return an AuthError or a BadRequestError.
+func (c *Client) Refresh(ctx context.Context, refreshToken string) (Session, error) {
+	return c.token(ctx, tokenRequest{RefreshToken: refreshToken})
+}
+
+func (c *Client) token(ctx context.Context, tr tokenRequest) (Session, error) {
+	data, err := json.Marshal(tr)
+	if err != nil {
+		c.debug(fmt.Sprintf("Error: %s", err.Error()))
+		return Session{}, new(JSONClientError)
+	}
+	req, err := c.newRequest(ctx, "POST", "/token", bytes.NewBuffer(data))
+	if err != nil {
+		return Session{}, err
+	}
+	req.Header.Del("Authorization")
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := c.do(req)
+	if err != nil {
+		return Session{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 201 {
+		c.debug(fmt.Sprintf("HTTP: unexpected response code - %d", resp.StatusCode))
+		return Session{}, new(InvalidResponseError)
+	}
+	var response tokenResponse
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		c.debug(fmt.Sprintf("Error: %s", err.Error()))
+		return Session{}, new(InvalidResponseError)
+	}
+	return Session{Token: response.Token, RefreshToken: response.RefreshToken}, nil
+}
+
+// TokenExpiry returns the exp claim of token when it is a JWT. The signature
+// is not checked, so the result is only used to warn before it expires.
+func TokenExpiry(token string) (time.Time, bool) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return time.Time{}, false
+	}
+	data, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return time.Time{}, false
+	}
+	var claims struct {
+		Exp *float64 `json:"exp"`
+	}
+	if err := json.Unmarshal(data, &claims); err != nil || claims.Exp == nil {
+		return time.Time{}, false
+	}
+	return time.Unix(int64(*claims.Exp), 0), true
+}
+
+// ServerVersion returns the version reported by the server.
+func (c *Client) ServerVersion(ctx context.Context) (string, error) {
+	req, err := c.newRequest(ctx, "GET", "/version", nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Del("Authorization")
+	req.Header.Set("Accept", "application/json")
+	resp, err := c.do(req)
+	if err != nil {
+		return "", err
+	}
+	var version serverVersion
+	if err := c.decode(resp, &version); err != nil {
+		return "", err
+	}
+	return version.Version, nil
+}
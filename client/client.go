@@ -0,0 +1,266 @@
+// Package client is a typed client for the Hashstack REST API. Every method
+// returns an error instead of exiting so that it can be embedded in other tools.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Client holds the server location and credentials used for every request.
+type Client struct {
+	// BaseURL is the server URL without a trailing slash, e.g. https://hashstack.local.
+	BaseURL string
+	// Token is the bearer token returned from Login.
+	Token string
+	// HTTPClient is used to send requests. http.DefaultClient is used when nil.
+	HTTPClient *http.Client
+	// Debug, when set, receives a message for each request and error.
+	Debug func(msg string)
+	// Reauth, when set, is called when the server rejects Token. The request
+	// is sent again once with the token it returns, which is kept on the
+	// client. Requests whose body can not be read twice are not retried.
+	Reauth func() (string, error)
+}
+
+// New returns a Client for the server at baseURL using token for authentication.
+func New(baseURL, token string) *Client {
+	return &Client{
+		BaseURL:    strings.TrimRight(baseURL, "/"),
+		Token:      token,
+		HTTPClient: http.DefaultClient,
+	}
+}
+
+func (c *Client) debug(msg string) {
+	if c.Debug != nil {
+		c.Debug(msg)
+	}
+}
+
+func (c *Client) httpClient() *http.Client {
+	if c.HTTPClient == nil {
+		return http.DefaultClient
+	}
+	return c.HTTPClient
+}
+
+// newRequest builds an authenticated request for path relative to BaseURL.
+func (c *Client) newRequest(ctx context.Context, method, path string, body io.Reader) (*http.Request, error) {
+	c.debug(fmt.Sprintf("HTTP: %s %s", method, path))
+	req, err := http.NewRequest(method, fmt.Sprintf("%s%s", c.BaseURL, path), body)
+	if err != nil {
+		c.debug(fmt.Sprintf("Error: %s", err.Error()))
+		return nil, new(RequestCreateError)
+	}
+	if c.Token != "" {
+		req.Header.Set("Authorization", fmt.Sprintf("bearer %s", c.Token))
+	}
+	return req.WithContext(ctx), nil
+}
+
+// do sends req and converts transport failures and error status codes into
+// the error types in this package. On success the caller must close the body.
+func (c *Client) do(req *http.Request) (*http.Response, error) {
+	resp, err := c.send(req)
+	if _, ok := err.(*AuthError); !ok || c.Reauth == nil || req.Header.Get("Authorization") == "" {
+		return resp, err
+	}
+	if req.Body != nil && req.GetBody == nil {
+		return resp, err
+	}
+	token, rerr := c.Reauth()
+	if rerr != nil {
+		c.debug(fmt.Sprintf("Error: %s", rerr.Error()))
+		return resp, err
+	}
+	c.Token = token
+	retry := req.Clone(req.Context())
+	if req.GetBody != nil {
+		if retry.Body, rerr = req.GetBody(); rerr != nil {
+			c.debug(fmt.Sprintf("Error: %s", rerr.Error()))
+			return resp, err
+		}
+	}
+	retry.Header.Set("Authorization", fmt.Sprintf("bearer %s", c.Token))
+	c.debug(fmt.Sprintf("HTTP: retrying %s %s with a new token", req.Method, req.URL.Path))
+	return c.send(retry)
+}
+
+// send is do without the retry after Reauth.
+func (c *Client) send(req *http.Request) (*http.Response, error) {
+	resp, err := c.httpClient().Do(req)
+	if err != nil {
+		c.debug(fmt.Sprintf("Error: %s", err.Error()))
+		var pinErr *PinError
+		if errors.As(err, &pinErr) {
+			return nil, pinErr
+		}
+		if strings.Contains(err.Error(), "x509") {
+			return nil, new(InvalidCertError)
+		}
+		return nil, new(RequestError)
+	}
+	if err := respToError(resp); err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	return resp, nil
+}
+
+// decode reads the body of resp into data. A nil data discards the body.
+func (c *Client) decode(resp *http.Response, data interface{}) error {
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		c.debug(fmt.Sprintf("Error: %s", err.Error()))
+		return new(InvalidResponseError)
+	}
+	if data == nil {
+		return nil
+	}
+	if err := json.Unmarshal(body, data); err != nil {
+		c.debug(fmt.Sprintf("Error: %s", err.Error()))
+		return new(JSONServerError)
+	}
+	return nil
+}
+
+// getTotal returns the number of items in a collection using the Content-Range header.
+func (c *Client) getTotal(ctx context.Context, path string) (int, error) {
+	var total int
+	req, err := c.newRequest(ctx, "GET", path, nil)
+	if err != nil {
+		return total, err
+	}
+	req.Header.Set("Range", "1-1")
+	resp, err := c.do(req)
+	if err != nil {
+		return total, err
+	}
+	resp.Body.Close()
+	contentRange := resp.Header.Get("Content-Range")
+	if contentRange == "" {
+		return total, new(InvalidResponseError)
+	}
+	parts := strings.Split(contentRange, "/")
+	if len(parts) != 2 {
+		return total, new(InvalidResponseError)
+	}
+	total, err = strconv.Atoi(parts[1])
+	if err != nil {
+		return total, new(InvalidResponseError)
+	}
+	return total, nil
+}
+
+// getRangeJSON requests every item in a collection and decodes it into data.
+// data is left untouched when the collection is empty.
+func (c *Client) getRangeJSON(ctx context.Context, path string, data interface{}) error {
+	total, err := c.getTotal(ctx, path)
+	if err != nil {
+		return err
+	}
+	if total < 1 {
+		return nil
+	}
+	req, err := c.newRequest(ctx, "GET", path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Range", fmt.Sprintf("1-%d", total))
+	resp, err := c.do(req)
+	if err != nil {
+		return err
+	}
+	return c.decode(resp, data)
+}
+
+// getReader returns the raw response body for path. The caller must close it.
+func (c *Client) getReader(ctx context.Context, path string) (io.ReadCloser, error) {
+	req, err := c.newRequest(ctx, "GET", path, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+func (c *Client) getJSON(ctx context.Context, path string, data interface{}) error {
+	req, err := c.newRequest(ctx, "GET", path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := c.do(req)
+	if err != nil {
+		return err
+	}
+	return c.decode(resp, data)
+}
+
+// sendJSON encodes in as the request body and decodes the response into out.
+func (c *Client) sendJSON(ctx context.Context, method, path string, in, out interface{}) error {
+	buff, err := json.Marshal(in)
+	if err != nil {
+		c.debug(fmt.Sprintf("Error: %s", err.Error()))
+		return new(JSONClientError)
+	}
+	req, err := c.newRequest(ctx, method, path, bytes.NewBuffer(buff))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := c.do(req)
+	if err != nil {
+		return err
+	}
+	return c.decode(resp, out)
+}
+
+func (c *Client) postJSON(ctx context.Context, path string, in, out interface{}) error {
+	return c.sendJSON(ctx, "POST", path, in, out)
+}
+
+func (c *Client) patchJSON(ctx context.Context, path string, in, out interface{}) error {
+	return c.sendJSON(ctx, "PATCH", path, in, out)
+}
+
+// postMultipart streams reader to path using contentType and decodes the response into out.
+func (c *Client) postMultipart(ctx context.Context, path, contentType string, reader io.Reader, out interface{}) error {
+	req, err := c.newRequest(ctx, "POST", path, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+	resp, err := c.do(req)
+	if err != nil {
+		return err
+	}
+	return c.decode(resp, out)
+}
+
+func (c *Client) delete(ctx context.Context, path string) error {
+	req, err := c.newRequest(ctx, "DELETE", path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := c.do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
@@ -0,0 +1,159 @@
+package client
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+	hashstack "github.com/stricture/hashstack-server-core-ng"
+)
+
+func newTestServer() *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/projects", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "bearer secret" {
+			w.WriteHeader(401)
+			return
+		}
+		projects := []hashstack.Project{{ID: 1, Name: "acme"}, {ID: 2, Name: "globex"}}
+		w.Header().Set("Content-Range", "1-2/2")
+		if r.Header.Get("Range") == "1-1" {
+			projects = projects[:1]
+		}
+		json.NewEncoder(w).Encode(projects)
+	})
+	mux.HandleFunc("/api/projects/1/jobs", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Range", "0-0/0")
+		w.Write([]byte("[]"))
+	})
+	mux.HandleFunc("/api/teams", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(400)
+		w.Write([]byte(`{"message":"Invalid request payload input","data":{"validation":{"keys":["name"],"values":["is required"]}}}`))
+	})
+	mux.HandleFunc("/api/wordlists", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Accept-Encoding", "zstd, gzip;q=0.5, identity")
+	})
+	mux.HandleFunc("/api/wordlists/uploads/abc", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Range") != "bytes 4-7/8" || r.Header.Get("Content-Encoding") != "gzip" {
+			w.WriteHeader(400)
+			return
+		}
+		zr, err := gzip.NewReader(r.Body)
+		if err != nil {
+			w.WriteHeader(400)
+			return
+		}
+		if data, _ := ioutil.ReadAll(zr); string(data) != "word" {
+			w.WriteHeader(400)
+			return
+		}
+		json.NewEncoder(w).Encode(Upload{ID: "abc", Size: 8, Offset: 8})
+	})
+	mux.HandleFunc("/api/agents", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Range", "0-0/1")
+		w.Write([]byte(`[{"id":7,"devices":[{"id":1,"benchmarks":[{"hash_mode":1000,"speed":500},{"hash_mode":0,"speed":900}]},{"id":2}]}]`))
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		var req tokenRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		if req.Password != "hunter2" {
+			w.WriteHeader(401)
+			return
+		}
+		w.WriteHeader(201)
+		json.NewEncoder(w).Encode(tokenResponse{Token: "secret"})
+	})
+	return httptest.NewServer(mux)
+}
+
+func TestClient(t *testing.T) {
+	Convey("Given a stand-in Hashstack server", t, func() {
+		ts := newTestServer()
+		defer ts.Close()
+
+		Convey("Login returns the token on success", func() {
+			token, err := New(ts.URL, "").Login(context.Background(), "admin", "hunter2")
+			So(err, ShouldBeNil)
+			So(token, ShouldEqual, "secret")
+		})
+
+		Convey("Login returns an AuthError for a bad password", func() {
+			_, err := New(ts.URL, "").Login(context.Background(), "admin", "wrong")
+			So(err, ShouldHaveSameTypeAs, new(AuthError))
+		})
+
+		Convey("A rejected token is replaced with Reauth and the request is sent again", func() {
+			c := New(ts.URL, "expired")
+			var calls int
+			c.Reauth = func() (string, error) {
+				calls++
+				return "secret", nil
+			}
+			projects, err := c.Projects(context.Background())
+			So(err, ShouldBeNil)
+			So(len(projects), ShouldEqual, 2)
+			So(calls, ShouldEqual, 1)
+			So(c.Token, ShouldEqual, "secret")
+		})
+
+		Convey("The expiry of a JWT is decoded", func() {
+			exp, ok := TokenExpiry("eyJhbGciOiJIUzI1NiJ9.eyJzdWIiOiJhZG1pbiIsImV4cCI6MTcwMDAwMDAwMH0.sig")
+			So(ok, ShouldBeTrue)
+			So(exp.Unix(), ShouldEqual, 1700000000)
+			_, ok = TokenExpiry("opaque")
+			So(ok, ShouldBeFalse)
+		})
+
+		Convey("Benchmarks are read from the devices of each agent", func() {
+			benchmarks, err := New(ts.URL, "secret").Benchmarks(context.Background(), 1000)
+			So(err, ShouldBeNil)
+			So(benchmarks, ShouldResemble, []Benchmark{{AgentID: 7, DeviceID: 1, HashMode: 1000, Speed: 500}})
+		})
+
+		Convey("Range requests return every item", func() {
+			projects, err := New(ts.URL+"/", "secret").Projects(context.Background())
+			So(err, ShouldBeNil)
+			So(len(projects), ShouldEqual, 2)
+			So(projects[1].Name, ShouldEqual, "globex")
+		})
+
+		Convey("Empty collections return no items and no error", func() {
+			jobs, err := New(ts.URL, "secret").Jobs(context.Background(), 1)
+			So(err, ShouldBeNil)
+			So(jobs, ShouldBeEmpty)
+		})
+
+		Convey("A missing token results in an AuthError", func() {
+			_, err := New(ts.URL, "").Projects(context.Background())
+			So(err, ShouldHaveSameTypeAs, new(AuthError))
+		})
+
+		Convey("Validation errors are included in a BadRequestError", func() {
+			_, err := New(ts.URL, "secret").CreateTeam(context.Background(), TeamRequest{})
+			So(err, ShouldHaveSameTypeAs, new(BadRequestError))
+			So(err.(*BadRequestError).ServerMsg, ShouldContainSubstring, "name - is required")
+		})
+
+		Convey("Upload encodings are read from the Accept-Encoding header", func() {
+			encodings, err := New(ts.URL, "secret").UploadEncodings(context.Background(), WordlistFile)
+			So(err, ShouldBeNil)
+			So(encodings, ShouldResemble, []string{"zstd", "gzip"})
+		})
+
+		Convey("Upload chunks are compressed and sent with a Content-Range", func() {
+			upload, err := New(ts.URL, "secret").UploadChunk(context.Background(), WordlistFile, "abc", 4, 8, []byte("word"), EncodingGzip)
+			So(err, ShouldBeNil)
+			So(upload.Offset, ShouldEqual, 8)
+		})
+
+		Convey("Unknown paths result in a NotFoundError", func() {
+			_, err := New(ts.URL, "secret").Attack(context.Background(), 42)
+			So(err, ShouldHaveSameTypeAs, new(NotFoundError))
+		})
+	})
+}
@@ -0,0 +1,96 @@
+package client
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Content encodings that can be used to compress uploads. An empty encoding
+// sends the body as is.
+const (
+	EncodingGzip = "gzip"
+	EncodingZstd = "zstd"
+)
+
+// UploadEncodings returns the content encodings the server accepts for
+// uploads of kind, as advertised in the Accept-Encoding header of an OPTIONS
+// response. Servers that do not advertise any encodings only accept plain uploads.
+func (c *Client) UploadEncodings(ctx context.Context, kind FileKind) ([]string, error) {
+	req, err := c.newRequest(ctx, "OPTIONS", kind.path(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+	var encodings []string
+	for _, v := range strings.Split(resp.Header.Get("Accept-Encoding"), ",") {
+		v = strings.TrimSpace(strings.Split(v, ";")[0])
+		if v != "" && v != "identity" {
+			encodings = append(encodings, strings.ToLower(v))
+		}
+	}
+	return encodings, nil
+}
+
+// Encode compresses data using encoding. An empty encoding returns data as is.
+func Encode(encoding string, data []byte) ([]byte, error) {
+	switch encoding {
+	case EncodingGzip:
+		var buf bytes.Buffer
+		w := gzip.NewWriter(&buf)
+		if _, err := w.Write(data); err != nil {
+			return nil, err
+		}
+		if err := w.Close(); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	case EncodingZstd:
+		w, err := zstd.NewWriter(nil)
+		if err != nil {
+			return nil, err
+		}
+		defer w.Close()
+		return w.EncodeAll(data, nil), nil
+	}
+	return data, nil
+}
+
+// encodeStream returns a reader of r compressed using encoding. Closing the
+// reader stops the compression of r, which must be done once the request that
+// reads it has finished.
+func encodeStream(encoding string, r io.Reader) io.ReadCloser {
+	var (
+		pr, pw = io.Pipe()
+		w      io.WriteCloser
+		err    error
+	)
+	switch encoding {
+	case EncodingGzip:
+		w = gzip.NewWriter(pw)
+	case EncodingZstd:
+		if w, err = zstd.NewWriter(pw); err != nil {
+			pw.CloseWithError(err)
+			return pr
+		}
+	default:
+		return ioutil.NopCloser(r)
+	}
+	go func() {
+		if _, err := io.Copy(w, r); err != nil {
+			pw.CloseWithError(err)
+			return
+		}
+		pw.CloseWithError(w.Close())
+	}()
+	return pr
+}
@@ -0,0 +1,144 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/stacktitan/boom"
+)
+
+// RequestCreateError is returned when an HTTP request could not be built.
+type RequestCreateError struct {
+}
+
+func (e *RequestCreateError) Error() string {
+	return "There was an error creating the request."
+}
+
+// RequestError is returned when the server could not be reached.
+type RequestError struct {
+}
+
+func (e *RequestError) Error() string {
+	return "There was an error completing the request. The server may not be available."
+}
+
+// InvalidCertError is returned when the server's TLS certificate could not be validated.
+type InvalidCertError struct {
+}
+
+func (e *InvalidCertError) Error() string {
+	return "There was an error while validating the server's TLS certificate. Use --ca-cert to trust the certificate authority that issued it."
+}
+
+// AuthError is returned for a 401 response.
+type AuthError struct {
+}
+
+func (e *AuthError) Error() string {
+	return "The client failed to authenticate to the server. Try to login again."
+}
+
+// AuthorizeError is returned for a 403 response.
+type AuthorizeError struct {
+}
+
+func (e *AuthorizeError) Error() string {
+	return "The server says that you are not authorized to complete this request."
+}
+
+// BadRequestError is returned for a 400 response. ServerMsg contains any
+// validation messages returned by the server.
+type BadRequestError struct {
+	ServerMsg string
+}
+
+func (e *BadRequestError) Error() string {
+	return fmt.Sprintf("There were validation errors in your request that resulted in a 400 status code being returned from the server.\n\nServer Message: %s\n", e.ServerMsg)
+}
+
+// NotFoundError is returned for a 404 response.
+type NotFoundError struct {
+}
+
+func (e *NotFoundError) Error() string {
+	return "The resource was not found on the server."
+}
+
+// InternalServerError is returned for a 500 response.
+type InternalServerError struct {
+}
+
+func (e *InternalServerError) Error() string {
+	return "There was an internal server error."
+}
+
+// InvalidResponseError is returned when the response could not be read or was not expected.
+type InvalidResponseError struct {
+}
+
+func (e *InvalidResponseError) Error() string {
+	return "An unexpected response from was sent from the server."
+}
+
+// JSONServerError is returned when the server's response could not be decoded.
+type JSONServerError struct {
+}
+
+func (e *JSONServerError) Error() string {
+	return "The JSON returned from the server could not be parsed correctly."
+}
+
+// JSONClientError is returned when a request body could not be encoded.
+type JSONClientError struct {
+}
+
+func (e *JSONClientError) Error() string {
+	return "There was an error parsing the JSON generated by the client."
+}
+
+// respToError converts an error status code into one of the error types above.
+// It returns nil for any status that is not treated as an error.
+func respToError(resp *http.Response) error {
+	switch resp.StatusCode {
+	case 401:
+		return new(AuthError)
+	case 403:
+		return new(AuthorizeError)
+	case 400:
+		data, err := ioutil.ReadAll(resp.Body)
+		e := new(BadRequestError)
+		if len(data) > 0 && err == nil {
+			var output boom.Output
+			if err := json.Unmarshal(data, &output); err != nil {
+				e.ServerMsg = "No message was returned from the server!"
+			} else {
+				if val, ok := output.Data["validation"]; ok {
+					e.ServerMsg = output.Message
+					validation, k1 := val.(map[string]interface{})
+					keys, k2 := validation["keys"].([]interface{})
+					values, k3 := validation["values"].([]interface{})
+					if k1 && k2 && k3 && (len(keys) == len(values)) {
+						e.ServerMsg += "\n\nThe following validation errors were identified:\n"
+						for i, k := range keys {
+							e.ServerMsg += fmt.Sprintf("%s - %s", k, values[i])
+						}
+					}
+
+				} else {
+					e.ServerMsg = fmt.Sprintf("%s.", output.Message)
+				}
+			}
+		} else {
+			e.ServerMsg = "No message was returned from the server!"
+		}
+		return e
+	case 404:
+		return new(NotFoundError)
+	case 500:
+		return new(InternalServerError)
+	}
+	return nil
+}
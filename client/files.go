@@ -0,0 +1,86 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+
+	hashstack "github.com/stricture/hashstack-server-core-ng"
+)
+
+// FileKind identifies one of the file collections stored on the server.
+type FileKind string
+
+// File collections that can be used by attacks.
+const (
+	WordlistFile FileKind = "wordlists"
+	RuleFile     FileKind = "rules"
+	HCStatFile   FileKind = "hcstat"
+)
+
+func (k FileKind) path() string {
+	return fmt.Sprintf("/api/%s", string(k))
+}
+
+// Files returns every file of kind.
+func (c *Client) Files(ctx context.Context, kind FileKind) ([]hashstack.File, error) {
+	var files []hashstack.File
+	err := c.getRangeJSON(ctx, kind.path(), &files)
+	return files, err
+}
+
+// File returns a file of kind by filename.
+func (c *Client) File(ctx context.Context, kind FileKind, filename string) (hashstack.File, error) {
+	f := hashstack.File{Filename: filename}
+	err := c.getJSON(ctx, fmt.Sprintf("%s?filename=%s", kind.path(), url.QueryEscape(filename)), &f)
+	return f, err
+}
+
+// Wordlist returns a wordlist by filename.
+func (c *Client) Wordlist(ctx context.Context, filename string) (hashstack.File, error) {
+	return c.File(ctx, WordlistFile, filename)
+}
+
+// Rule returns a rule file by filename.
+func (c *Client) Rule(ctx context.Context, filename string) (hashstack.File, error) {
+	return c.File(ctx, RuleFile, filename)
+}
+
+// HCStat returns an hcstat file by filename.
+func (c *Client) HCStat(ctx context.Context, filename string) (hashstack.File, error) {
+	return c.File(ctx, HCStatFile, filename)
+}
+
+// UploadFile streams a multipart form containing a file of kind to the server.
+// contentType must include the multipart boundary used by body. The body is
+// compressed using encoding, which should be one of the encodings returned by
+// UploadEncodings or empty.
+func (c *Client) UploadFile(ctx context.Context, kind FileKind, contentType, encoding string, body io.Reader) error {
+	stream := encodeStream(encoding, body)
+	defer stream.Close()
+	return c.UploadEncodedFile(ctx, kind, contentType, encoding, stream)
+}
+
+// UploadEncodedFile is UploadFile for a body that is already compressed using
+// encoding.
+func (c *Client) UploadEncodedFile(ctx context.Context, kind FileKind, contentType, encoding string, body io.Reader) error {
+	req, err := c.newRequest(ctx, "POST", kind.path(), body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+	if encoding != "" {
+		req.Header.Set("Content-Encoding", encoding)
+	}
+	resp, err := c.do(req)
+	if err != nil {
+		return err
+	}
+	return c.decode(resp, nil)
+}
+
+// DeleteFile deletes a file of kind by id.
+func (c *Client) DeleteFile(ctx context.Context, kind FileKind, id int64) error {
+	return c.delete(ctx, fmt.Sprintf("%s/%d", kind.path(), id))
+}
@@ -0,0 +1,22 @@
+package client
+
+import (
+	"context"
+	"fmt"
+
+	hashstack "github.com/stricture/hashstack-server-core-ng"
+)
+
+// HashModes returns every hash mode known to the server.
+func (c *Client) HashModes(ctx context.Context) ([]hashstack.HashMode, error) {
+	var modes []hashstack.HashMode
+	err := c.getJSON(ctx, "/api/hash_modes", &modes)
+	return modes, err
+}
+
+// HashMode returns a single hash mode by its hashcat number.
+func (c *Client) HashMode(ctx context.Context, mode int) (hashstack.HashMode, error) {
+	var hashMode hashstack.HashMode
+	err := c.getJSON(ctx, fmt.Sprintf("/api/hash_modes?mode=%d", mode), &hashMode)
+	return hashMode, err
+}
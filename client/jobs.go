@@ -0,0 +1,70 @@
+package client
+
+import (
+	"context"
+	"fmt"
+
+	hashstack "github.com/stricture/hashstack-server-core-ng"
+)
+
+// JobRequest is the body used to create a job.
+type JobRequest struct {
+	Name                string `json:"name"`
+	ListID              int64  `json:"list_id"`
+	AttackID            int64  `json:"attack_id"`
+	Priority            int    `json:"priority"`
+	MaxDedicatedDevices int    `json:"max_dedicated_devices"`
+	OpenCLVectorWidth   int    `json:"opencl_vector_width"`
+}
+
+// JobUpdate is the body used to modify, pause or start a job.
+type JobUpdate struct {
+	Priority            int  `json:"priority"`
+	MaxDedicatedDevices int  `json:"max_dedicated_devices"`
+	IsActive            bool `json:"is_active"`
+}
+
+// Jobs returns every job in a project.
+func (c *Client) Jobs(ctx context.Context, projectID int64) ([]hashstack.Job, error) {
+	var jobs []hashstack.Job
+	err := c.getRangeJSON(ctx, fmt.Sprintf("/api/projects/%d/jobs", projectID), &jobs)
+	return jobs, err
+}
+
+// Job returns a job by id.
+func (c *Client) Job(ctx context.Context, projectID, id int64) (hashstack.Job, error) {
+	var job hashstack.Job
+	err := c.getJSON(ctx, fmt.Sprintf("/api/projects/%d/jobs/%d", projectID, id), &job)
+	return job, err
+}
+
+// CreateJob creates a job in a project.
+func (c *Client) CreateJob(ctx context.Context, projectID int64, req JobRequest) (hashstack.Job, error) {
+	var job hashstack.Job
+	err := c.postJSON(ctx, fmt.Sprintf("/api/projects/%d/jobs", projectID), req, &job)
+	return job, err
+}
+
+// UpdateJob modifies a job.
+func (c *Client) UpdateJob(ctx context.Context, projectID, id int64, update JobUpdate) error {
+	return c.patchJSON(ctx, fmt.Sprintf("/api/projects/%d/jobs/%d", projectID, id), update, nil)
+}
+
+// DeleteJob deletes a job. The job's attack is not removed.
+func (c *Client) DeleteJob(ctx context.Context, projectID, id int64) error {
+	return c.delete(ctx, fmt.Sprintf("/api/projects/%d/jobs/%d", projectID, id))
+}
+
+// Tasks returns the tasks for a job.
+func (c *Client) Tasks(ctx context.Context, projectID, id int64) ([]hashstack.Task, error) {
+	var tasks []hashstack.Task
+	err := c.getJSON(ctx, fmt.Sprintf("/api/projects/%d/jobs/%d/tasks", projectID, id), &tasks)
+	return tasks, err
+}
+
+// Events returns the agent error events for a job.
+func (c *Client) Events(ctx context.Context, projectID, id int64) ([]hashstack.AgentEvent, error) {
+	var events []hashstack.AgentEvent
+	err := c.getJSON(ctx, fmt.Sprintf("/api/projects/%d/jobs/%d/events", projectID, id), &events)
+	return events, err
+}
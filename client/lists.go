@@ -0,0 +1,96 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+
+	hashstack "github.com/stricture/hashstack-server-core-ng"
+)
+
+// BinaryListRequest is the body used to upload a single binary hash.
+type BinaryListRequest struct {
+	ProjectID   int64  `json:"project_id"`
+	HashMode    int    `json:"hash_mode"`
+	EncodedHash string `json:"encoded_hash"`
+	Filename    string `json:"filename"`
+	Name        string `json:"name"`
+}
+
+// MultiTrackListRequest is the body used to upload hashes that track the file they came from.
+type MultiTrackListRequest struct {
+	ProjectID int64            `json:"project_id"`
+	HashMode  int              `json:"hash_mode"`
+	Hashes    []MultiTrackItem `json:"hashes"`
+	Name      string           `json:"name"`
+}
+
+// MultiTrackItem is a single hash and its originating filename.
+type MultiTrackItem struct {
+	Hash     string `json:"hash"`
+	Filename string `json:"filename"`
+}
+
+// ListCount returns the number of lists in a project.
+func (c *Client) ListCount(ctx context.Context, projectID int64) (int, error) {
+	return c.getTotal(ctx, fmt.Sprintf("/api/projects/%d/lists", projectID))
+}
+
+// Lists returns every list in a project.
+func (c *Client) Lists(ctx context.Context, projectID int64) ([]hashstack.List, error) {
+	var lists []hashstack.List
+	err := c.getRangeJSON(ctx, fmt.Sprintf("/api/projects/%d/lists", projectID), &lists)
+	return lists, err
+}
+
+// List returns a list by id.
+func (c *Client) List(ctx context.Context, projectID, id int64) (hashstack.List, error) {
+	var list hashstack.List
+	err := c.getJSON(ctx, fmt.Sprintf("/api/projects/%d/lists/%d", projectID, id), &list)
+	return list, err
+}
+
+// ListByName returns a list by name.
+func (c *Client) ListByName(ctx context.Context, projectID int64, name string) (hashstack.List, error) {
+	list := hashstack.List{ProjectID: projectID, Name: name}
+	err := c.getJSON(ctx, fmt.Sprintf("/api/projects/%d/lists?name=%s", projectID, url.QueryEscape(name)), &list)
+	return list, err
+}
+
+// UploadList streams a multipart form containing a text hash list to the server.
+// contentType must include the multipart boundary used by body.
+func (c *Client) UploadList(ctx context.Context, projectID int64, contentType string, body io.Reader) (hashstack.List, error) {
+	var list hashstack.List
+	err := c.postMultipart(ctx, fmt.Sprintf("/api/projects/%d/lists/multi", projectID), contentType, body, &list)
+	return list, err
+}
+
+// CreateMultiTrackList uploads hashes in a filename:hash format.
+func (c *Client) CreateMultiTrackList(ctx context.Context, projectID int64, req MultiTrackListRequest) (hashstack.List, error) {
+	var list hashstack.List
+	err := c.postJSON(ctx, fmt.Sprintf("/api/projects/%d/lists/multitrack", projectID), req, &list)
+	return list, err
+}
+
+// CreateBinaryList uploads a single binary hash.
+func (c *Client) CreateBinaryList(ctx context.Context, projectID int64, req BinaryListRequest) (hashstack.List, error) {
+	var list hashstack.List
+	err := c.postJSON(ctx, fmt.Sprintf("/api/projects/%d/lists/binary", projectID), req, &list)
+	return list, err
+}
+
+// DeleteList deletes a list and its plains.
+func (c *Client) DeleteList(ctx context.Context, projectID, id int64) error {
+	return c.delete(ctx, fmt.Sprintf("/api/projects/%d/lists/%d", projectID, id))
+}
+
+// Plains returns the cracked hash:plain stream for a list. The caller must close it.
+func (c *Client) Plains(ctx context.Context, projectID, id int64) (io.ReadCloser, error) {
+	return c.getReader(ctx, fmt.Sprintf("/api/projects/%d/lists/%d/plains", projectID, id))
+}
+
+// Hashes returns the uncracked hash stream for a list. The caller must close it.
+func (c *Client) Hashes(ctx context.Context, projectID, id int64) (io.ReadCloser, error) {
+	return c.getReader(ctx, fmt.Sprintf("/api/projects/%d/lists/%d/hashes", projectID, id))
+}
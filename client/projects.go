@@ -0,0 +1,69 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	hashstack "github.com/stricture/hashstack-server-core-ng"
+)
+
+// ProjectRequest is the body used to create a project.
+type ProjectRequest struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// Contributor references a user or team by id in an update request.
+type Contributor struct {
+	ID int64 `json:"id"`
+}
+
+// ProjectUpdate is the body used to modify a project. Contributors and Teams
+// replace the current values on the server.
+type ProjectUpdate struct {
+	Name         string `json:"name"`
+	Description  string `json:"description"`
+	IsActive     bool   `json:"is_active"`
+	OwnerUserID  int64  `json:"owner_user_id"`
+	Contributors []Contributor
+	Teams        []Contributor
+}
+
+// Projects returns every project the user has access to.
+func (c *Client) Projects(ctx context.Context) ([]hashstack.Project, error) {
+	var projects []hashstack.Project
+	err := c.getRangeJSON(ctx, "/api/projects", &projects)
+	return projects, err
+}
+
+// Project returns a project by id.
+func (c *Client) Project(ctx context.Context, id int64) (hashstack.Project, error) {
+	var project hashstack.Project
+	err := c.getJSON(ctx, fmt.Sprintf("/api/projects/%d", id), &project)
+	return project, err
+}
+
+// ProjectByName returns a project by name.
+func (c *Client) ProjectByName(ctx context.Context, name string) (hashstack.Project, error) {
+	project := hashstack.Project{Name: name}
+	err := c.getJSON(ctx, fmt.Sprintf("/api/projects?name=%s", url.QueryEscape(name)), &project)
+	return project, err
+}
+
+// CreateProject creates a new project.
+func (c *Client) CreateProject(ctx context.Context, req ProjectRequest) (hashstack.Project, error) {
+	var project hashstack.Project
+	err := c.postJSON(ctx, "/api/projects", req, &project)
+	return project, err
+}
+
+// UpdateProject modifies the project with id.
+func (c *Client) UpdateProject(ctx context.Context, id int64, update ProjectUpdate) error {
+	return c.patchJSON(ctx, fmt.Sprintf("/api/projects/%d", id), update, nil)
+}
+
+// DeleteProject deletes the project with id along with its lists.
+func (c *Client) DeleteProject(ctx context.Context, id int64) error {
+	return c.delete(ctx, fmt.Sprintf("/api/projects/%d", id))
+}
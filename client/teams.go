@@ -0,0 +1,65 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	hashstack "github.com/stricture/hashstack-server-core-ng"
+)
+
+// TeamRequest is the body used to create a team.
+type TeamRequest struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// TeamUpdate is the body used to modify a team. Contributors replaces the
+// current members on the server.
+type TeamUpdate struct {
+	Name         string `json:"name"`
+	Description  string `json:"description"`
+	IsActive     bool   `json:"is_active"`
+	OwnerUserID  int64  `json:"owner_user_id"`
+	Contributors []Contributor
+}
+
+// Teams returns every team the user has access to.
+func (c *Client) Teams(ctx context.Context) ([]hashstack.Team, error) {
+	var teams []hashstack.Team
+	err := c.getRangeJSON(ctx, "/api/teams", &teams)
+	return teams, err
+}
+
+// Team returns a team by id.
+func (c *Client) Team(ctx context.Context, id int64) (hashstack.Team, error) {
+	var team hashstack.Team
+	err := c.getJSON(ctx, fmt.Sprintf("/api/teams/%d", id), &team)
+	return team, err
+}
+
+// TeamByName returns a team by name.
+func (c *Client) TeamByName(ctx context.Context, name string) (hashstack.Team, error) {
+	team := hashstack.Team{Name: name}
+	err := c.getJSON(ctx, fmt.Sprintf("/api/teams?name=%s", url.QueryEscape(name)), &team)
+	return team, err
+}
+
+// CreateTeam creates a new team.
+func (c *Client) CreateTeam(ctx context.Context, req TeamRequest) (hashstack.Team, error) {
+	var team hashstack.Team
+	err := c.postJSON(ctx, "/api/teams", req, &team)
+	return team, err
+}
+
+// UpdateTeam modifies a team and returns the updated team.
+func (c *Client) UpdateTeam(ctx context.Context, id int64, update TeamUpdate) (hashstack.Team, error) {
+	var team hashstack.Team
+	err := c.patchJSON(ctx, fmt.Sprintf("/api/teams/%d", id), update, &team)
+	return team, err
+}
+
+// DeleteTeam deletes a team by id.
+func (c *Client) DeleteTeam(ctx context.Context, id int64) error {
+	return c.delete(ctx, fmt.Sprintf("/api/teams/%d", id))
+}
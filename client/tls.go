@@ -0,0 +1,168 @@
+package client
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// pinPrefix starts every pin, as in curl's --pinnedpubkey.
+const pinPrefix = "sha256//"
+
+// TLSConfig holds the options used to validate the server and to
+// authenticate the client with a certificate.
+type TLSConfig struct {
+	// CACert is a PEM file of certificate authorities trusted in addition to
+	// the system pool.
+	CACert string
+	// ClientCert and ClientKey are PEM files presented to the server.
+	ClientCert string
+	ClientKey  string
+	// Pins are SHA-256 hashes of the server's public key, see SPKIPin.
+	Pins []string
+	// Insecure skips every certificate check, including Pins.
+	Insecure bool
+}
+
+// PinError is returned when the server's public key does not match a pin.
+type PinError struct {
+	// Pin is the pin of the key sent by the server.
+	Pin string
+}
+
+func (e *PinError) Error() string {
+	return fmt.Sprintf("The server's public key (%s) does not match the pinned keys. The certificate may have been replaced; remove the pin if that is expected.", e.Pin)
+}
+
+// SPKIPin returns the SHA-256 hash of the certificate's public key in the
+// form sha256//<base64>.
+func SPKIPin(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	return pinPrefix + base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// ValidPin reports whether pin is in the form returned by SPKIPin.
+func ValidPin(pin string) bool {
+	if !strings.HasPrefix(pin, pinPrefix) {
+		return false
+	}
+	sum, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(pin, pinPrefix))
+	return err == nil && len(sum) == sha256.Size
+}
+
+// NewTransport returns a transport that validates the server with cfg. When
+// there are pins and the certificate does not chain to a trusted authority, a
+// server certificate whose key is pinned is accepted, as for a self-signed
+// certificate trusted on first use. Otherwise a key in the verified chain must
+// be pinned.
+func NewTransport(cfg TLSConfig) (*http.Transport, error) {
+	tc := &tls.Config{InsecureSkipVerify: cfg.Insecure}
+	if cfg.CACert != "" {
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		data, err := ioutil.ReadFile(cfg.CACert)
+		if err != nil {
+			return nil, err
+		}
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("there are no certificates in %s", cfg.CACert)
+		}
+		tc.RootCAs = pool
+	}
+	if cfg.ClientCert != "" || cfg.ClientKey != "" {
+		if cfg.ClientCert == "" || cfg.ClientKey == "" {
+			return nil, fmt.Errorf("both a client certificate and key are required")
+		}
+		cert, err := tls.LoadX509KeyPair(cfg.ClientCert, cfg.ClientKey)
+		if err != nil {
+			return nil, err
+		}
+		tc.Certificates = []tls.Certificate{cert}
+	}
+	for _, pin := range cfg.Pins {
+		if !ValidPin(pin) {
+			return nil, fmt.Errorf("%s is not a valid pin, it must be sha256// followed by a base64 SHA-256 hash", pin)
+		}
+	}
+	if len(cfg.Pins) > 0 && !cfg.Insecure {
+		// The chain is verified below so that a pinned self-signed
+		// certificate can be accepted.
+		tc.InsecureSkipVerify = true
+		tc.VerifyConnection = func(cs tls.ConnectionState) error {
+			return verifyPins(cs, tc.RootCAs, cfg.Pins)
+		}
+	}
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.TLSClientConfig = tc
+	return t, nil
+}
+
+func verifyPins(cs tls.ConnectionState, roots *x509.CertPool, pins []string) error {
+	if len(cs.PeerCertificates) == 0 {
+		return fmt.Errorf("the server did not send a certificate")
+	}
+	leaf := cs.PeerCertificates[0]
+	pinned := make(map[string]bool)
+	for _, pin := range pins {
+		pinned[pin] = true
+	}
+	opts := x509.VerifyOptions{
+		Roots:         roots,
+		DNSName:       cs.ServerName,
+		Intermediates: x509.NewCertPool(),
+	}
+	for _, cert := range cs.PeerCertificates[1:] {
+		opts.Intermediates.AddCert(cert)
+	}
+	chains, err := leaf.Verify(opts)
+	if err != nil {
+		// Only the leaf proved that it holds its key during the handshake.
+		if pinned[SPKIPin(leaf)] {
+			return nil
+		}
+		return &PinError{Pin: SPKIPin(leaf)}
+	}
+	for _, chain := range chains {
+		for _, cert := range chain {
+			if pinned[SPKIPin(cert)] {
+				return nil
+			}
+		}
+	}
+	return &PinError{Pin: SPKIPin(leaf)}
+}
+
+// ServerCertificate connects to the server at baseURL without validating its
+// certificate and returns the certificate it sends, so that it can be shown
+// to the user before it is pinned.
+func ServerCertificate(baseURL string) (*x509.Certificate, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, err
+	}
+	host := u.Host
+	if u.Port() == "" {
+		host = net.JoinHostPort(u.Hostname(), "443")
+	}
+	dialer := &net.Dialer{Timeout: 30 * time.Second}
+	conn, err := tls.DialWithDialer(dialer, "tcp", host, &tls.Config{InsecureSkipVerify: true, ServerName: u.Hostname()})
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	certs := conn.ConnectionState().PeerCertificates
+	if len(certs) == 0 {
+		return nil, fmt.Errorf("the server did not send a certificate")
+	}
+	return certs[0], nil
+}
@@ -0,0 +1,65 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/pem"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestTLS(t *testing.T) {
+	Convey("Given a server with a self-signed certificate", t, func() {
+		ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(201)
+			json.NewEncoder(w).Encode(tokenResponse{Token: "secret"})
+		}))
+		defer ts.Close()
+		login := func(cfg TLSConfig) error {
+			transport, err := NewTransport(cfg)
+			So(err, ShouldBeNil)
+			c := New(ts.URL, "")
+			c.HTTPClient = &http.Client{Transport: transport}
+			_, err = c.Login(context.Background(), "admin", "hunter2")
+			return err
+		}
+		pin := SPKIPin(ts.Certificate())
+
+		Convey("The certificate is rejected by default", func() {
+			So(login(TLSConfig{}), ShouldHaveSameTypeAs, new(InvalidCertError))
+		})
+
+		Convey("The certificate is accepted with --ca-cert", func() {
+			dir, _ := ioutil.TempDir("", "hashstack-tls-")
+			defer os.RemoveAll(dir)
+			ca := filepath.Join(dir, "ca.pem")
+			ioutil.WriteFile(ca, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ts.Certificate().Raw}), 0600)
+			So(login(TLSConfig{CACert: ca}), ShouldBeNil)
+			So(login(TLSConfig{CACert: ca, Pins: []string{pin}}), ShouldBeNil)
+		})
+
+		Convey("The certificate is accepted when its key is pinned", func() {
+			So(ValidPin(pin), ShouldBeTrue)
+			So(login(TLSConfig{Pins: []string{pin}}), ShouldBeNil)
+		})
+
+		Convey("Another key results in a PinError", func() {
+			other := "sha256//47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU="
+			err := login(TLSConfig{Pins: []string{other}})
+			So(err, ShouldHaveSameTypeAs, new(PinError))
+			So(err.(*PinError).Pin, ShouldEqual, pin)
+		})
+
+		Convey("The certificate can be fetched to be pinned", func() {
+			cert, err := ServerCertificate(ts.URL)
+			So(err, ShouldBeNil)
+			So(SPKIPin(cert), ShouldEqual, pin)
+		})
+	})
+}
@@ -0,0 +1,87 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+)
+
+// Upload is a resumable upload session for a file. Chunks are appended at
+// Offset until it reaches Size.
+type Upload struct {
+	ID       string `json:"id"`
+	Filename string `json:"filename"`
+	Size     int64  `json:"size"`
+	Offset   int64  `json:"offset"`
+	// Checksum is the hex encoded SHA-256 of the file. After CompleteUpload it
+	// is the checksum calculated by the server for the data it received.
+	Checksum string `json:"checksum"`
+}
+
+// UploadRequest is the body used to start an upload session.
+type UploadRequest struct {
+	Filename string `json:"filename"`
+	Size     int64  `json:"size"`
+	Checksum string `json:"checksum"`
+}
+
+func uploadPath(kind FileKind, id string) string {
+	return fmt.Sprintf("%s/uploads/%s", kind.path(), id)
+}
+
+// CreateUpload starts a resumable upload session for a file of kind. A
+// NotFoundError is returned by servers that only support UploadFile.
+func (c *Client) CreateUpload(ctx context.Context, kind FileKind, req UploadRequest) (Upload, error) {
+	var upload Upload
+	err := c.postJSON(ctx, fmt.Sprintf("%s/uploads", kind.path()), req, &upload)
+	return upload, err
+}
+
+// Upload returns an upload session by id. Offset is the number of bytes the
+// server has stored.
+func (c *Client) Upload(ctx context.Context, kind FileKind, id string) (Upload, error) {
+	var upload Upload
+	err := c.getJSON(ctx, uploadPath(kind, id), &upload)
+	return upload, err
+}
+
+// UploadChunk sends chunk to be stored at offset and returns the updated
+// session. The chunk is compressed using encoding, which should be one of the
+// encodings returned by UploadEncodings or empty. offset and total always
+// refer to the uncompressed file.
+func (c *Client) UploadChunk(ctx context.Context, kind FileKind, id string, offset, total int64, chunk []byte, encoding string) (Upload, error) {
+	var upload Upload
+	body, err := Encode(encoding, chunk)
+	if err != nil {
+		c.debug(fmt.Sprintf("Error: %s", err.Error()))
+		return upload, new(RequestCreateError)
+	}
+	req, err := c.newRequest(ctx, "PATCH", uploadPath(kind, id), bytes.NewReader(body))
+	if err != nil {
+		return upload, err
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", offset, offset+int64(len(chunk))-1, total))
+	if encoding != "" {
+		req.Header.Set("Content-Encoding", encoding)
+	}
+	resp, err := c.do(req)
+	if err != nil {
+		return upload, err
+	}
+	err = c.decode(resp, &upload)
+	return upload, err
+}
+
+// CompleteUpload finishes an upload session once every chunk has been sent.
+// The returned Checksum is calculated by the server.
+func (c *Client) CompleteUpload(ctx context.Context, kind FileKind, id string) (Upload, error) {
+	var upload Upload
+	err := c.postJSON(ctx, fmt.Sprintf("%s/complete", uploadPath(kind, id)), struct{}{}, &upload)
+	return upload, err
+}
+
+// DeleteUpload discards an upload session and any chunks the server has stored.
+func (c *Client) DeleteUpload(ctx context.Context, kind FileKind, id string) error {
+	return c.delete(ctx, uploadPath(kind, id))
+}
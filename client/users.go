@@ -0,0 +1,49 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	hashstack "github.com/stricture/hashstack-server-core-ng"
+)
+
+// SelfUpdate is the body used to change the current user's password.
+type SelfUpdate struct {
+	Username    string `json:"username"`
+	Password    string `json:"password"`
+	NewPassword string `json:"new_password"`
+}
+
+// Users returns every user on the server.
+func (c *Client) Users(ctx context.Context) ([]hashstack.User, error) {
+	var users []hashstack.User
+	err := c.getRangeJSON(ctx, "/api/users", &users)
+	return users, err
+}
+
+// User returns a user by id.
+func (c *Client) User(ctx context.Context, id int64) (hashstack.User, error) {
+	var user hashstack.User
+	err := c.getJSON(ctx, fmt.Sprintf("/api/users/%d", id), &user)
+	return user, err
+}
+
+// UserByUsername returns a user by username.
+func (c *Client) UserByUsername(ctx context.Context, username string) (hashstack.User, error) {
+	user := hashstack.User{Username: username}
+	err := c.getJSON(ctx, fmt.Sprintf("/api/users?username=%s", url.QueryEscape(username)), &user)
+	return user, err
+}
+
+// Self returns the user that owns the client's token.
+func (c *Client) Self(ctx context.Context) (hashstack.User, error) {
+	var user hashstack.User
+	err := c.getJSON(ctx, "/api/users/self", &user)
+	return user, err
+}
+
+// UpdateSelf changes the current user's password.
+func (c *Client) UpdateSelf(ctx context.Context, update SelfUpdate) error {
+	return c.postJSON(ctx, "/api/users/self", update, nil)
+}
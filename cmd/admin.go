@@ -1,7 +1,6 @@
 package cmd
 
 import (
-	"encoding/json"
 	"fmt"
 	"sort"
 	"strconv"
@@ -11,6 +10,7 @@ import (
 
 	humanize "github.com/dustin/go-humanize"
 	"github.com/spf13/cobra"
+	"github.com/stricture/hashstack-cli/client"
 	hashstack "github.com/stricture/hashstack-server-core-ng"
 )
 
@@ -26,23 +26,12 @@ Executes a subcommand as an administrator (-h or --help for a list of subcommand
 	},
 }
 
-type impersonateRequest struct {
-	Username string `json:"username"`
-}
-
 func getImpersonationToken(username string) string {
-	body, err := postJSON("/api/admin/impersonate", impersonateRequest{
-		Username: username,
-	})
+	token, err := apiClient().Impersonate(ctx, username)
 	if err != nil {
 		writeStdErrAndExit(err.Error())
 	}
-	var response tokenResponse
-	if err := json.Unmarshal(body, &response); err != nil {
-		debug(fmt.Sprintf("Error: %s", err.Error()))
-		writeStdErrAndExit(new(invalidResponseError).Error())
-	}
-	return response.Token
+	return token
 }
 
 var adminImpersonateCmd = &cobra.Command{
@@ -80,7 +69,7 @@ agent is brought offline and will never return.
 			return
 		}
 		id := args[0]
-		if err := deleteHTTP(fmt.Sprintf("/api/admin/agents/%s", id)); err != nil {
+		if err := apiClient().DeleteAgent(ctx, id); err != nil {
 			writeStdErrAndExit(err.Error())
 		}
 		fmt.Println("Agent has been deleted.")
@@ -102,14 +91,18 @@ func displayAdminProjects(projects []hashstack.Project) {
 	sort.Slice(projects, func(i, j int) bool {
 		return projects[i].OwnerUserID < projects[j].OwnerUserID
 	})
+	if isStructuredOutput() {
+		renderOutput(projects)
+		return
+	}
 	for _, p := range projects {
 		displayAdminProject(p)
 	}
 }
 
 func getAdminProjects() []hashstack.Project {
-	var projects []hashstack.Project
-	if err := getJSON("/api/admin/projects", &projects); err != nil {
+	projects, err := apiClient().AdminProjects(ctx)
+	if err != nil {
 		writeStdErrAndExit(err.Error())
 	}
 	return projects
@@ -143,14 +136,18 @@ func displayAdminJob(job hashstack.Job) {
 }
 
 func displayAdminJobs(jobs []hashstack.Job) {
+	if isStructuredOutput() {
+		renderOutput(jobs)
+		return
+	}
 	for _, j := range jobs {
 		displayAdminJob(j)
 	}
 }
 
 func getAdminJobs() []hashstack.Job {
-	var jobs []hashstack.Job
-	if err := getJSON("/api/admin/jobs", &jobs); err != nil {
+	jobs, err := apiClient().AdminJobs(ctx)
+	if err != nil {
 		writeStdErrAndExit(err.Error())
 	}
 	sort.Slice(jobs, func(i, j int) bool {
@@ -171,9 +168,8 @@ var adminJobsCmd = &cobra.Command{
 }
 
 func getAdminJob(projectID, jobID int64) hashstack.Job {
-	var job hashstack.Job
-	path := fmt.Sprintf("/api/admin/projects/%d/jobs/%d", projectID, jobID)
-	if err := getJSON(path, &job); err != nil {
+	job, err := apiClient().AdminJob(ctx, projectID, jobID)
+	if err != nil {
 		writeStdErrAndExit(err.Error())
 	}
 	return job
@@ -197,13 +193,12 @@ var adminPauseJobCmd = &cobra.Command{
 			writeStdErrAndExit("job_id is invalid")
 		}
 		job := getAdminJob(int64(projectID), int64(jobID))
-		update := updateRequest{
+		update := client.JobUpdate{
 			Priority:            job.Priority,
 			MaxDedicatedDevices: job.MaxDedicatedDevices,
 			IsActive:            false,
 		}
-		path := fmt.Sprintf("/api/admin/projects/%d/jobs/%d", job.ProjectID, job.ID)
-		if _, err := patchJSON(path, &update); err != nil {
+		if err := apiClient().UpdateAdminJob(ctx, job.ProjectID, job.ID, update); err != nil {
 			writeStdErrAndExit(err.Error())
 		}
 		fmt.Println("The job has been paused.")
@@ -228,19 +223,19 @@ var adminDelJobCmd = &cobra.Command{
 			writeStdErrAndExit("job_id is invalid")
 		}
 		job := getAdminJob(int64(projectID), int64(jobID))
-		var attack hashstack.Attack
-		if err := getJSON(fmt.Sprintf("/api/attacks/%d", job.AttackID), &attack); err != nil {
+		c := apiClient()
+		attack, err := c.Attack(ctx, job.AttackID)
+		if err != nil {
 			writeStdErrAndExit(err.Error())
 		}
 		if ok := promptDelete("this job"); !ok {
 			writeStdErrAndExit("Not deleting job.")
 		}
-		path := fmt.Sprintf("/api/admin/projects/%d/jobs/%d", job.ProjectID, job.ID)
-		if err := deleteHTTP(path); err != nil {
+		if err := c.DeleteAdminJob(ctx, job.ProjectID, job.ID); err != nil {
 			writeStdErrAndExit(err.Error())
 		}
 		if attack.Title == fmt.Sprintf("hashstack-cli-%d-%d-%s", job.ProjectID, job.ListID, job.Name) {
-			deleteHTTP(fmt.Sprintf("/api/attacks/%d", job.AttackID))
+			c.DeleteAttack(ctx, job.AttackID)
 		}
 		fmt.Println("The job was successfully deleted.")
 	},
@@ -264,13 +259,12 @@ var adminStartJobCmd = &cobra.Command{
 			writeStdErrAndExit("job_id is invalid")
 		}
 		job := getAdminJob(int64(projectID), int64(jobID))
-		update := updateRequest{
+		update := client.JobUpdate{
 			Priority:            job.Priority,
 			MaxDedicatedDevices: job.MaxDedicatedDevices,
 			IsActive:            true,
 		}
-		path := fmt.Sprintf("/api/admin/projects/%d/jobs/%d", job.ProjectID, job.ID)
-		if _, err := patchJSON(path, &update); err != nil {
+		if err := apiClient().UpdateAdminJob(ctx, job.ProjectID, job.ID, update); err != nil {
 			writeStdErrAndExit(err.Error())
 		}
 		fmt.Println("The job has been started.")
@@ -278,8 +272,8 @@ var adminStartJobCmd = &cobra.Command{
 }
 
 func getAdminTeams() []hashstack.Team {
-	var teams []hashstack.Team
-	if err := getJSON("/api/admin/teams", &teams); err != nil {
+	teams, err := apiClient().AdminTeams(ctx)
+	if err != nil {
 		writeStdErrAndExit(err.Error())
 	}
 	sort.Slice(teams, func(i, j int) bool {
@@ -320,11 +314,11 @@ Modifies a team by it's name or id. All values are optional and only provided op
 			team.ID = int64(teamID)
 		}
 		team = getTeam(team)
-		var contribs []updateContributor
+		var contribs []client.Contributor
 		for _, c := range team.Contributors {
-			contribs = append(contribs, updateContributor{ID: c.ID})
+			contribs = append(contribs, client.Contributor{ID: c.ID})
 		}
-		update := updateTeamReq{
+		update := client.TeamUpdate{
 			Name:         team.Name,
 			Description:  team.Description,
 			IsActive:     true,
@@ -351,13 +345,10 @@ Modifies a team by it's name or id. All values are optional and only provided op
 			getUser(&user)
 			update.OwnerUserID = user.ID
 		}
-		data, err := patchJSON(fmt.Sprintf("/api/teams/%d", team.ID), update)
+		team, err = apiClient().UpdateTeam(ctx, team.ID, update)
 		if err != nil {
 			writeStdErrAndExit(err.Error())
 		}
-		if err := json.Unmarshal(data, &team); err != nil {
-			writeStdErrAndExit(err.Error())
-		}
 		displayTeam(false, team)
 	},
 }
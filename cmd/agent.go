@@ -14,18 +14,16 @@ import (
 )
 
 func getAgentByID(id int64) hashstack.Agent {
-	var agent hashstack.Agent
-	path := fmt.Sprintf("/api/agents/%d", id)
-	if err := getJSON(path, &agent); err != nil {
+	agent, err := apiClient().AgentByID(ctx, id)
+	if err != nil {
 		writeStdErrAndExit(err.Error())
 	}
 	return agent
 }
 
 func getAgent(uuid string) hashstack.Agent {
-	var agent hashstack.Agent
-	path := fmt.Sprintf("/api/agents/%s", uuid)
-	if err := getJSON(path, &agent); err != nil {
+	agent, err := apiClient().Agent(ctx, uuid)
+	if err != nil {
 		writeStdErrAndExit(err.Error())
 	}
 	return agent
@@ -35,8 +33,27 @@ func isOnline(agent hashstack.Agent) bool {
 	return time.Now().Add(-5*time.Minute).Unix() < agent.CheckinAt
 }
 
+// agentView is an agent along with its computed online state.
+type agentView struct {
+	hashstack.Agent
+	Online        bool    `json:"online"`
+	MemoryPercent float64 `json:"memory_percent"`
+}
+
+func newAgentView(agent hashstack.Agent) agentView {
+	return agentView{
+		Agent:         agent,
+		Online:        isOnline(agent),
+		MemoryPercent: percentOf(int(agent.MemoryUsed), int(agent.MemoryTotal)),
+	}
+}
+
 func displayAgent(a hashstack.Agent) {
 	agent := getAgent(a.UUID)
+	if isStructuredOutput() {
+		renderOutput(newAgentView(agent))
+		return
+	}
 	memstat := fmt.Sprintf("%s/%s (%2.f%%)",
 		humanize.Bytes(uint64(agent.MemoryUsed)),
 		humanize.Bytes(uint64(agent.MemoryTotal)),
@@ -74,11 +91,11 @@ var (
 )
 
 func displayAgents() {
-	var agents []hashstack.Agent
-	if err := getRangeJSON("/api/agents", &agents); err != nil {
+	agents, err := apiClient().Agents(ctx)
+	if err != nil {
 		writeStdErrAndExit(err.Error())
 	}
-	if len(agents) < 1 {
+	if len(agents) < 1 && !isStructuredOutput() {
 		writeStdErrAndExit("There are no agents in the cluster!")
 	}
 	switch flAgentSortOrder {
@@ -104,6 +121,17 @@ func displayAgents() {
 			return binary.BigEndian.Uint32(first) < binary.BigEndian.Uint32(second)
 		})
 	}
+	if isStructuredOutput() {
+		views := make([]agentView, 0, len(agents))
+		for _, a := range agents {
+			if flAgentShowOnlineOnly && !isOnline(a) {
+				continue
+			}
+			views = append(views, newAgentView(getAgent(a.UUID)))
+		}
+		renderOutput(views)
+		return
+	}
 	for _, a := range agents {
 		if flAgentShowOnlineOnly && !isOnline(a) {
 			continue
@@ -0,0 +1,144 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	humanize "github.com/dustin/go-humanize"
+	"github.com/gosuri/uitable"
+	"github.com/spf13/cobra"
+	"github.com/stricture/hashstack-cli/client"
+)
+
+var flAPITokenExpiresDays int
+
+// humanizeUnix returns t relative to now, or never when it is 0.
+func humanizeUnix(t int64) string {
+	if t == 0 {
+		return "never"
+	}
+	return humanize.Time(time.Unix(t, 0))
+}
+
+func getAPIToken(nameOrID string) client.APIToken {
+	tokens, err := apiClient().APITokens(ctx)
+	if err != nil {
+		writeStdErrAndExit(err.Error())
+	}
+	id, _ := strconv.ParseInt(nameOrID, 10, 64)
+	for _, t := range tokens {
+		if t.Name == nameOrID || (id != 0 && t.ID == id) {
+			return t
+		}
+	}
+	writeStdErrAndExit(fmt.Sprintf("There is no API token named %s.", nameOrID))
+	return client.APIToken{}
+}
+
+var apiTokenCmd = &cobra.Command{
+	Use:   "api-tokens",
+	Short: "Display your API tokens (-h or --help for subcommands).",
+	Long: `
+Display your API tokens. API tokens are long-lived tokens for scripts and service accounts, so that
+they do not need a password. Set HASHSTACK_SERVER_URL and HASHSTACK_TOKEN to use one without a
+configuration file:
+
+    hashstack api-tokens create nightly-import --expires-days 90
+    HASHSTACK_SERVER_URL=https://hashstack.example.com HASHSTACK_TOKEN=<token> hashstack projects
+`,
+	PreRun: ensureAuth,
+	Run: func(cmd *cobra.Command, args []string) {
+		tokens, err := apiClient().APITokens(ctx)
+		if err != nil {
+			writeStdErrAndExit(err.Error())
+		}
+		if isStructuredOutput() {
+			renderOutput(tokens)
+			return
+		}
+		if len(tokens) < 1 {
+			writeStdErrAndExit("You do not have any API tokens. Use hashstack api-tokens create to add one.")
+		}
+		tbl := uitable.New()
+		tbl.AddRow("ID", "Name", "Created", "Last Used", "Expires")
+		for _, t := range tokens {
+			tbl.AddRow(t.ID, t.Name, humanizeUnix(t.CreatedAt), humanizeUnix(t.LastUsedAt), humanizeUnix(t.ExpiresAt))
+		}
+		fmt.Println(tbl)
+	},
+}
+
+var listAPITokenCmd = &cobra.Command{
+	Use:    "list",
+	Short:  "Display your API tokens.",
+	PreRun: ensureAuth,
+	Run: func(cmd *cobra.Command, args []string) {
+		apiTokenCmd.Run(cmd, args)
+	},
+}
+
+var createAPITokenCmd = &cobra.Command{
+	Use:   "create <name>",
+	Short: "Create an API token and display it.",
+	Long: `
+Create an API token and display it. The token is only displayed once, so store it somewhere safe.
+It does not expire unless --expires-days is set.
+`,
+	PreRun: ensureAuth,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) < 1 {
+			writeStdErrAndExit("name is required.")
+		}
+		if flAPITokenExpiresDays < 0 {
+			writeStdErrAndExit("--expires-days can not be negative.")
+		}
+		req := client.APITokenRequest{Name: args[0]}
+		if flAPITokenExpiresDays > 0 {
+			req.ExpiresAt = time.Now().AddDate(0, 0, flAPITokenExpiresDays).Unix()
+		}
+		token, err := apiClient().CreateAPIToken(ctx, req)
+		if err != nil {
+			writeStdErrAndExit(err.Error())
+		}
+		if isStructuredOutput() {
+			renderOutput(token)
+			return
+		}
+		fmt.Printf("ID..........: %d\n", token.ID)
+		fmt.Printf("Name........: %s\n", token.Name)
+		fmt.Printf("Expires.....: %s\n", humanizeUnix(token.ExpiresAt))
+		fmt.Printf("Token.......: %s\n", token.Token)
+		fmt.Println("\nThis token will not be displayed again.")
+	},
+}
+
+var revokeAPITokenCmd = &cobra.Command{
+	Use:   "revoke <name|id>",
+	Short: "Revoke an API token by name or id.",
+	Long: `
+Revoke an API token by name or id. Requests made with it are rejected from then on.
+`,
+	PreRun: ensureAuth,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) < 1 {
+			writeStdErrAndExit("name or id is required.")
+		}
+		token := getAPIToken(args[0])
+		if !promptDelete(fmt.Sprintf("the API token %s", token.Name)) {
+			writeStdErrAndExit("Not revoking the API token.")
+		}
+		if err := apiClient().RevokeAPIToken(ctx, token.ID); err != nil {
+			writeStdErrAndExit(err.Error())
+		}
+		fmt.Printf("The API token %s was revoked.\n", token.Name)
+	},
+}
+
+func init() {
+	createAPITokenCmd.PersistentFlags().IntVar(&flAPITokenExpiresDays, "expires-days", 0, "Number of days until the token expires (default: never)")
+	apiTokenCmd.AddCommand(listAPITokenCmd)
+	apiTokenCmd.AddCommand(createAPITokenCmd)
+	apiTokenCmd.AddCommand(revokeAPITokenCmd)
+	RootCmd.AddCommand(apiTokenCmd)
+}
@@ -0,0 +1,288 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/stricture/hashstack-cli/client"
+	hashstack "github.com/stricture/hashstack-server-core-ng"
+)
+
+// tempAttackPrefix starts the title of every attack created for a single job by jobs add.
+const tempAttackPrefix = "hashstack-cli-"
+
+var flShowAllAttacks bool
+
+// tempAttackTitle returns the title used for the attack created by jobs add so
+// that it can be removed when the job is deleted.
+func tempAttackTitle(projectID, listID int64, name string) string {
+	return fmt.Sprintf("%s%d-%d-%s", tempAttackPrefix, projectID, listID, name)
+}
+
+func getAttacks() []hashstack.Attack {
+	attacks, err := apiClient().Attacks(ctx)
+	if err != nil {
+		writeStdErrAndExit(err.Error())
+	}
+	sort.Slice(attacks, func(i, j int) bool {
+		return attacks[i].Title < attacks[j].Title
+	})
+	return attacks
+}
+
+func getAttack(arg string) hashstack.Attack {
+	var (
+		attack hashstack.Attack
+		err    error
+	)
+	i, converr := strconv.Atoi(arg)
+	if converr != nil {
+		attack, err = apiClient().AttackByTitle(ctx, arg)
+	} else {
+		attack, err = apiClient().Attack(ctx, int64(i))
+	}
+	if err != nil {
+		writeStdErrAndExit(err.Error())
+	}
+	return attack
+}
+
+// ensureAttackTitle exits if an attack with title already exists.
+func ensureAttackTitle(title string) {
+	if strings.HasPrefix(title, tempAttackPrefix) {
+		writeStdErrAndExit(fmt.Sprintf("Attack names may not start with %s.", tempAttackPrefix))
+	}
+	_, err := apiClient().AttackByTitle(ctx, title)
+	if err == nil {
+		writeStdErrAndExit(fmt.Sprintf("An attack named %s already exists.", title))
+	}
+	if _, ok := err.(*client.NotFoundError); !ok {
+		writeStdErrAndExit(err.Error())
+	}
+}
+
+// filesByID maps file ids to files for each kind of file referenced by an attack.
+func filesByID() map[client.FileKind]map[int64]hashstack.File {
+	byID := make(map[client.FileKind]map[int64]hashstack.File)
+	for _, kind := range []client.FileKind{client.WordlistFile, client.RuleFile, client.HCStatFile} {
+		files, err := apiClient().Files(ctx, kind)
+		if err != nil {
+			writeStdErrAndExit(err.Error())
+		}
+		byID[kind] = make(map[int64]hashstack.File)
+		for _, f := range files {
+			byID[kind][f.ID] = f
+		}
+	}
+	return byID
+}
+
+// fileNames maps file ids to filenames for each kind of file referenced by an attack.
+func fileNames() map[client.FileKind]map[int64]string {
+	names := make(map[client.FileKind]map[int64]string)
+	for kind, files := range filesByID() {
+		names[kind] = make(map[int64]string)
+		for id, f := range files {
+			names[kind][id] = f.Filename
+		}
+	}
+	return names
+}
+
+func displayAttack(isMulti bool, attack hashstack.Attack) {
+	if isStructuredOutput() {
+		renderOutput(attack)
+		return
+	}
+	fmt.Printf("ID...............: %d\n", attack.ID)
+	fmt.Printf("Name.............: %s\n", attack.Title)
+	fmt.Printf("Steps............: %d\n", len(attack.Steps))
+	if isMulti {
+		return
+	}
+	names := fileNames()
+	steps := attack.Steps
+	sort.Slice(steps, func(i, j int) bool {
+		return steps[i].IDX < steps[j].IDX
+	})
+	for i, s := range steps {
+		fmt.Println()
+		fmt.Printf("Step.%d.Mode......: %d (%s)\n", i+1, s.AttackMode, attackModeNames[s.AttackMode])
+		if s.WordlistID != 0 {
+			fmt.Printf("Step.%d.Wordlist..: %s\n", i+1, names[client.WordlistFile][s.WordlistID])
+		}
+		if s.WordlistCombinationID != 0 {
+			fmt.Printf("Step.%d.Wordlist..: %s\n", i+1, names[client.WordlistFile][s.WordlistCombinationID])
+		}
+		if s.RuleID != 0 {
+			fmt.Printf("Step.%d.Rules.....: %s\n", i+1, names[client.RuleFile][s.RuleID])
+		}
+		if s.RuleBufLeft != "" {
+			fmt.Printf("Step.%d.Rule.Left.: %s\n", i+1, s.RuleBufLeft)
+		}
+		if s.RuleBufRight != "" {
+			fmt.Printf("Step.%d.Rule.Right: %s\n", i+1, s.RuleBufRight)
+		}
+		if s.Mask != "" {
+			fmt.Printf("Step.%d.Mask......: %s\n", i+1, s.Mask)
+		}
+		if s.MarkovHCStatFileID != 0 {
+			fmt.Printf("Step.%d.Markov....: %s\n", i+1, names[client.HCStatFile][s.MarkovHCStatFileID])
+		}
+		for n, charset := range []string{s.CustomCharset1, s.CustomCharset2, s.CustomCharset3, s.CustomCharset4} {
+			if charset != "" {
+				fmt.Printf("Step.%d.Charset.%d.: %s\n", i+1, n+1, charset)
+			}
+		}
+	}
+}
+
+func displayAttacks(attacks []hashstack.Attack) {
+	var named []hashstack.Attack
+	for _, a := range attacks {
+		if flShowAllAttacks || !strings.HasPrefix(a.Title, tempAttackPrefix) {
+			named = append(named, a)
+		}
+	}
+	if isStructuredOutput() {
+		renderOutput(named)
+		return
+	}
+	if len(named) < 1 {
+		fmt.Printf("There are no attacks. Use 'hashstack attacks add' to create one.\n\n")
+		return
+	}
+	for _, a := range named {
+		displayAttack(true, a)
+		fmt.Println()
+	}
+}
+
+// cloneSteps converts the steps of an existing attack into steps for a new attack.
+func cloneSteps(steps []hashstack.AttackStep) []client.AttackStep {
+	var out []client.AttackStep
+	for _, s := range steps {
+		out = append(out, client.AttackStep{
+			IDX:                   s.IDX,
+			AttackMode:            s.AttackMode,
+			WordlistID:            s.WordlistID,
+			WordlistCombinationID: s.WordlistCombinationID,
+			RuleID:                s.RuleID,
+			RuleBufLeft:           s.RuleBufLeft,
+			RuleBufRight:          s.RuleBufRight,
+			Mask:                  s.Mask,
+			IsHexCharset:          s.IsHexCharset,
+			MarkovThreshold:       s.MarkovThreshold,
+			MarkovHCStatFileID:    s.MarkovHCStatFileID,
+			CustomCharset1:        s.CustomCharset1,
+			CustomCharset2:        s.CustomCharset2,
+			CustomCharset3:        s.CustomCharset3,
+			CustomCharset4:        s.CustomCharset4,
+		})
+	}
+	return out
+}
+
+var attackCmd = &cobra.Command{
+	Use:   "attacks [name|id]",
+	Short: "Display a list of saved attacks (-h or --help for subcommands).",
+	Long: `
+Displays a list of saved attacks. If a name or id is provided, the steps for that specific attack will be displayed.
+Additional subcommands are available.
+
+Saved attacks can be used to start a job against any list with 'hashstack jobs add --attack <name|id>'. Attacks
+created by 'hashstack jobs add' for a single job are hidden unless --all is provided.
+`,
+	PreRun: ensureAuth,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) > 0 {
+			displayAttack(false, getAttack(args[0]))
+			return
+		}
+		displayAttacks(getAttacks())
+	},
+}
+
+var addAttackCmd = &cobra.Command{
+	Use:   "add <name> <plan_file>",
+	Short: "Add a new attack with the provided name from a plan file.",
+	Long: `
+Add a new attack with the provided name from a plan file. The plan file uses the same TOML, YAML, or JSON
+format as 'hashstack jobs add --plan'.
+`,
+	PreRun: ensureAuth,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) < 2 {
+			writeStdErrAndExit("name and plan_file are required.")
+		}
+		plan, err := loadAttackPlan(args[1])
+		if err != nil {
+			writeStdErrAndExit(err.Error())
+		}
+		ensureAttackTitle(args[0])
+		c := apiClient()
+		steps, err := plan.attackSteps(c)
+		if err != nil {
+			writeStdErrAndExit(err.Error())
+		}
+		attack, err := c.CreateAttack(ctx, client.AttackRequest{Title: args[0], Steps: steps})
+		if err != nil {
+			writeStdErrAndExit(err.Error())
+		}
+		displayAttack(false, attack)
+	},
+}
+
+var cloneAttackCmd = &cobra.Command{
+	Use:    "clone <name|id> <new_name>",
+	Short:  "Copy an attack to a new attack with the provided name.",
+	Long:   "Copy an attack to a new attack with the provided name.",
+	PreRun: ensureAuth,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) < 2 {
+			writeStdErrAndExit("name|id and new_name are required.")
+		}
+		source := getAttack(args[0])
+		ensureAttackTitle(args[1])
+		req := client.AttackRequest{
+			Title: args[1],
+			Steps: cloneSteps(source.Steps),
+		}
+		attack, err := apiClient().CreateAttack(ctx, req)
+		if err != nil {
+			writeStdErrAndExit(err.Error())
+		}
+		displayAttack(false, attack)
+	},
+}
+
+var delAttackCmd = &cobra.Command{
+	Use:    "delete <name|id>",
+	Short:  "Delete an attack by name or id.",
+	Long:   "Delete an attack by name or id.",
+	PreRun: ensureAuth,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) < 1 {
+			writeStdErrAndExit("name|id is required.")
+		}
+		attack := getAttack(args[0])
+		if ok := promptDelete("this attack"); !ok {
+			writeStdErrAndExit("Not deleting attack.")
+		}
+		if err := apiClient().DeleteAttack(ctx, attack.ID); err != nil {
+			writeStdErrAndExit(err.Error())
+		}
+		fmt.Println("The attack was deleted successfully.")
+	},
+}
+
+func init() {
+	attackCmd.PersistentFlags().BoolVar(&flShowAllAttacks, "all", false, "Include attacks created for a single job")
+	attackCmd.AddCommand(addAttackCmd)
+	attackCmd.AddCommand(cloneAttackCmd)
+	attackCmd.AddCommand(delAttackCmd)
+	RootCmd.AddCommand(attackCmd)
+}
@@ -0,0 +1,175 @@
+package cmd
+
+import (
+	"bufio"
+	"compress/bzip2"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/stricture/hashstack-cli/client"
+)
+
+// Supported values for --compress.
+const (
+	compressAuto = "auto"
+	compressNone = "none"
+)
+
+var flCompress string
+
+// decompressors maps the extension of a compressed local file to a function
+// that returns a reader of its contents. The reader must be closed once it is
+// no longer used.
+var decompressors = map[string]func(io.Reader) (io.ReadCloser, error){
+	".gz": func(r io.Reader) (io.ReadCloser, error) {
+		return gzip.NewReader(r)
+	},
+	".zst": func(r io.Reader) (io.ReadCloser, error) {
+		d, err := zstd.NewReader(r, zstd.WithDecoderConcurrency(1))
+		if err != nil {
+			return nil, err
+		}
+		return d.IOReadCloser(), nil
+	},
+	".bz2": func(r io.Reader) (io.ReadCloser, error) {
+		return ioutil.NopCloser(bzip2.NewReader(r)), nil
+	},
+}
+
+// extEncodings maps the extension of a compressed local file to the content
+// encoding it is compressed with, so that it can be sent without being
+// compressed again when the server accepts that encoding.
+var extEncodings = map[string]string{
+	".gz":  client.EncodingGzip,
+	".zst": client.EncodingZstd,
+}
+
+func validateCompress() {
+	switch flCompress {
+	case compressAuto, compressNone, client.EncodingGzip, client.EncodingZstd:
+	default:
+		writeStdErrAndExit(fmt.Sprintf("Unsupported compression %s. Use auto, none, gzip, or zstd.", flCompress))
+	}
+}
+
+// uploadEncoding returns the encoding to compress uploads of kind with, based on
+// --compress and the encodings accepted by the server. An empty string means
+// the upload is sent uncompressed.
+func uploadEncoding(c *client.Client, kind client.FileKind) string {
+	if flCompress == compressNone {
+		return ""
+	}
+	encodings, err := c.UploadEncodings(ctx, kind)
+	if err != nil {
+		debug(fmt.Sprintf("Error: %s", err.Error()))
+		return ""
+	}
+	preferred := []string{client.EncodingZstd, client.EncodingGzip}
+	if flCompress != compressAuto {
+		preferred = []string{flCompress}
+	}
+	for _, p := range preferred {
+		for _, e := range encodings {
+			if e == p {
+				debug(fmt.Sprintf("Upload: compressing with %s", p))
+				return p
+			}
+		}
+	}
+	debug("Upload: the server does not accept compressed uploads")
+	return ""
+}
+
+// uploadSource reads the contents of a local file to upload, decompressing it
+// when the file ends in .gz, .zst, or .bz2. Compressed files can only be read
+// forwards, so seeking backwards starts over from the beginning of the file.
+type uploadSource struct {
+	file *os.File
+	// name is the filename used on the server, without any compression extension.
+	name string
+	// encoding is the content encoding of the local file, if it has one.
+	encoding   string
+	decompress func(io.Reader) (io.ReadCloser, error)
+	// progress, when set, receives every byte read from the local file.
+	progress io.Writer
+	r        io.Reader
+	// dec is the decompressor reading the file, closed when the file is read again.
+	dec io.ReadCloser
+	pos int64
+}
+
+func openUploadSource(filename string) (*uploadSource, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	s := &uploadSource{
+		file: file,
+		name: filepath.Base(file.Name()),
+	}
+	ext := strings.ToLower(filepath.Ext(s.name))
+	if fn, ok := decompressors[ext]; ok {
+		s.decompress = fn
+		s.encoding = extEncodings[ext]
+		s.name = strings.TrimSuffix(s.name, filepath.Ext(s.name))
+	}
+	if err := s.seek(0); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// seek moves the source to offset bytes into the uncompressed contents.
+func (s *uploadSource) seek(offset int64) error {
+	if s.decompress == nil || offset == 0 || offset < s.pos {
+		start := offset
+		if s.decompress != nil {
+			start = 0
+		}
+		if _, err := s.file.Seek(start, io.SeekStart); err != nil {
+			return err
+		}
+		var raw io.Reader = s.file
+		if s.progress != nil {
+			raw = io.TeeReader(s.file, s.progress)
+		}
+		s.closeDecoder()
+		s.r, s.pos = raw, start
+		if s.decompress != nil {
+			dec, err := s.decompress(bufio.NewReader(raw))
+			if err != nil {
+				return err
+			}
+			s.r, s.dec = dec, dec
+		}
+	}
+	n, err := io.CopyN(ioutil.Discard, s.r, offset-s.pos)
+	s.pos += n
+	return err
+}
+
+func (s *uploadSource) Read(p []byte) (int, error) {
+	n, err := s.r.Read(p)
+	s.pos += int64(n)
+	return n, err
+}
+
+// closeDecoder releases the decompressor of the previous read of the file.
+func (s *uploadSource) closeDecoder() {
+	if s.dec != nil {
+		s.dec.Close()
+		s.dec = nil
+	}
+}
+
+func (s *uploadSource) Close() error {
+	s.closeDecoder()
+	return s.file.Close()
+}
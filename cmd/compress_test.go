@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"compress/gzip"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/stricture/hashstack-cli/client"
+)
+
+func TestUploadSource(t *testing.T) {
+	Convey("Given a gzip compressed wordlist", t, func() {
+		dir, err := ioutil.TempDir("", "hashstack")
+		So(err, ShouldBeNil)
+		defer os.RemoveAll(dir)
+		filename := filepath.Join(dir, "words.txt.gz")
+		fh, err := os.Create(filename)
+		So(err, ShouldBeNil)
+		w := gzip.NewWriter(fh)
+		w.Write([]byte("password\nletmein\nhunter2\n"))
+		w.Close()
+		fh.Close()
+
+		src, err := openUploadSource(filename)
+		So(err, ShouldBeNil)
+		defer src.Close()
+
+		Convey("The server filename does not include the extension", func() {
+			So(src.name, ShouldEqual, "words.txt")
+		})
+
+		Convey("Seeking forwards and backwards reads the uncompressed contents", func() {
+			buf := make([]byte, 7)
+			So(src.seek(9), ShouldBeNil)
+			src.Read(buf)
+			So(string(buf), ShouldEqual, "letmein")
+			So(src.seek(0), ShouldBeNil)
+			src.Read(buf)
+			So(string(buf), ShouldEqual, "passwor")
+		})
+
+		Convey("The file is sent without being compressed again when the server accepts gzip", func() {
+			So(src.encoding, ShouldEqual, client.EncodingGzip)
+			var filename, contents string
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				zr, err := gzip.NewReader(r.Body)
+				if err != nil || r.Header.Get("Content-Encoding") != "gzip" {
+					w.WriteHeader(400)
+					return
+				}
+				_, params, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+				part, err := multipart.NewReader(zr, params["boundary"]).NextPart()
+				if err != nil {
+					w.WriteHeader(400)
+					return
+				}
+				data, _ := ioutil.ReadAll(part)
+				filename, contents = part.FileName(), string(data)
+			}))
+			defer ts.Close()
+			uploadFileMultipart(client.New(ts.URL, ""), client.WordlistFile, client.EncodingGzip, src, 25)
+			So(filename, ShouldEqual, "words.txt")
+			So(contents, ShouldEqual, "password\nletmein\nhunter2\n")
+		})
+	})
+}
@@ -0,0 +1,227 @@
+package cmd
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/gosuri/uitable"
+	"github.com/spf13/cobra"
+)
+
+// profileView is a profile along with whether it is active and logged in.
+type profileView struct {
+	Name      string `json:"name"`
+	ServerURL string `json:"server_url"`
+	Insecure  bool   `json:"insecure"`
+	Active    bool   `json:"active"`
+	LoggedIn  bool   `json:"logged_in"`
+	// TokenStore is where the token is saved: keyring, file, or plaintext.
+	TokenStore string `json:"token_store"`
+}
+
+func profileNames() []string {
+	names := make([]string, 0, len(cfg.Profiles))
+	for name := range cfg.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+var contextCmd = &cobra.Command{
+	Use:   "context",
+	Short: "Manage the servers, or profiles, in the configuration file (-h or --help for subcommands).",
+	Long: `
+Manage the servers, or profiles, in the configuration file (-h or --help for subcommands).
+
+Each profile has its own server and session token. Commands use the profile given by --profile, then
+the HASHSTACK_PROFILE environment variable, then the profile selected with 'hashstack context use'.
+'hashstack login' saves the token to that profile without changing the others:
+
+    hashstack context add lab https://hashstack.lab.example.com
+    hashstack --profile lab login https://hashstack.lab.example.com alice
+    hashstack context use lab
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		contextListCmd.Run(cmd, args)
+	},
+}
+
+var contextListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Display every profile in the configuration file.",
+	Long: `
+Display every profile in the configuration file. The active profile is marked with *.
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		views := make([]profileView, 0, len(cfg.Profiles))
+		for _, name := range profileNames() {
+			p := cfg.Profiles[name]
+			v := profileView{Name: name, ServerURL: p.ServerURL, Insecure: p.Insecure, Active: name == activeProfile, TokenStore: p.TokenRef}
+			if p.Token != "" {
+				v.TokenStore = tokenStorePlaintext
+			}
+			v.LoggedIn = v.TokenStore != ""
+			views = append(views, v)
+		}
+		if isStructuredOutput() {
+			renderOutput(views)
+			return
+		}
+		if len(views) < 1 {
+			writeStdErrAndExit("There are no profiles. Use hashstack login or hashstack context add to create one.")
+		}
+		tbl := uitable.New()
+		tbl.AddRow("", "Name", "Server", "Logged In", "Token Store", "Insecure")
+		for _, v := range views {
+			active := ""
+			if v.Active {
+				active = "*"
+			}
+			tbl.AddRow(active, v.Name, v.ServerURL, yesNo(v.LoggedIn), v.TokenStore, yesNo(v.Insecure))
+		}
+		fmt.Println(tbl)
+	},
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
+
+var contextUseCmd = &cobra.Command{
+	Use:   "use <name>",
+	Short: "Select the profile used by commands that do not set --profile.",
+	Long: `
+Select the profile used by commands that do not set --profile or HASHSTACK_PROFILE.
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) < 1 {
+			writeStdErrAndExit("name is required.")
+		}
+		if _, ok := cfg.Profiles[args[0]]; !ok {
+			writeStdErrAndExit(fmt.Sprintf("There is no profile named %s. Use one of: %s.", args[0], strings.Join(profileNames(), ", ")))
+		}
+		cfg.CurrentProfile = args[0]
+		savecfg()
+		fmt.Printf("Using the profile %s.\n", args[0])
+	},
+}
+
+var contextAddCmd = &cobra.Command{
+	Use:   "add <name> <server_url>",
+	Short: "Add a profile for a server.",
+	Long: `
+Add a profile for a server. Use 'hashstack --profile <name> login' to save a session token to it.
+The --insecure, --ca-cert, --client-cert, --client-key, and --pin options are saved to the profile:
+
+    hashstack context add lab https://hashstack.lab.example.com --ca-cert lab-ca.pem \
+        --client-cert me.pem --client-key me.key
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) < 2 {
+			writeStdErrAndExit("name and server_url are required.")
+		}
+		name := args[0]
+		if _, ok := cfg.Profiles[name]; ok {
+			writeStdErrAndExit(fmt.Sprintf("There is already a profile named %s.", name))
+		}
+		if u, err := url.Parse(args[1]); err != nil || u.Scheme == "" || u.Host == "" {
+			writeStdErrAndExit("The provided URL is not valid.")
+		}
+		// The flags hold the active profile's options unless they were given.
+		p := &profile{ServerURL: strings.TrimRight(args[1], "/")}
+		flags := cmd.Flags()
+		p.Insecure = flags.Changed("insecure") && flInsecure
+		if flags.Changed("ca-cert") {
+			p.CACert = absPath(flCACert)
+		}
+		if flags.Changed("client-cert") {
+			p.ClientCert, p.ClientKey = absPath(flClientCert), absPath(flClientKey)
+		}
+		if flags.Changed("pin") {
+			p.Pins = flPins
+		}
+		cfg.Profiles[name] = p
+		if cfg.CurrentProfile == "" {
+			cfg.CurrentProfile = name
+		}
+		savecfg()
+		fmt.Printf("The profile %s was added. Use 'hashstack --profile %s login %s <username>' to log in.\n", name, name, args[1])
+	},
+}
+
+var contextRemoveCmd = &cobra.Command{
+	Use:   "remove <name>",
+	Short: "Remove a profile and its session token.",
+	Long: `
+Remove a profile and its session token.
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) < 1 {
+			writeStdErrAndExit("name is required.")
+		}
+		name := args[0]
+		if _, ok := cfg.Profiles[name]; !ok {
+			writeStdErrAndExit(fmt.Sprintf("There is no profile named %s.", name))
+		}
+		if ok := promptDelete(fmt.Sprintf("the profile %s", name)); !ok {
+			writeStdErrAndExit("Not removing the profile.")
+		}
+		deleteToken(name, cfg.Profiles[name])
+		delete(cfg.Profiles, name)
+		if cfg.CurrentProfile == name {
+			cfg.CurrentProfile = ""
+		}
+		savecfg()
+		fmt.Printf("The profile %s was removed.\n", name)
+	},
+}
+
+var contextMigrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Move session tokens stored in plain text to the keyring or encrypted file.",
+	Long: `
+Move the session token of every profile that is stored in plain text in the configuration file to the
+token store. Tokens are saved to the keyring (the Secret Service on Linux, the Keychain on macOS, or
+the Credential Manager on Windows). Where there is no keyring they are saved to a file next to the
+configuration file that is encrypted with a passphrase, which is read from HASHSTACK_PASSPHRASE or
+asked for. Set token_store in the configuration file to keyring or file to always use one store, or to
+plaintext to keep tokens in the configuration file.
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if cfg.TokenStore == tokenStorePlaintext {
+			writeStdErrAndExit(fmt.Sprintf("The token_store in %s is plaintext.", flCfgFile))
+		}
+		var moved int
+		for _, name := range profileNames() {
+			p := cfg.Profiles[name]
+			if p.Token == "" {
+				continue
+			}
+			refresh := p.RefreshToken
+			saveToken(name, p, p.Token)
+			saveRefreshToken(name, p, refresh)
+			fmt.Printf("The session token for the profile %s was moved to the %s.\n", name, p.TokenRef)
+			moved++
+		}
+		if moved == 0 {
+			fmt.Println("There are no session tokens stored in plain text.")
+			return
+		}
+		savecfg()
+	},
+}
+
+func init() {
+	contextCmd.AddCommand(contextMigrateCmd)
+	contextCmd.AddCommand(contextListCmd)
+	contextCmd.AddCommand(contextUseCmd)
+	contextCmd.AddCommand(contextAddCmd)
+	contextCmd.AddCommand(contextRemoveCmd)
+	RootCmd.AddCommand(contextCmd)
+}
@@ -0,0 +1,396 @@
+package cmd
+
+import (
+	"fmt"
+	"math/big"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	humanize "github.com/dustin/go-humanize"
+	"github.com/mattn/go-runewidth"
+	"github.com/nsf/termbox-go"
+	"github.com/stricture/hashstack-cli/client"
+	hashstack "github.com/stricture/hashstack-server-core-ng"
+)
+
+// dashboardRefresh is how often a dashboard fetches new data from the server.
+const dashboardRefresh = 5 * time.Second
+
+var flNoDashboard bool
+
+// dashboardScreen is the content of a full-screen dashboard.
+type dashboardScreen interface {
+	// update fetches the latest state from the server.
+	update()
+	draw(c *canvas)
+	// key handles a key press. It returns false to close the dashboard.
+	key(ev termbox.Event) bool
+}
+
+// useDashboard reports whether progress should be shown in a full-screen
+// dashboard rather than printed, which requires stdout to be a terminal.
+func useDashboard() bool {
+	if isStructuredOutput() || flNoDashboard {
+		return false
+	}
+	return isTerminal(os.Stdout)
+}
+
+// runDashboard shows s until a key handler closes it. The screen is redrawn
+// after every key press, when the terminal is resized, and after each update.
+func runDashboard(s dashboardScreen) {
+	if err := termbox.Init(); err != nil {
+		debug(fmt.Sprintf("Error: %s", err.Error()))
+		writeStdErrAndExit("There was an error starting the dashboard. Use --no-dashboard to print progress instead.")
+	}
+	beforeExit = termbox.Close
+	// termbox and the event loop below read the terminal, so the password can
+	// not be asked for when the session expires.
+	noPasswordPrompt = true
+	defer func() {
+		beforeExit = nil
+		noPasswordPrompt = false
+		termbox.Close()
+	}()
+	events := make(chan termbox.Event)
+	go func() {
+		for {
+			events <- termbox.PollEvent()
+		}
+	}()
+	ticker := time.NewTicker(dashboardRefresh)
+	defer ticker.Stop()
+	s.update()
+	for {
+		termbox.Clear(termbox.ColorDefault, termbox.ColorDefault)
+		w, h := termbox.Size()
+		s.draw(&canvas{w: w, h: h})
+		termbox.Flush()
+		select {
+		case ev := <-events:
+			switch ev.Type {
+			case termbox.EventKey:
+				if ev.Key == termbox.KeyCtrlC || !s.key(ev) {
+					return
+				}
+			case termbox.EventError:
+				debug(fmt.Sprintf("Error: %s", ev.Err.Error()))
+				return
+			}
+		case <-ticker.C:
+			s.update()
+		}
+	}
+}
+
+// canvas writes lines of text to the terminal from the top down. Text that
+// does not fit is cut off.
+type canvas struct {
+	w, h int
+	y    int
+}
+
+// text writes s at column x of the current line and returns the next column.
+func (c *canvas) text(x int, fg, bg termbox.Attribute, s string) int {
+	for _, r := range s {
+		if x >= c.w || c.y >= c.h {
+			break
+		}
+		termbox.SetCell(x, c.y, r, fg, bg)
+		x += runewidth.RuneWidth(r)
+	}
+	return x
+}
+
+// line writes a line of text and moves to the next line.
+func (c *canvas) line(fg termbox.Attribute, format string, a ...interface{}) {
+	c.text(0, fg, termbox.ColorDefault, fmt.Sprintf(format, a...))
+	c.y++
+}
+
+// highlight writes a line of text across the full width in reverse video.
+func (c *canvas) highlight(format string, a ...interface{}) {
+	s := fmt.Sprintf(format, a...)
+	if pad := c.w - runewidth.StringWidth(s); pad > 0 {
+		s += strings.Repeat(" ", pad)
+	}
+	c.text(0, termbox.ColorDefault|termbox.AttrReverse, termbox.ColorDefault, s)
+	c.y++
+}
+
+// bar writes label followed by a progress bar that fills the rest of the line.
+func (c *canvas) bar(label string, percent float64) {
+	x := c.text(0, termbox.ColorDefault, termbox.ColorDefault, label)
+	suffix := fmt.Sprintf(" %6.2f%%", percent)
+	width := c.w - x - len(suffix) - 2
+	if width > 0 {
+		filled := int(float64(width) * percent / 100)
+		if filled > width {
+			filled = width
+		}
+		x = c.text(x, termbox.ColorDefault, termbox.ColorDefault, "[")
+		x = c.text(x, termbox.ColorGreen, termbox.ColorDefault, strings.Repeat("=", filled))
+		x = c.text(x, termbox.ColorDefault, termbox.ColorDefault, strings.Repeat(" ", width-filled)+"]")
+	}
+	c.text(x, termbox.ColorDefault, termbox.ColorDefault, suffix)
+	c.y++
+}
+
+// footer writes the key help and a status message on the last line.
+func (c *canvas) footer(help, message string) {
+	c.y = c.h - 1
+	if message != "" {
+		c.highlight(" %s", message)
+		return
+	}
+	c.highlight(" %s", help)
+}
+
+// deviceKey identifies a device of an agent.
+type deviceKey struct {
+	agentID  int64
+	deviceID int64
+}
+
+// deviceView is a device along with its agent and the speed it reports for a job.
+type deviceView struct {
+	hashstack.Device
+	Hostname string
+	Speed    uint64
+}
+
+// agentDevices maps every device of agents to its agent's hostname and state.
+func agentDevices(agents []hashstack.Agent) map[deviceKey]deviceView {
+	devices := make(map[deviceKey]deviceView)
+	for _, a := range agents {
+		for _, d := range a.Devices {
+			devices[deviceKey{a.ID, d.ID}] = deviceView{Device: d, Hostname: a.Hostname}
+		}
+	}
+	return devices
+}
+
+// deviceSpeeds returns the speed of each device working on tasks. Devices
+// that have not reported recently are left out.
+func deviceSpeeds(tasks []hashstack.Task) map[deviceKey]uint64 {
+	speeds := make(map[deviceKey]uint64)
+	for _, task := range tasks {
+		for _, m := range task.Micros {
+			if time.Now().Add(-2*time.Minute).Unix() > m.Status.UpdatedAt {
+				continue
+			}
+			var speed uint64
+			if m.Status.SpeedMS > 0 {
+				speed = uint64(m.Status.SpeedCnt * 1000 / m.Status.SpeedMS)
+			}
+			speeds[deviceKey{m.AgentID, m.DeviceID}] += speed
+		}
+	}
+	return speeds
+}
+
+// jobDevices returns the devices working on the tasks of a job with the speed of each.
+func jobDevices(tasks []hashstack.Task, devices map[deviceKey]deviceView) []deviceView {
+	var views []deviceView
+	for key, speed := range deviceSpeeds(tasks) {
+		d, ok := devices[key]
+		if !ok {
+			d = deviceView{Hostname: fmt.Sprintf("agent %d", key.agentID), Device: hashstack.Device{ID: key.deviceID}}
+		}
+		d.Speed = speed
+		views = append(views, d)
+	}
+	sort.Slice(views, func(i, j int) bool {
+		if views[i].Hostname != views[j].Hostname {
+			return views[i].Hostname < views[j].Hostname
+		}
+		return views[i].ID < views[j].ID
+	})
+	return views
+}
+
+// taskPercent returns how much of the task's keyspace is complete.
+func taskPercent(task hashstack.Task) float64 {
+	total, ok := new(big.Int).SetString(task.Keyspace, 10)
+	if !ok {
+		return 0
+	}
+	complete, ok := new(big.Int).SetString(task.KeyspaceCompleted, 10)
+	if !ok {
+		return 0
+	}
+	return bigPercentOf(complete, total)
+}
+
+// drawDevices writes a table of devices.
+func drawDevices(c *canvas, devices []deviceView) {
+	c.line(termbox.AttrBold, "%-20s %-24s %12s %6s %6s %6s", "Host", "Device", "Speed", "Temp", "Fan", "Load")
+	for _, d := range devices {
+		c.line(termbox.ColorDefault, "%-20.20s %-24.24s %12s %5dC %5d%% %5d%%", d.Hostname, d.Name, formatHashRate(d.Speed), d.Temperature, d.FanSpeed, d.Load)
+	}
+}
+
+// jobControl applies the keyboard shortcuts shared by the dashboards to a job.
+type jobControl struct {
+	// deleting is the job whose deletion is waiting to be confirmed. It is
+	// kept so that the job that was named is deleted even if the list of
+	// jobs is refreshed before the answer.
+	deleting *hashstack.Job
+}
+
+// confirming reports whether the next key answers a delete prompt.
+func (jc *jobControl) confirming() bool {
+	return jc.deleting != nil
+}
+
+const jobControlHelp = "p pause  s start  +/- priority  d delete"
+
+// key handles a key press for job. It returns a message describing the
+// result, whether the key was handled, and whether the job was deleted. The
+// answer to a delete prompt applies to the job the prompt named, not job.
+func (jc *jobControl) key(job hashstack.Job, ev termbox.Event) (message string, handled, deleted bool) {
+	if jc.deleting != nil {
+		pending := *jc.deleting
+		jc.deleting = nil
+		if ev.Ch != 'y' && ev.Ch != 'Y' {
+			return "The job was not deleted.", true, false
+		}
+		deleteJob(pending)
+		return fmt.Sprintf("The job %d was deleted.", pending.ID), true, true
+	}
+	update := client.JobUpdate{
+		Priority:            job.Priority,
+		MaxDedicatedDevices: job.MaxDedicatedDevices,
+		IsActive:            job.IsActive,
+	}
+	switch ev.Ch {
+	case 'p':
+		update.IsActive = false
+		message = "The job has been paused."
+	case 's':
+		update.IsActive = true
+		message = "The job has been started."
+	case '+':
+		if update.Priority < 100 {
+			update.Priority++
+		}
+		message = fmt.Sprintf("The job priority is now %d.", update.Priority)
+	case '-':
+		if update.Priority > 1 {
+			update.Priority--
+		}
+		message = fmt.Sprintf("The job priority is now %d.", update.Priority)
+	case 'd':
+		jc.deleting = &job
+		return fmt.Sprintf("Delete job %d (%s)? [yY/nN]", job.ID, job.Name), true, false
+	default:
+		return "", false, false
+	}
+	if err := apiClient().UpdateJob(ctx, job.ProjectID, job.ID, update); err != nil {
+		debug(fmt.Sprintf("Error: %s", err.Error()))
+		return err.Error(), true, false
+	}
+	return message, true, false
+}
+
+// jobDashboard is the full-screen view of a single job.
+type jobDashboard struct {
+	job     hashstack.Job
+	view    jobView
+	devices map[deviceKey]deviceView
+	// cracked is the number of cracked hashes when the dashboard was opened.
+	cracked  int64
+	updated  bool
+	message  string
+	control  jobControl
+	deleted  bool
+	finished bool
+}
+
+func (d *jobDashboard) update() {
+	d.job = getJob(d.job.ProjectID, d.job.ID)
+	d.view = newJobView(d.job)
+	agents, err := apiClient().Agents(ctx)
+	if err != nil {
+		debug(fmt.Sprintf("Error: %s", err.Error()))
+	}
+	d.devices = agentDevices(agents)
+	if !d.updated {
+		d.cracked = d.view.List.RecoveredCount
+		d.updated = true
+	}
+	if d.job.IsExhausted && !d.finished {
+		d.finished = true
+		d.message = "The job is finished. Lists may continue to be updated with recovered plains."
+	}
+}
+
+func (d *jobDashboard) draw(c *canvas) {
+	job, view := d.job, d.view
+	c.highlight(" Job %d: %s (%s)", job.ID, job.Name, view.Status)
+	c.y++
+	c.line(termbox.ColorDefault, "Hash.Mode...........: %d (%s)", view.Mode.HashMode, view.Mode.Algorithm)
+	c.line(termbox.ColorDefault, "Hash.Target.........: %s", view.List.Name)
+	c.line(termbox.ColorDefault, "Job.Priority........: %d", job.Priority)
+	c.line(termbox.ColorDefault, "Job.Cracked.........: %d/%d (%0.2f%%) hashes, %d new", view.List.RecoveredCount, view.List.DigestCount, view.CrackedPercent, view.List.RecoveredCount-d.cracked)
+	eta := "Undetermined"
+	if view.ETA > 0 {
+		eta = humanize.Time(time.Unix(view.ETA, 0))
+	}
+	c.line(termbox.ColorDefault, "Time.Estimated......: %s", eta)
+	c.line(termbox.ColorDefault, "Device.Speed........: %s on %d devices", formatHashRate(view.Speed), view.ActiveDevices)
+	c.bar("Job.Progress........: ", view.ProgressPercent)
+	c.y++
+	for i, task := range view.tasks {
+		c.bar(fmt.Sprintf("Task.%-3d............: ", i+1), taskPercent(task))
+	}
+	c.y++
+	drawDevices(c, jobDevices(view.tasks, d.devices))
+	if len(view.events) > 0 {
+		c.y++
+		c.line(termbox.AttrBold, "Errors (%d), see 'hashstack jobs errors %d %d'", len(view.events), job.ProjectID, job.ID)
+		for i := len(view.events) - 1; i >= 0 && c.y < c.h-1; i-- {
+			e := view.events[i]
+			host := fmt.Sprintf("agent %d", e.AgentID)
+			for k, dv := range d.devices {
+				if k.agentID == e.AgentID {
+					host = dv.Hostname
+					break
+				}
+			}
+			c.line(termbox.ColorRed, "%s %s: %s", humanize.Time(time.Unix(e.UpdatedAt, 0)), host, e.Buffer)
+		}
+	}
+	c.footer("q quit, the job will continue to run  "+jobControlHelp, d.message)
+}
+
+func (d *jobDashboard) key(ev termbox.Event) bool {
+	if !d.control.confirming() && (ev.Ch == 'q' || ev.Key == termbox.KeyEsc) {
+		return false
+	}
+	message, handled, deleted := d.control.key(d.job, ev)
+	if deleted {
+		d.deleted = true
+		return false
+	}
+	if handled {
+		d.message = message
+		if !d.control.confirming() {
+			d.update()
+		}
+	}
+	return true
+}
+
+// runJobDashboard shows a job in a full-screen dashboard.
+func runJobDashboard(job hashstack.Job) {
+	d := &jobDashboard{job: job}
+	runDashboard(d)
+	if d.deleted {
+		fmt.Println("The job was successfully deleted.")
+		return
+	}
+	fmt.Printf("The job will continue to run. Use 'hashstack jobs %d %d' to view it again.\n", job.ProjectID, job.ID)
+}
@@ -0,0 +1,121 @@
+package cmd
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nsf/termbox-go"
+	. "github.com/smartystreets/goconvey/convey"
+	hashstack "github.com/stricture/hashstack-server-core-ng"
+)
+
+func TestDashboardDevices(t *testing.T) {
+	Convey("Given a task with a current and a stale device", t, func() {
+		now := time.Now().Unix()
+		tasks := []hashstack.Task{{
+			Keyspace:          "400",
+			KeyspaceCompleted: "100",
+			Micros: []hashstack.Micro{
+				{AgentID: 1, DeviceID: 2, Status: hashstack.MicroStatus{SpeedCnt: 5000, SpeedMS: 100, UpdatedAt: now}},
+				{AgentID: 1, DeviceID: 3, Status: hashstack.MicroStatus{SpeedCnt: 5000, SpeedMS: 100, UpdatedAt: now - 600}},
+			},
+		}}
+		devices := agentDevices([]hashstack.Agent{{ID: 1, Hostname: "rig1", Devices: []hashstack.Device{{ID: 2, Name: "GPU"}}}})
+
+		Convey("Only the current device is shown with its speed", func() {
+			views := jobDevices(tasks, devices)
+			So(len(views), ShouldEqual, 1)
+			So(views[0].Hostname, ShouldEqual, "rig1")
+			So(views[0].Speed, ShouldEqual, 50000)
+		})
+
+		Convey("The task progress is computed from its keyspace", func() {
+			So(taskPercent(tasks[0]), ShouldEqual, 25)
+		})
+	})
+}
+
+func TestJobControlDelete(t *testing.T) {
+	Convey("Given a delete prompt for a job", t, func() {
+		var jc jobControl
+		message, handled, _ := jc.key(hashstack.Job{ID: 1, Name: "first"}, termbox.Event{Ch: 'd'})
+		So(handled, ShouldBeTrue)
+		So(message, ShouldContainSubstring, "Delete job 1")
+
+		Convey("The prompted job is kept when the selection changes", func() {
+			So(jc.confirming(), ShouldBeTrue)
+			So(jc.deleting.ID, ShouldEqual, 1)
+			message, _, deleted := jc.key(hashstack.Job{ID: 2, Name: "second"}, termbox.Event{Ch: 'n'})
+			So(deleted, ShouldBeFalse)
+			So(message, ShouldEqual, "The job was not deleted.")
+			So(jc.confirming(), ShouldBeFalse)
+		})
+	})
+}
+
+func TestTopDashboardUpdate(t *testing.T) {
+	Convey("Given a project with two running jobs", t, func() {
+		var (
+			failing bool
+			tasks   = make(map[string]int)
+		)
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if failing {
+				w.WriteHeader(502)
+				return
+			}
+			var v interface{}
+			switch r.URL.Path {
+			case "/api/stats":
+				v = hashstack.ClusterStats{ActiveJobCount: 2}
+			case "/api/agents":
+				v = []hashstack.Agent{}
+			case "/api/projects":
+				v = []hashstack.Project{{ID: 1, Name: "acme"}}
+			case "/api/projects/1/jobs":
+				v = []hashstack.Job{{ID: 1, ProjectID: 1, ListID: 5, IsActive: true}, {ID: 2, ProjectID: 1, ListID: 5, IsActive: true, CreatedAt: 1}}
+			case "/api/projects/1/lists":
+				v = []hashstack.List{{ID: 5, ProjectID: 1, Name: "ntlm.txt"}}
+			default:
+				if strings.HasSuffix(r.URL.Path, "/tasks") {
+					tasks[r.URL.Path]++
+				}
+				v = []interface{}{}
+			}
+			w.Header().Set("Content-Range", "0-1/1")
+			json.NewEncoder(w).Encode(v)
+		}))
+		defer ts.Close()
+		defer func(serverURL, token string) { flServerURL, flToken = serverURL, token }(flServerURL, flToken)
+		flServerURL, flToken, httpClient = ts.URL, "secret", nil
+		d := &topDashboard{cracked: make(map[int64]int64)}
+		d.update()
+		So(len(d.jobs), ShouldEqual, 2)
+		So(d.jobs[0].List.Name, ShouldEqual, "ntlm.txt")
+		So(tasks, ShouldResemble, map[string]int{"/api/projects/1/jobs/1/tasks": 1, "/api/projects/1/jobs/2/tasks": 1})
+
+		Convey("Later updates only fetch the tasks of the selected job", func() {
+			d.selected = 1
+			d.update()
+			So(d.selected, ShouldEqual, 1)
+			So(tasks, ShouldResemble, map[string]int{"/api/projects/1/jobs/1/tasks": 1, "/api/projects/1/jobs/2/tasks": 2})
+		})
+
+		Convey("A failed update is shown and the last jobs are kept", func() {
+			failing = true
+			d.update()
+			So(len(d.jobs), ShouldEqual, 2)
+			So(d.message, ShouldNotBeEmpty)
+
+			Convey("The error is cleared by the next update", func() {
+				failing = false
+				d.update()
+				So(d.message, ShouldBeEmpty)
+			})
+		})
+	})
+}
@@ -5,7 +5,14 @@ import (
 	"os"
 )
 
+// beforeExit, when set, is called before writeStdErrAndExit writes to the
+// terminal, e.g. to restore it from a full-screen dashboard.
+var beforeExit func()
+
 func writeStdErrAndExit(msg string) {
+	if beforeExit != nil {
+		beforeExit()
+	}
 	fmt.Fprintf(os.Stderr, fmt.Sprintf("%s\n", msg))
 	if !flDebug {
 		fmt.Fprintf(os.Stderr, "\nRunning with --debug will show additional context for this error.\n")
@@ -0,0 +1,213 @@
+package cmd
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	humanize "github.com/dustin/go-humanize"
+	"github.com/spf13/cobra"
+	"github.com/stricture/hashstack-cli/client"
+	"github.com/stricture/hashstack-cli/hcmask"
+	hashstack "github.com/stricture/hashstack-server-core-ng"
+)
+
+// stepEstimate is the keyspace of a single attack step.
+type stepEstimate struct {
+	Step       int    `json:"step"`
+	AttackMode int    `json:"attack_mode"`
+	Keyspace   string `json:"keyspace"`
+}
+
+// estimateView is the projected run time of an attack against a list.
+type estimateView struct {
+	Mode     hashstack.HashMode `json:"mode"`
+	Digests  int64              `json:"digests"`
+	Steps    []stepEstimate     `json:"steps"`
+	Keyspace string             `json:"keyspace"`
+	Devices  int                `json:"devices"`
+	Speed    uint64             `json:"speed"`
+	// Duration is in seconds. It is -1 when no devices have a benchmark for
+	// the mode, or the benchmarks could not be read.
+	Duration int64 `json:"duration"`
+}
+
+// stepKeyspace returns the number of candidates generated by step. files maps
+// file ids to files, which are used for their line counts.
+func stepKeyspace(step client.AttackStep, files map[client.FileKind]map[int64]hashstack.File) (*big.Int, error) {
+	lines := func(kind client.FileKind, id int64) (*big.Int, error) {
+		f, ok := files[kind][id]
+		if !ok {
+			return nil, fmt.Errorf("The %s file with id %d does not exist on the server.", kind, id)
+		}
+		return big.NewInt(f.Lines), nil
+	}
+	mask := func() (*big.Int, error) {
+		l := hcmask.Line{
+			Charsets: [4]string{step.CustomCharset1, step.CustomCharset2, step.CustomCharset3, step.CustomCharset4},
+			Mask:     step.Mask,
+		}
+		keyspace, err := l.MarkovKeyspace(step.IsHexCharset, step.MarkovThreshold)
+		if err != nil {
+			return nil, fmt.Errorf("The mask %s is not valid: %s.", step.Mask, err.Error())
+		}
+		return keyspace, nil
+	}
+	switch step.AttackMode {
+	case 0:
+		keyspace, err := lines(client.WordlistFile, step.WordlistID)
+		if err != nil || step.RuleID == 0 {
+			return keyspace, err
+		}
+		rules, err := lines(client.RuleFile, step.RuleID)
+		if err != nil {
+			return nil, err
+		}
+		return keyspace.Mul(keyspace, rules), nil
+	case 1:
+		left, err := lines(client.WordlistFile, step.WordlistID)
+		if err != nil {
+			return nil, err
+		}
+		right, err := lines(client.WordlistFile, step.WordlistCombinationID)
+		if err != nil {
+			return nil, err
+		}
+		return left.Mul(left, right), nil
+	case 3:
+		return mask()
+	case 6, 7:
+		words, err := lines(client.WordlistFile, step.WordlistID)
+		if err != nil {
+			return nil, err
+		}
+		keyspace, err := mask()
+		if err != nil {
+			return nil, err
+		}
+		return keyspace.Mul(keyspace, words), nil
+	}
+	return nil, fmt.Errorf("The attack-mode %d is not valid.", step.AttackMode)
+}
+
+// estimateJob computes the keyspace of steps and the time to run them against
+// lists using the benchmark speed of every cluster device for the hash mode.
+func estimateJob(c *client.Client, lists []hashstack.List, steps []client.AttackStep) estimateView {
+	view := estimateView{
+		Mode:     getMode(lists[0].HashMode),
+		Duration: -1,
+	}
+	for _, l := range lists {
+		view.Digests += l.DigestCount
+	}
+	files := filesByID()
+	total := big.NewInt(0)
+	for i, step := range steps {
+		keyspace, err := stepKeyspace(step, files)
+		if err != nil {
+			if len(steps) > 1 {
+				err = fmt.Errorf("Step %d: %s", i+1, err.Error())
+			}
+			writeStdErrAndExit(err.Error())
+		}
+		total.Add(total, keyspace)
+		view.Steps = append(view.Steps, stepEstimate{Step: i + 1, AttackMode: step.AttackMode, Keyspace: keyspace.String()})
+	}
+	view.Keyspace = total.String()
+
+	benchmarks, err := c.Benchmarks(ctx, view.Mode.HashMode)
+	if err != nil {
+		// The keyspace is still useful without a run time.
+		debug(fmt.Sprintf("Error: %s", err.Error()))
+		return view
+	}
+	for _, b := range benchmarks {
+		if b.Speed == 0 {
+			continue
+		}
+		view.Devices++
+		view.Speed += b.Speed
+	}
+	if view.Speed == 0 {
+		return view
+	}
+	// A job is run for each list in a group. For salted modes each candidate
+	// is checked once for every salt, assume every hash has its own.
+	work := new(big.Int).Mul(total, big.NewInt(int64(len(lists))))
+	if view.Mode.IsSalted && view.Digests > 1 {
+		work.Mul(total, big.NewInt(view.Digests))
+	}
+	seconds := work.Div(work, new(big.Int).SetUint64(view.Speed))
+	if seconds.IsInt64() {
+		view.Duration = seconds.Int64()
+	} else {
+		view.Duration = int64(^uint64(0) >> 1)
+	}
+	return view
+}
+
+func displayEstimate(view estimateView) {
+	if isStructuredOutput() {
+		renderOutput(view)
+		return
+	}
+	duration := "Unknown, no device benchmarks are available for this mode"
+	if view.Duration >= 0 {
+		duration = formatEstimate(view.Duration)
+	}
+	fmt.Printf("Hash.Type...........: %s\n", view.Mode.Algorithm)
+	fmt.Printf("Hash.Target.........: %d hashes\n", view.Digests)
+	if len(view.Steps) > 1 {
+		for _, s := range view.Steps {
+			fmt.Printf("Step.%-3d............: %s (%s)\n", s.Step, s.Keyspace, attackModeNames[s.AttackMode])
+		}
+	}
+	fmt.Printf("Keyspace............: %s\n", view.Keyspace)
+	fmt.Printf("Devices.............: %d\n", view.Devices)
+	fmt.Printf("Speed...............: %s\n", formatHashRate(view.Speed))
+	fmt.Printf("Time.Estimated......: %s\n", duration)
+	if view.Mode.IsSalted && view.Digests > 1 {
+		fmt.Println("\nThe mode is salted, the estimate assumes every hash has a unique salt.")
+	}
+	fmt.Println()
+}
+
+// formatEstimate returns seconds as a duration, e.g. "3 hours (ends Mon Jan 2 15:04:05 MST 2006)".
+func formatEstimate(seconds int64) string {
+	const maxDuration = int64(100 * 365 * 24 * time.Hour / time.Second)
+	if seconds > maxDuration {
+		return "More than 100 years"
+	}
+	end := time.Now().Add(time.Duration(seconds) * time.Second)
+	return fmt.Sprintf("%s (ends %s)", strings.TrimSpace(humanize.RelTime(time.Now(), end, "", "")), end.Format(time.UnixDate))
+}
+
+var estimateCmd = &cobra.Command{
+	Use:   "estimate <project_name|project_id> <list_name|list_id> <wordlist|mask>",
+	Short: "Estimate the keyspace and run time of a job without adding it.",
+	Long: `
+Estimate the keyspace and run time of a job without adding it. The arguments and flags are the same as
+'hashstack jobs add' without the job name, see 'hashstack jobs add --help'.
+
+The keyspace is computed locally from the masks, custom charsets, and the line counts of the wordlists and
+rule files on the server. The run time uses the benchmark speed of every device in the cluster for the hash
+mode of the list, so it assumes the job has the whole cluster to itself.
+
+Use 'hashstack jobs add --estimate' to see the estimate before adding a job.
+`,
+	PreRun: ensureAuth,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) < 2 || (flPlanFile == "" && flAttackName == "" && flMaskFile == "" && len(args) < 3) {
+			writeStdErrAndExit("Missing required argument.")
+		}
+		c := apiClient()
+		_, lists, _, steps := jobAttack(c, args[0], args[1], args[2:])
+		displayEstimate(estimateJob(c, lists, steps))
+	},
+}
+
+func init() {
+	addAttackFlags(estimateCmd.PersistentFlags())
+	RootCmd.AddCommand(estimateCmd)
+}
@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/stricture/hashstack-cli/client"
+	hashstack "github.com/stricture/hashstack-server-core-ng"
+)
+
+func TestStepKeyspace(t *testing.T) {
+	Convey("Given a wordlist with 1000 lines and a rule file with 64 rules", t, func() {
+		files := map[client.FileKind]map[int64]hashstack.File{
+			client.WordlistFile: {1: {ID: 1, Lines: 1000}},
+			client.RuleFile:     {2: {ID: 2, Lines: 64}},
+		}
+
+		Convey("Each attack mode multiplies its inputs", func() {
+			for _, c := range []struct {
+				step     client.AttackStep
+				keyspace string
+			}{
+				{client.AttackStep{AttackMode: 0, WordlistID: 1, RuleID: 2}, "64000"},
+				{client.AttackStep{AttackMode: 1, WordlistID: 1, WordlistCombinationID: 1}, "1000000"},
+				{client.AttackStep{AttackMode: 3, Mask: "?1?d", CustomCharset1: "?l?u"}, "520"},
+				{client.AttackStep{AttackMode: 3, Mask: "?l?l", MarkovThreshold: 5}, "25"},
+				{client.AttackStep{AttackMode: 6, WordlistID: 1, Mask: "?d?d"}, "100000"},
+			} {
+				keyspace, err := stepKeyspace(c.step, files)
+				So(err, ShouldBeNil)
+				So(keyspace.String(), ShouldEqual, c.keyspace)
+			}
+		})
+
+		Convey("Missing files are an error", func() {
+			_, err := stepKeyspace(client.AttackStep{AttackMode: 7, WordlistID: 3, Mask: "?d"}, files)
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
@@ -1,8 +1,13 @@
 package cmd
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"mime/multipart"
 	"os"
 	"path/filepath"
@@ -10,42 +15,257 @@ import (
 	"time"
 
 	"github.com/cheggaaa/pb"
+	humanize "github.com/dustin/go-humanize"
+	"github.com/stricture/hashstack-cli/client"
 	hashstack "github.com/stricture/hashstack-server-core-ng"
 )
 
-func uploadFile(path, filename string) {
-	file, err := os.Open(filename)
+const (
+	// uploadChunkSize is the number of bytes sent in each request of a resumable upload.
+	uploadChunkSize = 8 << 20
+	// uploadChunkRetries is the number of times a failed chunk is retried before giving up.
+	uploadChunkRetries = 5
+)
+
+// uploadState is saved in the uploads directory next to the configuration file
+// after every chunk so that an interrupted upload can be resumed. Size and
+// Offset refer to the uncompressed contents of the file.
+type uploadState struct {
+	ServerURL string          `json:"server_url"`
+	Kind      client.FileKind `json:"kind"`
+	Path      string          `json:"path"`
+	FileSize  int64           `json:"file_size"`
+	ModTime   int64           `json:"mod_time"`
+	Size      int64           `json:"size"`
+	Checksum  string          `json:"checksum"`
+	UploadID  string          `json:"upload_id"`
+	Offset    int64           `json:"offset"`
+}
+
+// uploadStatePath returns the location of the state for uploading path as kind to the current server.
+func uploadStatePath(kind client.FileKind, path string) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s|%s|%s", flServerURL, kind, path)))
+	return filepath.Join(filepath.Dir(flCfgFile), "uploads", hex.EncodeToString(sum[:8])+".json")
+}
+
+func loadUploadState(filename string) (uploadState, bool) {
+	var state uploadState
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return state, false
+	}
+	if err := json.Unmarshal(data, &state); err != nil {
+		debug(fmt.Sprintf("Error: %s", err.Error()))
+		return state, false
+	}
+	return state, true
+}
+
+func (s uploadState) save(filename string) {
+	data, err := json.Marshal(s)
+	if err != nil {
+		debug(fmt.Sprintf("Error: %s", err.Error()))
+		return
+	}
+	os.MkdirAll(filepath.Dir(filename), 0700)
+	if err := ioutil.WriteFile(filename, data, 0600); err != nil {
+		debug(fmt.Sprintf("Upload: unable to save state to %s", filename))
+		debug(fmt.Sprintf("Error: %s", err.Error()))
+	}
+}
+
+// matches returns true when the state was created for the file as it is now.
+func (s uploadState) matches(stat os.FileInfo) bool {
+	return s.ServerURL == flServerURL && s.FileSize == stat.Size() && s.ModTime == stat.ModTime().Unix() && s.UploadID != ""
+}
+
+// fileChecksum returns the checksum and size of the uncompressed contents of src.
+func fileChecksum(src *uploadSource, filesize int64) (string, int64) {
+	fmt.Printf("Calculating the checksum for %s\n", filepath.Base(src.file.Name()))
+	bar := pb.New64(filesize).SetUnits(pb.U_BYTES)
+	bar.SetWidth(80)
+	bar.Start()
+	src.progress = bar
+	defer func() {
+		src.progress = nil
+	}()
+	h := sha256.New()
+	if err := src.seek(0); err != nil {
+		debug(fmt.Sprintf("Error: %s", err.Error()))
+		writeStdErrAndExit("There was an error reading the file provided")
+	}
+	size, err := io.Copy(h, src)
+	if err != nil {
+		debug(fmt.Sprintf("Error: %s", err.Error()))
+		writeStdErrAndExit("There was an error reading the file provided")
+	}
+	bar.Finish()
+	return hex.EncodeToString(h.Sum(nil)), size
+}
+
+// uploadFile uploads filename in chunks, resuming a previous upload of the same
+// file when one exists. Servers that do not support resumable uploads receive
+// the file in a single request. Files ending in .gz, .zst, or .bz2 are
+// decompressed and uploads are compressed when the server supports it.
+func uploadFile(kind client.FileKind, filename string) {
+	validateCompress()
+	src, err := openUploadSource(filename)
 	if err != nil {
 		debug(fmt.Sprintf("File: Open local file %s", filename))
 		debug(fmt.Sprintf("Error: %s", err.Error()))
 		writeStdErrAndExit("There was an error while trying to open the file provided")
 	}
-	defer file.Close()
+	defer src.Close()
 
-	filename = filepath.Base(file.Name())
-	filestat, err := file.Stat()
+	filestat, err := src.file.Stat()
 	if err != nil {
 		debug(fmt.Sprintf("Error: %s", err.Error()))
 		writeStdErrAndExit("There was an error getting stats for the file provided")
 	}
-	filesize := filestat.Size()
+	path, err := filepath.Abs(src.file.Name())
+	if err != nil {
+		path = src.file.Name()
+	}
+	statePath := uploadStatePath(kind, path)
+	debug(fmt.Sprintf("Upload: state file %s", statePath))
+
+	c := apiClient()
+	encoding := uploadEncoding(c, kind)
+	state, ok := loadUploadState(statePath)
+	resume := ok && state.matches(filestat)
+	if resume {
+		upload, err := c.Upload(ctx, kind, state.UploadID)
+		switch err.(type) {
+		case nil:
+			state.Offset = upload.Offset
+			fmt.Printf("Resuming the upload of %s at %s\n", src.name, humanize.Bytes(uint64(state.Offset)))
+		case *client.NotFoundError:
+			debug("Upload: the previous upload no longer exists on the server")
+			resume = false
+		default:
+			writeStdErrAndExit(err.Error())
+		}
+	}
+	if !resume {
+		checksum, size := fileChecksum(src, filestat.Size())
+		req := client.UploadRequest{
+			Filename: src.name,
+			Size:     size,
+			Checksum: checksum,
+		}
+		upload, err := c.CreateUpload(ctx, kind, req)
+		if err != nil {
+			if _, ok := err.(*client.NotFoundError); !ok {
+				writeStdErrAndExit(err.Error())
+			}
+			debug("Upload: the server does not support resumable uploads")
+			uploadFileMultipart(c, kind, encoding, src, size)
+			displayUploadedFile(kind, src.name)
+			return
+		}
+		state = uploadState{
+			ServerURL: flServerURL,
+			Kind:      kind,
+			Path:      path,
+			FileSize:  filestat.Size(),
+			ModTime:   filestat.ModTime().Unix(),
+			Size:      size,
+			Checksum:  checksum,
+			UploadID:  upload.ID,
+			Offset:    upload.Offset,
+		}
+		state.save(statePath)
+	}
+
+	uploadChunks(c, encoding, src, &state, statePath)
+
+	upload, err := c.CompleteUpload(ctx, kind, state.UploadID)
+	if err != nil {
+		writeStdErrAndExit(fmt.Sprintf("%s\n\nRun the same command again to resume the upload.", err.Error()))
+	}
+	os.Remove(statePath)
+	if upload.Checksum != state.Checksum {
+		debug(fmt.Sprintf("Upload: local checksum %s, server checksum %s", state.Checksum, upload.Checksum))
+		c.DeleteUpload(ctx, kind, state.UploadID)
+		writeStdErrAndExit("The checksum of the file on the server does not match the local file. Please upload the file again.")
+	}
+	displayUploadedFile(kind, src.name)
+}
+
+// uploadChunks sends src from state.Offset to the end, saving the state after
+// each chunk. Failed chunks are retried from the offset reported by the server.
+func uploadChunks(c *client.Client, encoding string, src *uploadSource, state *uploadState, statePath string) {
+	bar := pb.New64(state.Size).SetUnits(pb.U_BYTES)
+	bar.SetWidth(80)
+	bar.Set64(state.Offset)
+	bar.Start()
 
+	attempt := 0
+	buf := make([]byte, uploadChunkSize)
+	for state.Offset < state.Size {
+		size := state.Size - state.Offset
+		if size > uploadChunkSize {
+			size = uploadChunkSize
+		}
+		if src.pos != state.Offset {
+			if err := src.seek(state.Offset); err != nil {
+				debug(fmt.Sprintf("Error: %s", err.Error()))
+				writeStdErrAndExit("There was an error reading the file provided")
+			}
+		}
+		chunk := buf[:size]
+		if _, err := io.ReadFull(src, chunk); err != nil {
+			debug(fmt.Sprintf("Error: %s", err.Error()))
+			writeStdErrAndExit("There was an error reading the file provided")
+		}
+		upload, err := c.UploadChunk(ctx, state.Kind, state.UploadID, state.Offset, state.Size, chunk, encoding)
+		if err == nil && upload.Offset <= state.Offset {
+			err = new(client.InvalidResponseError)
+		}
+		if err != nil {
+			attempt++
+			debug(fmt.Sprintf("Upload: chunk at offset %d failed (attempt %d of %d)", state.Offset, attempt, uploadChunkRetries))
+			if attempt >= uploadChunkRetries {
+				writeStdErrAndExit(fmt.Sprintf("%s\n\nRun the same command again to resume the upload.", err.Error()))
+			}
+			time.Sleep(time.Duration(attempt) * 2 * time.Second)
+			if upload, err = c.Upload(ctx, state.Kind, state.UploadID); err == nil {
+				state.Offset = upload.Offset
+			}
+			bar.Set64(state.Offset)
+			continue
+		}
+		attempt = 0
+		state.Offset = upload.Offset
+		bar.Set64(state.Offset)
+		state.save(statePath)
+	}
+	bar.Finish()
+	fmt.Println("")
+}
+
+// uploadFileMultipart streams the entire contents of src to the server in a single multipart request.
+func uploadFileMultipart(c *client.Client, kind client.FileKind, encoding string, src *uploadSource, size int64) {
+	if encoding != "" && encoding == src.encoding {
+		uploadFileEncoded(c, kind, src)
+		return
+	}
 	pipeOut, pipeIn := io.Pipe()
 	writer := multipart.NewWriter(pipeIn)
 
-	bar := pb.New64(filesize).SetUnits(pb.U_BYTES)
+	bar := pb.New64(size).SetUnits(pb.U_BYTES)
 	bar.SetWidth(80)
 
 	wg := &sync.WaitGroup{}
 	wg.Add(1)
 	go func() {
-		if _, err = postMultipart(path, writer.FormDataContentType(), pipeOut); err != nil {
+		if err := c.UploadFile(ctx, kind, writer.FormDataContentType(), encoding, pipeOut); err != nil {
 			writeStdErrAndExit(err.Error())
 		}
 		wg.Done()
 	}()
 
-	part, err := writer.CreateFormFile("file", filename)
+	part, err := writer.CreateFormFile("file", src.name)
 	if err != nil {
 		debug("Local HTTP: There was an error preparing the multipart form")
 		debug(fmt.Sprintf("Error: %s", err.Error()))
@@ -56,8 +276,12 @@ func uploadFile(path, filename string) {
 
 	bar.Start()
 
-	if _, err = io.Copy(out, file); err != nil {
-		debug(fmt.Sprintf("File: There was an error reading the file %s", filename))
+	if err := src.seek(0); err != nil {
+		debug(fmt.Sprintf("Error: %s", err.Error()))
+		writeStdErrAndExit("There was an error reading the file provided")
+	}
+	if _, err = io.Copy(out, src); err != nil {
+		debug(fmt.Sprintf("File: There was an error reading the file %s", src.name))
 		debug(fmt.Sprintf("Error: %s", err.Error()))
 		writeStdErrAndExit("There was an error reading the file provided")
 	}
@@ -68,15 +292,66 @@ func uploadFile(path, filename string) {
 
 	bar.Finish()
 	fmt.Println("")
+}
+
+// uploadFileEncoded sends the local file of src as it is when it is already
+// compressed with the encoding used for the upload. The multipart headers around
+// it are compressed as separate gzip members or zstd frames, which the server
+// reads as a single stream.
+func uploadFileEncoded(c *client.Client, kind client.FileKind, src *uploadSource) {
+	debug(fmt.Sprintf("Upload: sending %s without compressing it again", filepath.Base(src.file.Name())))
+	var form bytes.Buffer
+	writer := multipart.NewWriter(&form)
+	if _, err := writer.CreateFormFile("file", src.name); err != nil {
+		debug("Local HTTP: There was an error preparing the multipart form")
+		debug(fmt.Sprintf("Error: %s", err.Error()))
+		writeStdErrAndExit("There was an error while preparing the request")
+	}
+	head, err := client.Encode(src.encoding, form.Bytes())
+	if err != nil {
+		debug(fmt.Sprintf("Error: %s", err.Error()))
+		writeStdErrAndExit("There was an error while preparing the request")
+	}
+	form.Reset()
+	writer.Close()
+	tail, err := client.Encode(src.encoding, form.Bytes())
+	if err != nil {
+		debug(fmt.Sprintf("Error: %s", err.Error()))
+		writeStdErrAndExit("There was an error while preparing the request")
+	}
+
+	filestat, err := src.file.Stat()
+	if err != nil {
+		debug(fmt.Sprintf("Error: %s", err.Error()))
+		writeStdErrAndExit("There was an error getting stats for the file provided")
+	}
+	if _, err := src.file.Seek(0, io.SeekStart); err != nil {
+		debug(fmt.Sprintf("Error: %s", err.Error()))
+		writeStdErrAndExit("There was an error reading the file provided")
+	}
+	src.closeDecoder()
+
+	bar := pb.New64(filestat.Size()).SetUnits(pb.U_BYTES)
+	bar.SetWidth(80)
+	bar.Start()
+	body := io.MultiReader(bytes.NewReader(head), io.TeeReader(src.file, bar), bytes.NewReader(tail))
+	if err := c.UploadEncodedFile(ctx, kind, writer.FormDataContentType(), src.encoding, body); err != nil {
+		writeStdErrAndExit(err.Error())
+	}
+	bar.Finish()
+	fmt.Println("")
+}
+
+func displayUploadedFile(kind client.FileKind, filename string) {
 	time.Sleep(5 * time.Second)
 
 	f := hashstack.File{Filename: filename}
-	switch path {
-	case "/api/wordlists":
+	switch kind {
+	case client.WordlistFile:
 		displayWordlist(f)
-	case "/api/rules":
+	case client.RuleFile:
 		displayRule(f)
-	case "/api/hcstat":
+	case client.HCStatFile:
 		displayHCStat(f)
 	}
 }
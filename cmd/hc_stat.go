@@ -7,20 +7,26 @@ import (
 
 	humanize "github.com/dustin/go-humanize"
 	"github.com/spf13/cobra"
+	"github.com/stricture/hashstack-cli/client"
 	hashstack "github.com/stricture/hashstack-server-core-ng"
 )
 
 func getHCStat(f *hashstack.File) {
-	path := fmt.Sprintf("/api/hcstat?filename=%s", f.Filename)
-	if err := getJSON(path, &f); err != nil {
+	file, err := apiClient().HCStat(ctx, f.Filename)
+	if err != nil {
 		writeStdErrAndExit(err.Error())
 	}
+	*f = file
 }
 
 func displayHCStat(f hashstack.File) {
 	if f.ID == 0 {
 		getHCStat(&f)
 	}
+	if isStructuredOutput() {
+		renderOutput(f)
+		return
+	}
 	fmt.Printf("Name.............: %s\n", f.Filename)
 	fmt.Printf("Size.............: %s\n", humanize.Bytes(uint64(f.Size)))
 	fmt.Printf("Last Modified....: %s\n", humanize.Time(time.Unix(f.UpdatedAt, 0)))
@@ -28,13 +34,17 @@ func displayHCStat(f hashstack.File) {
 }
 
 func displayHCStats() {
-	var hcstats []hashstack.File
-	if err := getRangeJSON("/api/hcstat", &hcstats); err != nil {
+	hcstats, err := apiClient().Files(ctx, client.HCStatFile)
+	if err != nil {
 		writeStdErrAndExit(err.Error())
 	}
 	sort.Slice(hcstats, func(i, j int) bool {
 		return hcstats[i].Filename < hcstats[j].Filename
 	})
+	if isStructuredOutput() {
+		renderOutput(hcstats)
+		return
+	}
 	for _, w := range hcstats {
 		displayHCStat(w)
 	}
@@ -60,16 +70,23 @@ hcstat files can be used in jobs. Additional subcommands are available to add an
 }
 
 var addHCStatCmd = &cobra.Command{
-	Use:    "add <file>",
-	Short:  "Upload the provided file to the server.",
-	Long:   "Upload the provided file to the server.",
+	Use:   "add <file>",
+	Short: "Upload the provided file to the server.",
+	Long: `
+Upload the provided file to the server. Files are sent in chunks and the checksum of the
+server copy is verified once the upload finishes. If the upload is interrupted, run the same
+command again to resume it.
+
+Files ending in .gz, .zst, or .bz2 are decompressed before they are sent. Uploads are compressed
+with zstd or gzip when the server supports it. Use --compress to choose the compression.
+`,
 	PreRun: ensureAuth,
 	Run: func(cmd *cobra.Command, args []string) {
 		if len(args) < 1 {
 			writeStdErrAndExit("file is required")
 		}
 		file := args[0]
-		uploadFile("/api/hcstat", file)
+		uploadFile(client.HCStatFile, file)
 	},
 }
 
@@ -78,8 +95,7 @@ func deleteHCStat(filename string) {
 		Filename: filename,
 	}
 	getHCStat(&f)
-	path := fmt.Sprintf("/api/hcstat/%d", f.ID)
-	if err := deleteHTTP(path); err != nil {
+	if err := apiClient().DeleteFile(ctx, client.HCStatFile, f.ID); err != nil {
 		writeStdErrAndExit(err.Error())
 	}
 	fmt.Println("hcstat file deleted successfully.")
@@ -102,6 +118,7 @@ var delHCStatCmd = &cobra.Command{
 }
 
 func init() {
+	addHCStatCmd.PersistentFlags().StringVar(&flCompress, "compress", compressAuto, "upload compression: auto, none, gzip, or zstd")
 	hcstatCmd.AddCommand(addHCStatCmd)
 	hcstatCmd.AddCommand(delHCStatCmd)
 	RootCmd.AddCommand(hcstatCmd)
@@ -0,0 +1,141 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/gosuri/uitable"
+	"github.com/segmentio/go-prompt"
+	"github.com/spf13/cobra"
+	"github.com/stricture/hashstack-cli/hashfmt"
+)
+
+// identifySamples is the number of lines from the start of a file used to identify its hash mode.
+const identifySamples = 1000
+
+// candidateView is a hash mode that matched a file along with whether the server supports it.
+type candidateView struct {
+	Mode      int    `json:"mode"`
+	Name      string `json:"name"`
+	Matched   int    `json:"matched"`
+	Samples   int    `json:"samples"`
+	Prefixed  bool   `json:"prefixed"`
+	Supported bool   `json:"supported"`
+
+	candidate hashfmt.Candidate
+}
+
+// identifyFile returns the ranked candidate modes for the hashes in filename.
+func identifyFile(filename string) []candidateView {
+	file, err := os.Open(filename)
+	if err != nil {
+		debug(fmt.Sprintf("Error: %s", err.Error()))
+		writeStdErrAndExit("There was an error opening the provided file.")
+	}
+	defer file.Close()
+	samples, err := hashfmt.Sample(file, identifySamples)
+	if err != nil {
+		debug(fmt.Sprintf("Error: %s", err.Error()))
+		writeStdErrAndExit("There was an error reading the provided file.")
+	}
+	if len(samples) < 1 {
+		writeStdErrAndExit("The provided file does not contain any hashes.")
+	}
+	hashModes, err := apiClient().HashModes(ctx)
+	if err != nil {
+		writeStdErrAndExit(err.Error())
+	}
+	supported := make(map[int]bool)
+	for _, m := range hashModes {
+		supported[m.HashMode] = m.IsSupported
+	}
+	views := make([]candidateView, 0)
+	for _, c := range hashfmt.Identify(samples) {
+		views = append(views, candidateView{
+			Mode:      c.Mode,
+			Name:      c.Name,
+			Matched:   c.Matched,
+			Samples:   c.Samples,
+			Prefixed:  c.Prefixed,
+			Supported: supported[c.Mode],
+			candidate: c,
+		})
+	}
+	return views
+}
+
+// identifyMode picks the best supported mode for filename, asking the user to
+// choose when several modes match equally well.
+func identifyMode(filename string) int {
+	var choices []candidateView
+	for _, v := range identifyFile(filename) {
+		if !v.Supported {
+			continue
+		}
+		if len(choices) > 0 && !v.candidate.Equivalent(choices[0].candidate) {
+			break
+		}
+		choices = append(choices, v)
+	}
+	if len(choices) < 1 {
+		writeStdErrAndExit("Unable to identify a supported hash mode for the provided file. Use 'hashstack identify' for details.")
+	}
+	best := choices[0]
+	if len(choices) > 1 {
+		var names []string
+		for _, c := range choices {
+			names = append(names, fmt.Sprintf("%d (%s)", c.Mode, c.Name))
+		}
+		best = choices[prompt.Choose("Several hash modes match the provided file. Which mode should be used?", names)]
+	}
+	fmt.Printf("Hash mode %d (%s) matched %d of %d sampled lines.\n\n", best.Mode, best.Name, best.Matched, best.Samples)
+	return best.Mode
+}
+
+var identifyCmd = &cobra.Command{
+	Use:   "identify <file>",
+	Short: "Display the hash modes that match the hashes in a file.",
+	Long: `
+Display the hash modes that match the hashes in a file, best match first. Up to 1000 lines from the start
+of the file are checked against the formats of common modes and the modes supported by the server.
+
+Use 'hashstack lists add <project_name|project_id> auto <file>' to add a list using the best match.
+`,
+	PreRun: ensureAuth,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) < 1 {
+			writeStdErrAndExit("file is required.")
+		}
+		views := identifyFile(args[0])
+		if isStructuredOutput() {
+			renderOutput(views)
+			return
+		}
+		if len(views) < 1 {
+			writeStdErrAndExit("The hashes in the provided file do not match any known mode.")
+		}
+		var prefixed bool
+		tbl := uitable.New()
+		tbl.AddRow("#", "Name", "Matched", "Supported")
+		for _, v := range views {
+			supported := "yes"
+			if !v.Supported {
+				supported = "no"
+			}
+			name := v.Name
+			if v.Prefixed {
+				name += " *"
+				prefixed = true
+			}
+			tbl.AddRow(v.Mode, name, fmt.Sprintf("%d/%d", v.Matched, v.Samples), supported)
+		}
+		fmt.Println(tbl)
+		if prefixed {
+			fmt.Println("\n* Matched after removing the field before the first ':', e.g. a filename or username.")
+		}
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(identifyCmd)
+}
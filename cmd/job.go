@@ -1,17 +1,20 @@
 package cmd
 
 import (
-	"encoding/json"
 	"fmt"
 	"io"
 	"math/big"
 	"os"
 	"sort"
 	"strconv"
+	"strings"
 	"time"
 
 	humanize "github.com/dustin/go-humanize"
+	"github.com/segmentio/go-prompt"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/stricture/hashstack-cli/client"
 	hashstack "github.com/stricture/hashstack-server-core-ng"
 )
 
@@ -30,41 +33,57 @@ var (
 	flCustomCharset2      string
 	flCustomCharset3      string
 	flCustomCharset4      string
+	flPlanFile            string
+	flAttackName          string
+	flMaskFile            string
+	flEstimate            bool
 )
 
 func getEvents(projectID, jobID int64) []hashstack.AgentEvent {
-	var events []hashstack.AgentEvent
-	path := fmt.Sprintf("/api/projects/%d/jobs/%d/events", projectID, jobID)
-	if err := getJSON(path, &events); err != nil {
+	events, err := apiClient().Events(ctx, projectID, jobID)
+	if err != nil {
 		writeStdErrAndExit(err.Error())
 	}
 	return events
 }
 
 func getTasks(projectID, jobID int64) []hashstack.Task {
-	var tasks []hashstack.Task
-	path := fmt.Sprintf("/api/projects/%d/jobs/%d/tasks", projectID, jobID)
 	for {
-		if err := getJSON(path, &tasks); err != nil {
+		tasks, err := apiClient().Tasks(ctx, projectID, jobID)
+		if err != nil {
 			time.Sleep(5 * time.Second)
 			continue
 		}
-		break
+		return tasks
 	}
-	return tasks
 }
 
 func deleteJob(job hashstack.Job) {
-	path := fmt.Sprintf("/api/projects/%d/jobs/%d", job.ProjectID, job.ID)
-	if err := deleteHTTP(path); err != nil {
+	c := apiClient()
+	if err := c.DeleteJob(ctx, job.ProjectID, job.ID); err != nil {
 		writeStdErrAndExit(err.Error())
 	}
-	var attack hashstack.Attack
-	if err := getJSON(fmt.Sprintf("/api/attacks/%d", job.AttackID), &attack); err != nil {
+	attack, err := c.Attack(ctx, job.AttackID)
+	if err != nil {
 		writeStdErrAndExit(err.Error())
 	}
-	if attack.Title == fmt.Sprintf("hashstack-cli-%d-%d-%s", job.ProjectID, job.ListID, job.Name) {
-		deleteHTTP(fmt.Sprintf("/api/attacks/%d", job.AttackID))
+	if attack.Title == tempAttackTitle(job.ProjectID, job.ListID, job.Name) {
+		c.DeleteAttack(ctx, job.AttackID)
+		return
+	}
+	// The temporary attack of a batch is shared, so it is deleted with the last job.
+	if strings.HasPrefix(attack.Title, tempAttackTitle(job.ProjectID, 0, "")) {
+		jobs, err := c.Jobs(ctx, job.ProjectID)
+		if err != nil {
+			debug(fmt.Sprintf("Error: %s", err.Error()))
+			return
+		}
+		for _, j := range jobs {
+			if j.AttackID == job.AttackID {
+				return
+			}
+		}
+		c.DeleteAttack(ctx, job.AttackID)
 	}
 }
 
@@ -73,27 +92,43 @@ var (
 	jobListCrackedCount int64
 )
 
-func displayJob(w io.Writer, job hashstack.Job) {
-	status := "Running"
-	if job.IsExhausted {
-		status = "Finished"
-	}
-	if !job.IsActive && !job.IsExhausted {
-		status = "Paused"
-	}
+// jobView is a job along with the values computed from its list and tasks.
+type jobView struct {
+	hashstack.Job
+	Status            string             `json:"status"`
+	List              hashstack.List     `json:"list"`
+	Mode              hashstack.HashMode `json:"mode"`
+	CrackedPercent    float64            `json:"cracked_percent"`
+	Keyspace          string             `json:"keyspace"`
+	KeyspaceCompleted string             `json:"keyspace_completed"`
+	ProgressPercent   float64            `json:"progress_percent"`
+	ErrorCount        int                `json:"error_count"`
+	ActiveDevices     int                `json:"active_devices"`
+	Speed             uint64             `json:"speed"`
+	ETA               int64              `json:"eta"`
+
+	events []hashstack.AgentEvent
+	tasks  []hashstack.Task
+}
+
+func newJobView(job hashstack.Job) jobView {
 	list := hashstack.List{
 		ProjectID: job.ProjectID,
 		ID:        job.ListID,
 	}
 	getListByID(&list)
 	mode := getMode(list.HashMode)
-	tasks := getTasks(job.ProjectID, job.ID)
-	events := getEvents(job.ProjectID, job.ID)
-	for _, e := range events {
-		if e.CreatedAt >= agentEventTrackTime {
-			agentEventTrackTime = time.Now().Unix()
-			fmt.Fprintf(w, "There was an error returned from an agent: %s!\n\n", e.Buffer)
-		}
+	return computeJobView(job, list, mode, getTasks(job.ProjectID, job.ID), getEvents(job.ProjectID, job.ID))
+}
+
+// computeJobView calculates the progress, speed, and ETA of job from its tasks.
+func computeJobView(job hashstack.Job, list hashstack.List, mode hashstack.HashMode, tasks []hashstack.Task, events []hashstack.AgentEvent) jobView {
+	status := "Running"
+	if job.IsExhausted {
+		status = "Finished"
+	}
+	if !job.IsActive && !job.IsExhausted {
+		status = "Paused"
 	}
 
 	var (
@@ -162,14 +197,49 @@ func displayJob(w io.Writer, job hashstack.Job) {
 		}
 	}
 
+	view := jobView{
+		Job:               job,
+		Status:            status,
+		List:              list,
+		Mode:              mode,
+		CrackedPercent:    percentOf(int(list.RecoveredCount), int(list.DigestCount)),
+		Keyspace:          bigkeyspace.String(),
+		KeyspaceCompleted: bigkeyspacecomplete.String(),
+		ErrorCount:        len(events),
+		ActiveDevices:     activeDevices,
+		Speed:             bigspeed.Uint64(),
+		events:            events,
+		tasks:             tasks,
+	}
+	view.ProgressPercent = bigPercentOf(bigkeyspacecomplete, bigkeyspace)
+	if bigeta.Int64() > 0 {
+		view.ETA = time.Now().Add(time.Duration(bigeta.Int64()) * time.Second).Unix()
+	}
+	return view
+}
+
+func displayJob(w io.Writer, job hashstack.Job) {
+	view := newJobView(job)
+	if isStructuredOutput() {
+		renderOutput(view)
+		return
+	}
+	list := view.List
+	for _, e := range view.events {
+		if e.CreatedAt >= agentEventTrackTime {
+			agentEventTrackTime = time.Now().Unix()
+			fmt.Fprintf(w, "There was an error returned from an agent: %s!\n\n", e.Buffer)
+		}
+	}
+
 	var (
 		timeETA      = "Undetermined"
 		timeStarted  = "Has not started"
 		timeFinished = "Unknown"
 		timeCreated  string
 	)
-	if bigeta.Int64() > 0 {
-		etaUnix := time.Now().Add(time.Duration(bigeta.Int64()) * time.Second)
+	if view.ETA > 0 {
+		etaUnix := time.Unix(view.ETA, 0)
 		timeETA = fmt.Sprintf("%s (%s)", etaUnix.Format(time.UnixDate), humanize.Time(etaUnix))
 	}
 
@@ -186,27 +256,27 @@ func displayJob(w io.Writer, job hashstack.Job) {
 	createdAtUnix := time.Unix(job.CreatedAt, 0)
 	timeCreated = fmt.Sprintf("%s (%s)", createdAtUnix.Format(time.UnixDate), humanize.Time(createdAtUnix))
 
-	strspeed := formatHashRate(bigspeed.Uint64())
-	liststat := fmt.Sprintf("%d/%d (%0.2f%%) hashes", list.RecoveredCount, list.DigestCount, percentOf(int(list.RecoveredCount), int(list.DigestCount)))
+	strspeed := formatHashRate(view.Speed)
+	liststat := fmt.Sprintf("%d/%d (%0.2f%%) hashes", list.RecoveredCount, list.DigestCount, view.CrackedPercent)
 
 	fmt.Fprintf(w, "Job.ID..............: %d\n", job.ID)
 	fmt.Fprintf(w, "Job.Priority........: %d\n", job.Priority)
 	fmt.Fprintf(w, "Job.Name............: %s\n", job.Name)
-	fmt.Fprintf(w, "Job.Status..........: %s\n", status)
+	fmt.Fprintf(w, "Job.Status..........: %s\n", view.Status)
 	fmt.Fprintf(w, "Job.Cracked.........: %s\n", liststat)
-	fmt.Fprintf(w, "Job.Progress........: %s/%s (%0.2f%%)\n", bigkeyspacecomplete.String(), bigkeyspace.String(), bigPercentOf(bigkeyspacecomplete, bigkeyspace))
-	fmt.Fprintf(w, "Job.Errors..........: %d errors\n", len(events))
-	fmt.Fprintf(w, "Hash.Mode...........: %d (%s)\n", mode.HashMode, mode.Algorithm)
+	fmt.Fprintf(w, "Job.Progress........: %s/%s (%0.2f%%)\n", view.KeyspaceCompleted, view.Keyspace, view.ProgressPercent)
+	fmt.Fprintf(w, "Job.Errors..........: %d errors\n", view.ErrorCount)
+	fmt.Fprintf(w, "Hash.Mode...........: %d (%s)\n", view.Mode.HashMode, view.Mode.Algorithm)
 	fmt.Fprintf(w, "Hash.Target.........: %s\n", list.Name)
 	fmt.Fprintf(w, "Time.Created........: %s\n", timeCreated)
 	fmt.Fprintf(w, "Time.Started........: %s\n", timeStarted)
-	if status != "Running" {
+	if view.Status != "Running" {
 		fmt.Fprintf(w, "Time.Finished.......: %s\n", timeFinished)
 		return
 	}
 	fmt.Fprintf(w, "Time.Estimated......: %s\n", timeETA)
 	fmt.Fprintf(w, "Device.Max..........: %d\n", job.MaxDedicatedDevices)
-	fmt.Fprintf(w, "Device.Active.......: %d\n", activeDevices)
+	fmt.Fprintf(w, "Device.Active.......: %d\n", view.ActiveDevices)
 	fmt.Fprintf(w, "Device.Speed........: %s\n", strspeed)
 
 	if jobListCrackedCount == 0 && list.RecoveredCount != 0 {
@@ -219,28 +289,38 @@ func displayJob(w io.Writer, job hashstack.Job) {
 }
 
 func getJob(projectID, jobID int64) hashstack.Job {
-	var job hashstack.Job
-	path := fmt.Sprintf("/api/projects/%d/jobs/%d", projectID, jobID)
-	if err := getJSON(path, &job); err != nil {
+	job, err := apiClient().Job(ctx, projectID, jobID)
+	if err != nil {
 		writeStdErrAndExit(err.Error())
 	}
 	return job
 }
 
-func displayJobs(p hashstack.Project) {
-	path := fmt.Sprintf("/api/projects/%d/jobs", p.ID)
-	var jobs []hashstack.Job
-	if err := getRangeJSON(path, &jobs); err != nil {
+func getProjectJobs(p hashstack.Project) []hashstack.Job {
+	jobs, err := apiClient().Jobs(ctx, p.ID)
+	if err != nil {
 		writeStdErrAndExit(err.Error())
 	}
-	if len(jobs) < 1 {
-		fmt.Printf("There are no jobs for this project.\n\n")
-		return
-	}
 	sort.Slice(jobs, func(i, j int) bool {
 		return jobs[i].CreatedAt < jobs[j].CreatedAt
 	})
+	return jobs
+}
 
+func displayJobs(p hashstack.Project) {
+	jobs := getProjectJobs(p)
+	if isStructuredOutput() {
+		views := make([]jobView, 0, len(jobs))
+		for _, j := range jobs {
+			views = append(views, newJobView(j))
+		}
+		renderOutput(views)
+		return
+	}
+	if len(jobs) < 1 {
+		fmt.Printf("There are no jobs for this project.\n\n")
+		return
+	}
 	for _, j := range jobs {
 		displayJob(os.Stdout, j)
 		fmt.Println()
@@ -253,12 +333,27 @@ var jobCmd = &cobra.Command{
 	Long: `
 Display a list of jobs for a project or attach to a job by id (-h or --help for subcommands). If no project is
 provided, then all jobs for all projects will be displayed.
+
+When attached to a job in a terminal, a full-screen dashboard shows the progress of each task, the speed,
+temperature, and fan speed of each device, newly cracked hashes, and agent errors. Press p to pause the job,
+s to start it, + or - to change its priority, d to delete it, or q to quit and leave it running. Use
+--no-dashboard to print the progress every 5 seconds instead.
 `,
 	PreRun: ensureAuth,
 	Run: func(cmd *cobra.Command, args []string) {
 		switch len(args) {
 		case 0:
 			projects := getProjects()
+			if isStructuredOutput() {
+				views := make([]jobView, 0)
+				for _, project := range projects {
+					for _, j := range getProjectJobs(project) {
+						views = append(views, newJobView(j))
+					}
+				}
+				renderOutput(views)
+				return
+			}
 			for _, project := range projects {
 				fmt.Printf("Project.ID......: %d\n", project.ID)
 				fmt.Printf("Project.Name....: %s\n", project.Name)
@@ -280,12 +375,6 @@ provided, then all jobs for all projects will be displayed.
 	},
 }
 
-type updateRequest struct {
-	Priority            int  `json:"priority"`
-	MaxDedicatedDevices int  `json:"max_dedicated_devices"`
-	IsActive            bool `json:"is_active"`
-}
-
 var pauseJobCmd = &cobra.Command{
 	Use:    "pause <project_name|project_id> <job_id>",
 	Short:  "Pauses a job by project_name|project_id and job_id.",
@@ -301,13 +390,12 @@ var pauseJobCmd = &cobra.Command{
 			writeStdErrAndExit("job_id is invalid")
 		}
 		job := getJob(project.ID, int64(i))
-		update := updateRequest{
+		update := client.JobUpdate{
 			Priority:            job.Priority,
 			MaxDedicatedDevices: job.MaxDedicatedDevices,
 			IsActive:            false,
 		}
-		path := fmt.Sprintf("/api/projects/%d/jobs/%d", project.ID, job.ID)
-		if _, err := patchJSON(path, &update); err != nil {
+		if err := apiClient().UpdateJob(ctx, project.ID, job.ID, update); err != nil {
 			writeStdErrAndExit(err.Error())
 		}
 		fmt.Println("The job has been paused.")
@@ -367,13 +455,12 @@ priority and/or max-devices.
 			writeStdErrAndExit("job_id is invalid")
 		}
 		job := getJob(project.ID, int64(i))
-		update := updateRequest{
+		update := client.JobUpdate{
 			Priority:            flPriority,
 			MaxDedicatedDevices: flMaxDedicatedDevices,
 			IsActive:            job.IsActive,
 		}
-		path := fmt.Sprintf("/api/projects/%d/jobs/%d", project.ID, job.ID)
-		if _, err := patchJSON(path, &update); err != nil {
+		if err := apiClient().UpdateJob(ctx, project.ID, job.ID, update); err != nil {
 			writeStdErrAndExit(err.Error())
 		}
 		fmt.Println("The job has been updated.")
@@ -418,53 +505,93 @@ var startJobCmd = &cobra.Command{
 			writeStdErrAndExit("The job_id provided is not valid.")
 		}
 		job := getJob(project.ID, int64(i))
-		update := updateRequest{
+		update := client.JobUpdate{
 			Priority:            job.Priority,
 			MaxDedicatedDevices: job.MaxDedicatedDevices,
 			IsActive:            true,
 		}
-		path := fmt.Sprintf("/api/projects/%d/jobs/%d", project.ID, job.ID)
-		if _, err := patchJSON(path, &update); err != nil {
+		if err := apiClient().UpdateJob(ctx, project.ID, job.ID, update); err != nil {
 			writeStdErrAndExit(err.Error())
 		}
 		fmt.Println("The job has been started.")
 	},
 }
 
-type jobRequest struct {
-	Name                string `json:"name"`
-	ListID              int64  `json:"list_id"`
-	AttackID            int64  `json:"attack_id"`
-	Priority            int    `json:"priority"`
-	MaxDedicatedDevices int    `json:"max_dedicated_devices"`
-	OpenCLVectorWidth   int    `json:"opencl_vector_width"`
+// startJob creates a temporary attack from steps and a job that runs it against list.
+func startJob(c *client.Client, project hashstack.Project, list hashstack.List, name string, steps []client.AttackStep) (hashstack.Job, error) {
+	attack := client.AttackRequest{
+		Title: tempAttackTitle(project.ID, list.ID, name),
+		Steps: steps,
+	}
+	plan, err := c.CreateAttack(ctx, attack)
+	if err != nil {
+		return hashstack.Job{}, err
+	}
+	debug("uploaded temporary attack plan")
+	job, err := createJob(c, project, list, name, plan.ID)
+	if err != nil {
+		c.DeleteAttack(ctx, plan.ID)
+	}
+	return job, err
 }
 
-type attackStep struct {
-	IDX                   int    `json:"idx"`
-	AttackMode            int    `json:"attack_mode"`
-	WordlistID            int64  `json:"wordlist_id"`
-	WordlistCombinationID int64  `json:"wordlist_combination_id"`
-	RuleID                int64  `json:"rule_id"`
-	RuleBufLeft           string `json:"rule_buf_left"`
-	RuleBufRight          string `json:"rule_buf_right"`
-	Mask                  string `json:"mask"`
-	IsHexCharset          bool   `json:"is_hex_charset"`
-	MarkovThreshold       int    `json:"markov_threshold"`
-	MarkovHCStatFileID    int64  `json:"markov_hc_stat_file_id"`
-	CustomCharset1        string `json:"custom_charset1"`
-	CustomCharset2        string `json:"custom_charset2"`
-	CustomCharset3        string `json:"custom_charset3"`
-	CustomCharset4        string `json:"custom_charset4"`
+// launchJobs creates a job for each list in a list group using either the
+// saved attack with attackID or a temporary attack from steps. A single job is
+// attached to, otherwise the new jobs are displayed.
+func launchJobs(c *client.Client, project hashstack.Project, lists []hashstack.List, name string, attackID int64, steps []client.AttackStep) {
+	var jobs []hashstack.Job
+	for i, list := range lists {
+		jobName := name
+		if len(lists) > 1 {
+			jobName = shardName(name, i)
+		}
+		var (
+			job hashstack.Job
+			err error
+		)
+		if attackID != 0 {
+			job, err = createJob(c, project, list, jobName, attackID)
+		} else {
+			job, err = startJob(c, project, list, jobName, steps)
+		}
+		if err != nil {
+			writeStdErrAndExit(err.Error())
+		}
+		jobs = append(jobs, job)
+	}
+	if len(jobs) == 1 {
+		statsJob(jobs[0])
+		return
+	}
+	if isStructuredOutput() {
+		renderOutput(jobs)
+		return
+	}
+	fmt.Printf("Created %d jobs, one for each list in the group.\n\n", len(jobs))
+	for i, job := range jobs {
+		fmt.Printf("Job.ID..............: %d\n", job.ID)
+		fmt.Printf("Job.Name............: %s\n", job.Name)
+		fmt.Printf("Hash.Target.........: %s\n", lists[i].Name)
+		fmt.Println()
+	}
+	fmt.Printf("Use 'hashstack jobs %d' to view their progress.\n", project.ID)
 }
 
-type attackRequest struct {
-	Title string       `json:"title"`
-	Steps []attackStep `json:"steps"`
+// createJob creates a job that runs the attack with attackID against list.
+func createJob(c *client.Client, project hashstack.Project, list hashstack.List, name string, attackID int64) (hashstack.Job, error) {
+	jobreq := client.JobRequest{
+		Name:                name,
+		ListID:              list.ID,
+		AttackID:            attackID,
+		Priority:            flPriority,
+		MaxDedicatedDevices: flMaxDedicatedDevices,
+		OpenCLVectorWidth:   flOpenCLVectorWidth,
+	}
+	return c.CreateJob(ctx, project.ID, jobreq)
 }
 
 var addJobCmd = &cobra.Command{
-	Use:   "add <project_name|project_id> <list_name|list_id> <name> <wordlist|mask>",
+	Use:   "add <project_name|project_id> <list_name|list_id|glob> <name> <wordlist|mask>",
 	Short: "Add a job for the provided project and list.",
 	Long: `Add a job for the provided project and list.
 
@@ -475,151 +602,147 @@ Attack Modes:
 3 | Brute-force
 6 | Hybrid Wordlist + Mask
 7 | Hybrid Mask + Wordlist
+
+Use --plan to run several steps as a single job. The plan file may be TOML, YAML, or JSON
+and contains an ordered list of steps. Files are referenced by name. For example:
+
+[[steps]]
+attack_mode = 0
+wordlist = "rockyou.txt"
+rules_file = "best64.rule"
+
+[[steps]]
+attack_mode = 6
+wordlist = "rockyou.txt"
+mask = "?d?d?d?d"
+
+[[steps]]
+attack_mode = 3
+mask = "?u?l?l?l?l?l?d?d"
+markov_hcstat = "hashcat.hcstat"
+
+Step keys: attack_mode, wordlist, combination_wordlist, rules_file, rule_left, rule_right, mask,
+hex_charset, markov_hcstat, markov_threshold, custom_charset1, custom_charset2, custom_charset3,
+and custom_charset4.
+
+Use --attack to run a saved attack by name or id. See 'hashstack attacks' for more information.
+
+Use -a 3 --mask-file to run every mask in an .hcmask file as a step of a single job, in the order they
+appear in the file. Custom charsets on a line of the file replace the --custom-charset flags for that mask.
+See 'hashstack masks generate' to create a mask file.
+
+If the list was split into a group of lists when it was added, a job is created for each list in the group.
+
+To add the same attack to many lists at once, give a glob such as 'client-*' as the list, or use --all-lists
+and leave out the list argument. --mode limits either to lists of one hash mode. A job named <name>-<list>
+is created for each matching list, every job shares one attack, and the results are shown as a table
+instead of attaching to a job:
+
+    hashstack jobs add acme 'dc*' rockyou -a 0 rockyou.txt --mode 1000
+    hashstack jobs add acme --all-lists quick -a 3 '?a?a?a?a?a?a'
+
+Use --estimate to display the keyspace and estimated run time, see 'hashstack estimate', and confirm
+before the job is added.
 `,
 	PreRun: ensureAuth,
 	Run: func(cmd *cobra.Command, args []string) {
-		if len(args) < 4 {
-			writeStdErrAndExit("Missing required argument.")
+		if flAllLists && len(args) > 0 {
+			args = append([]string{args[0], ""}, args[1:]...)
 		}
-		var (
-			name = args[2]
-		)
-		project := getProject(args[0])
-		list := getList(project.ID, args[1])
-
-		step := attackStep{
-			IDX:        0,
-			AttackMode: flAttackMode,
+		if len(args) < 3 || (flPlanFile == "" && flAttackName == "" && flMaskFile == "" && len(args) < 4) {
+			writeStdErrAndExit("Missing required argument.")
 		}
-		switch flAttackMode {
-		case 0:
-			var (
-				wordlistFile hashstack.File
-				ruleFile     hashstack.File
-			)
-			if err := getJSON(fmt.Sprintf("/api/wordlists?filename=%s", args[3]), &wordlistFile); err != nil {
-				debug(fmt.Sprintf("Error: %s", err.Error()))
-				writeStdErrAndExit("The provided wordlist does not exist on the server.")
-			}
-			step.WordlistID = wordlistFile.ID
-			if flRulesFile != "" {
-				if err := getJSON(fmt.Sprintf("/api/rules?filename=%s", flRulesFile), &ruleFile); err != nil {
-					debug(fmt.Sprintf("Error: %s", err.Error()))
-					writeStdErrAndExit("The provided rule file does not exist on the server.")
-				}
-				step.RuleID = ruleFile.ID
-			}
-		case 1:
-			if len(args) < 4 {
-				writeStdErrAndExit("Two wordlist files are required for a combination attack.")
+		c := apiClient()
+		project, lists, attack, steps := jobAttack(c, args[0], args[1], args[3:])
+		if flEstimate {
+			displayEstimate(estimateJob(c, lists, steps))
+			if !prompt.Confirm("Do you want to add the job? [yY/nN]") {
+				return
 			}
-			if flRuleLeft != "" {
-				step.RuleBufLeft = flRuleLeft
-			}
-			if flRuleRight != "" {
-				step.RuleBufRight = flRuleRight
-			}
-			var (
-				wordlistFile    hashstack.File
-				combinationFile hashstack.File
-			)
-			if err := getJSON(fmt.Sprintf("/api/wordlists?filename=%s", args[3]), &wordlistFile); err != nil {
-				debug(fmt.Sprintf("Error: %s", err.Error()))
-				writeStdErrAndExit("The provided wordlist does not exist on the server.")
-			}
-			if err := getJSON(fmt.Sprintf("/api/wordlists?filename=%s", args[4]), &combinationFile); err != nil {
-				debug(fmt.Sprintf("Error: %s", err.Error()))
-				writeStdErrAndExit("The provided combination wordlist does not exist on the server.")
-			}
-			step.WordlistID = wordlistFile.ID
-			step.WordlistCombinationID = combinationFile.ID
-
-		case 3:
-			step.Mask = args[3]
-			step.CustomCharset1 = flCustomCharset1
-			step.CustomCharset2 = flCustomCharset2
-			step.CustomCharset3 = flCustomCharset3
-			step.CustomCharset4 = flCustomCharset4
-			step.IsHexCharset = flIsHexCharset
-		case 6:
-			if len(args) < 5 {
-				writeStdErrAndExit("A wordlist file and mask are required for this attack mode.")
-			}
-			var wordlistFile hashstack.File
-			if err := getJSON(fmt.Sprintf("/api/wordlists?filename=%s", args[3]), &wordlistFile); err != nil {
-				debug(fmt.Sprintf("Error: %s", err.Error()))
-				writeStdErrAndExit("The provided wordlist does not exist on the server.")
-			}
-			step.WordlistID = wordlistFile.ID
-			step.Mask = args[4]
-		case 7:
-			if len(args) < 5 {
-				writeStdErrAndExit("A mask and wordlist file are required for this attack mode.")
-			}
-			var wordlistFile hashstack.File
-			if err := getJSON(fmt.Sprintf("/api/wordlists?filename=%s", args[4]), &wordlistFile); err != nil {
-				debug(fmt.Sprintf("Error: %s", err.Error()))
-				writeStdErrAndExit("The provided wordlist does not exist on the server.")
-			}
-			step.WordlistID = wordlistFile.ID
-			step.Mask = args[3]
-		default:
-			writeStdErrAndExit("The attack-mode provided is not valid.")
 		}
-		attack := attackRequest{
-			Title: fmt.Sprintf("hashstack-cli-%d-%d-%s", project.ID, list.ID, name),
-			Steps: []attackStep{step},
-		}
-		data, err := postJSON("/api/attacks", &attack)
-		if err != nil {
-			writeStdErrAndExit(err.Error())
-		}
-		debug("uploaded temporary attack plan")
-		var plan hashstack.Attack
-		if err := json.Unmarshal(data, &plan); err != nil {
-			debug(fmt.Sprintf("Error: %s", err.Error()))
-			writeStdErrAndExit(new(jsonServerError).Error())
+		if isBatch(args[1]) {
+			launchBatch(c, project, lists, args[2], attack.ID, steps)
+			return
 		}
+		launchJobs(c, project, lists, args[2], attack.ID, steps)
+	},
+}
 
-		jobreq := jobRequest{
-			Name:                name,
-			ListID:              list.ID,
-			AttackID:            plan.ID,
-			Priority:            flPriority,
-			MaxDedicatedDevices: flMaxDedicatedDevices,
-			OpenCLVectorWidth:   flOpenCLVectorWidth,
-		}
-		data, err = postJSON(fmt.Sprintf("/api/projects/%d/jobs", project.ID), &jobreq)
-		if err != nil {
-			deleteHTTP(fmt.Sprintf("/api/attacks/%d", plan.ID))
+// jobAttack returns the project and lists for jobs add or estimate along with
+// the attack to run. When a saved attack is used, steps are a copy of its
+// steps. Otherwise attack is empty and steps are built from the plan, mask
+// file, or flags and the wordlist and mask arguments in attackArgs.
+func jobAttack(c *client.Client, projectArg, listArg string, attackArgs []string) (hashstack.Project, []hashstack.List, hashstack.Attack, []client.AttackStep) {
+	var attack hashstack.Attack
+	if flPlanFile != "" && flAttackName != "" {
+		writeStdErrAndExit("--plan and --attack can not be used together.")
+	}
+	if flMaskFile != "" && (flPlanFile != "" || flAttackName != "") {
+		writeStdErrAndExit("--mask-file can not be used with --plan or --attack.")
+	}
+	if flMaskFile != "" && flAttackMode != 3 {
+		writeStdErrAndExit("--mask-file can only be used with a brute-force attack (-a 3).")
+	}
+	if flAttackName != "" {
+		project := getProject(projectArg)
+		lists := getJobLists(project.ID, listArg)
+		attack = getAttack(flAttackName)
+		return project, lists, attack, cloneSteps(attack.Steps)
+	}
+	var (
+		plan attackPlan
+		err  error
+	)
+	switch {
+	case flPlanFile != "":
+		if plan, err = loadAttackPlan(flPlanFile); err != nil {
 			writeStdErrAndExit(err.Error())
 		}
-		var job hashstack.Job
-		if err := json.Unmarshal(data, &job); err != nil {
-			debug(fmt.Sprintf("Error: %s", err.Error()))
-			writeStdErrAndExit(new(jsonServerError).Error())
+	case flMaskFile != "":
+		if plan, err = loadMaskPlan(flMaskFile); err != nil {
+			writeStdErrAndExit(err.Error())
 		}
-		statsJob(job)
-	},
+	default:
+		plan.Steps = []planStep{planStepFromFlags(attackArgs)}
+	}
+	project := getProject(projectArg)
+	lists := getJobLists(project.ID, listArg)
+	steps, err := plan.attackSteps(c)
+	if err != nil {
+		writeStdErrAndExit(err.Error())
+	}
+	return project, lists, attack, steps
+}
+
+// addAttackFlags registers the flags that describe the attack run by a job.
+func addAttackFlags(flags *pflag.FlagSet) {
+	flags.IntVarP(&flAttackMode, "attack-mode", "a", 0, "Attack mode, see references above")
+	flags.BoolVar(&flIsHexCharset, "hex-charset", false, "Assume charset is given in hex")
+	flags.StringVar(&flMarkovHcstat, "markov-hcstat", "", "Specify hcstat file to use")
+	flags.IntVarP(&flMarkovThreshold, "markov-threshold", "t", 0, "Threshold X when to stop accepting new markov-chains")
+	flags.StringVarP(&flRuleLeft, "rule-left", "j", "", "Single rule applied to each word from left wordlist")
+	flags.StringVarP(&flRuleRight, "rule-right", "k", "", "Single rule applied to each word from left wordlist")
+	flags.StringVarP(&flRulesFile, "rules-file", "r", "", "Rule file to be applied to each word from wordlists")
+	flags.StringVarP(&flCustomCharset1, "custom-charset1", "1", "", "User-defined charset ?1")
+	flags.StringVarP(&flCustomCharset2, "custom-charset2", "2", "", "User-defined charset ?2")
+	flags.StringVarP(&flCustomCharset3, "custom-charset3", "3", "", "User-defined charset ?3")
+	flags.StringVarP(&flCustomCharset4, "custom-charset4", "4", "", "User-defined charset ?4")
+	flags.StringVar(&flPlanFile, "plan", "", "TOML, YAML, or JSON file describing the ordered attack steps to run")
+	flags.StringVar(&flAttackName, "attack", "", "Name or id of a saved attack to run")
+	flags.StringVar(&flMaskFile, "mask-file", "", ".hcmask file with a mask on each line to run in order")
 }
 
 func init() {
-	addJobCmd.PersistentFlags().IntVarP(&flAttackMode, "attack-mode", "a", 0, "Attack mode, see references above")
-	addJobCmd.PersistentFlags().BoolVar(&flIsHexCharset, "hex-charset", false, "Assume charset is given in hex")
-	addJobCmd.PersistentFlags().StringVar(&flMarkovHcstat, "markov-hcstat", "", "Specify hcstat file to use")
-	addJobCmd.PersistentFlags().IntVarP(&flMarkovThreshold, "markov-threshold", "t", 0, "Threshold X when to stop accepting new markov-chains")
+	addAttackFlags(addJobCmd.PersistentFlags())
 	addJobCmd.PersistentFlags().IntVar(&flOpenCLVectorWidth, "opencl-vector-width", 0, "Manual override OpenCL vector-width to X")
 	addJobCmd.PersistentFlags().IntVar(&flPriority, "priority", 1, "The priority for this job 1-100")
 	addJobCmd.PersistentFlags().IntVar(&flMaxDedicatedDevices, "max-devices", 0, "Maximum devices across the entire cluster to use, 0 is unlimited")
-	addJobCmd.PersistentFlags().StringVarP(&flRuleLeft, "rule-left", "j", "", "Single rule applied to each word from left wordlist")
-	addJobCmd.PersistentFlags().StringVarP(&flRuleRight, "rule-right", "k", "", "Single rule applied to each word from left wordlist")
-	addJobCmd.PersistentFlags().StringVarP(&flRulesFile, "rules-file", "r", "", "Rule file to be applied to each word from wordlists")
-	addJobCmd.PersistentFlags().StringVarP(&flCustomCharset1, "custom-charset1", "1", "", "User-defined charset ?1")
-	addJobCmd.PersistentFlags().StringVarP(&flCustomCharset2, "custom-charset2", "2", "", "User-defined charset ?2")
-	addJobCmd.PersistentFlags().StringVarP(&flCustomCharset3, "custom-charset3", "3", "", "User-defined charset ?3")
-	addJobCmd.PersistentFlags().StringVarP(&flCustomCharset4, "custom-charset4", "4", "", "User-defined charset ?4")
+	addJobCmd.PersistentFlags().BoolVar(&flAllLists, "all-lists", false, "Add a job for every list in the project; the list argument is left out")
+	addJobCmd.PersistentFlags().IntVar(&flJobListMode, "mode", -1, "Only add jobs for lists of this hash mode")
+	addJobCmd.PersistentFlags().BoolVar(&flEstimate, "estimate", false, "Display the estimated keyspace and run time and ask before adding the job")
 	updateJobCmd.PersistentFlags().IntVar(&flPriority, "priority", 1, "The priority for this job 1-100")
 	updateJobCmd.PersistentFlags().IntVar(&flMaxDedicatedDevices, "max-devices", 0, "Maximum devices across the entire cluster to use, 0 is unlimited")
+	jobCmd.PersistentFlags().BoolVar(&flNoDashboard, "no-dashboard", false, "Print the progress of a job instead of showing a full-screen dashboard")
 	jobCmd.AddCommand(addJobCmd)
 	jobCmd.AddCommand(pauseJobCmd)
 	jobCmd.AddCommand(startJobCmd)
@@ -0,0 +1,127 @@
+package cmd
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/gosuri/uitable"
+	"github.com/stricture/hashstack-cli/client"
+	hashstack "github.com/stricture/hashstack-server-core-ng"
+)
+
+var (
+	flAllLists    bool
+	flJobListMode int
+)
+
+// isListPattern reports whether arg is a glob rather than a list name or id.
+func isListPattern(arg string) bool {
+	return strings.ContainsAny(arg, "*?[")
+}
+
+// isBatch reports whether jobs add should create a job for every list that
+// matches listArg, --all-lists, or --mode.
+func isBatch(listArg string) bool {
+	return flAllLists || flJobListMode >= 0 || isListPattern(listArg)
+}
+
+// getJobLists returns the lists a job should be added to. In a batch, every
+// list in the project whose name matches the glob listArg, or every list with
+// --all-lists, is returned, limited to lists of hash mode --mode when it is
+// set. Otherwise listArg is a list or group of lists.
+func getJobLists(projectID int64, listArg string) []hashstack.List {
+	if !isBatch(listArg) {
+		return getListGroup(projectID, listArg)
+	}
+	pattern := listArg
+	if flAllLists || pattern == "" {
+		pattern = "*"
+	}
+	if _, err := path.Match(pattern, ""); err != nil {
+		writeStdErrAndExit("The provided list pattern is not valid.")
+	}
+	all, err := apiClient().Lists(ctx, projectID)
+	if err != nil {
+		writeStdErrAndExit(err.Error())
+	}
+	var lists []hashstack.List
+	for _, l := range all {
+		if ok, _ := path.Match(pattern, l.Name); !ok {
+			continue
+		}
+		if flJobListMode >= 0 && l.HashMode != flJobListMode {
+			continue
+		}
+		lists = append(lists, l)
+	}
+	if len(lists) < 1 {
+		writeStdErrAndExit("There are no lists in the project that match.")
+	}
+	sort.Slice(lists, func(i, j int) bool {
+		return lists[i].Name < lists[j].Name
+	})
+	return lists
+}
+
+// batchJob is the result of adding a job to one list of a batch.
+type batchJob struct {
+	List  string `json:"list"`
+	JobID int64  `json:"job_id,omitempty"`
+	Name  string `json:"name"`
+	Error string `json:"error,omitempty"`
+}
+
+// launchBatch creates a job named name-<list> for each list that runs the
+// saved attack with attackID or a single temporary attack from steps shared
+// by every job. A failure for one list does not stop the others, and the
+// results are displayed as a table instead of attaching to a job.
+func launchBatch(c *client.Client, project hashstack.Project, lists []hashstack.List, name string, attackID int64, steps []client.AttackStep) {
+	var (
+		results []batchJob
+		failed  int
+		temp    bool
+	)
+	if attackID == 0 {
+		attack, err := c.CreateAttack(ctx, client.AttackRequest{
+			Title: tempAttackTitle(project.ID, 0, name),
+			Steps: steps,
+		})
+		if err != nil {
+			writeStdErrAndExit(err.Error())
+		}
+		debug("uploaded temporary attack plan shared by the batch")
+		attackID = attack.ID
+		temp = true
+	}
+	for _, list := range lists {
+		r := batchJob{List: list.Name, Name: fmt.Sprintf("%s-%s", name, list.Name)}
+		job, err := createJob(c, project, list, r.Name, attackID)
+		if err != nil {
+			debug(fmt.Sprintf("Error: %s", err.Error()))
+			r.Error = err.Error()
+			failed++
+		}
+		r.JobID = job.ID
+		results = append(results, r)
+	}
+	if temp && failed == len(lists) {
+		c.DeleteAttack(ctx, attackID)
+	}
+	if isStructuredOutput() {
+		renderOutput(results)
+		return
+	}
+	tbl := uitable.New()
+	tbl.AddRow("List", "Job.ID", "Job.Name", "Status")
+	for _, r := range results {
+		if r.Error != "" {
+			tbl.AddRow(r.List, "-", r.Name, r.Error)
+			continue
+		}
+		tbl.AddRow(r.List, r.JobID, r.Name, "created")
+	}
+	fmt.Println(tbl)
+	fmt.Printf("\nCreated %d of %d jobs. Use 'hashstack jobs %d' to view their progress.\n", len(results)-failed, len(results), project.ID)
+}
@@ -3,33 +3,33 @@ package cmd
 import (
 	"bufio"
 	"encoding/base64"
-	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
-	"mime/multipart"
 	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
-	"sync"
 
-	"github.com/cheggaaa/pb"
 	"github.com/spf13/cobra"
+	"github.com/stricture/hashstack-cli/client"
+	"github.com/stricture/hashstack-cli/hashfmt"
 	hashstack "github.com/stricture/hashstack-server-core-ng"
 )
 
 func getListByID(list *hashstack.List) {
-	path := fmt.Sprintf("/api/projects/%d/lists/%d", list.ProjectID, list.ID)
-	if err := getJSON(path, list); err != nil {
+	l, err := apiClient().List(ctx, list.ProjectID, list.ID)
+	if err != nil {
 		writeStdErrAndExit(err.Error())
 	}
+	*list = l
 }
 func getListByName(list *hashstack.List) {
-	path := fmt.Sprintf("/api/projects/%d/lists?name=%s", list.ProjectID, list.Name)
-	if err := getJSON(path, list); err != nil {
+	l, err := apiClient().ListByName(ctx, list.ProjectID, list.Name)
+	if err != nil {
 		writeStdErrAndExit(err.Error())
 	}
+	*list = l
 }
 func getList(projectID int64, arg string) hashstack.List {
 	list := hashstack.List{
@@ -46,7 +46,24 @@ func getList(projectID int64, arg string) hashstack.List {
 	return list
 }
 
+// listView is a list along with its computed crack ratio.
+type listView struct {
+	hashstack.List
+	CrackedPercent float64 `json:"cracked_percent"`
+}
+
+func newListView(list hashstack.List) listView {
+	return listView{
+		List:           list,
+		CrackedPercent: percentOf(int(list.RecoveredCount), int(list.DigestCount)),
+	}
+}
+
 func displayList(list hashstack.List) {
+	if isStructuredOutput() {
+		renderOutput(newListView(list))
+		return
+	}
 	liststat := fmt.Sprintf("%d/%d (%0.2f%%) hashes", list.RecoveredCount, list.DigestCount, percentOf(int(list.RecoveredCount), int(list.DigestCount)))
 	fmt.Printf("ID..............: %d\n", list.ID)
 	fmt.Printf("Name............: %s\n", list.Name)
@@ -61,14 +78,21 @@ func displayLists(arg string) {
 	if err != nil {
 		writeStdErrAndExit(err.Error())
 	}
-	if count < 1 {
+	if count < 1 && !isStructuredOutput() {
 		writeStdErrAndExit("You have not created any lists for this project.")
 	}
-	path := fmt.Sprintf("/api/projects/%d/lists", project.ID)
-	var lists []hashstack.List
-	if err := getRangeJSON(path, &lists); err != nil {
+	lists, err := apiClient().Lists(ctx, project.ID)
+	if err != nil {
 		writeStdErrAndExit(err.Error())
 	}
+	if isStructuredOutput() {
+		views := make([]listView, 0, len(lists))
+		for _, l := range lists {
+			views = append(views, newListView(l))
+		}
+		renderOutput(views)
+		return
+	}
 	for _, l := range lists {
 		displayList(l)
 	}
@@ -90,120 +114,72 @@ that specific list. Additional subcommands are available.
 			displayLists(args[0])
 		case 2:
 			project := getProject(args[0])
-			displayList(getList(project.ID, args[1]))
+			displayListGroup(getListGroup(project.ID, args[1]))
 		default:
 			cmd.Usage()
 		}
 	},
 }
 
-type binaryRequest struct {
-	ProjectID   int64  `json:"project_id"`
-	HashMode    int    `json:"hash_mode"`
-	EncodedHash string `json:"encoded_hash"`
-	Filename    string `json:"filename"`
-	Name        string `json:"name"`
-}
+var (
+	flIsHexSalt      bool
+	flStripInvalid   bool
+	flWriteValidFile string
+	flListMode       string
+	flNoNormalize    bool
+	flWithUsernames  bool
+	flJoinUsernames  bool
+	flCrackedFormat  string
+)
 
-type multiTrackRequest struct {
-	ProjectID int64            `json:"project_id"`
-	HashMode  int              `json:"hash_mode"`
-	Hashes    []multiTrackItem `json:"hashes"`
-	Name      string           `json:"name"`
-}
+// modeAuto identifies the hash mode of a list from its contents.
+const modeAuto = "auto"
 
-type multiTrackItem struct {
-	Hash     string `json:"hash"`
-	Filename string `json:"filename"`
-}
+// maxReportedLineErrors is the number of invalid lines printed by lists add.
+const maxReportedLineErrors = 10
 
-var (
-	flIsHexSalt bool
-)
+// checkValidation exits when a list has invalid lines, unless --strip-invalid
+// was provided, or when it has no valid lines at all.
+func checkValidation(result hashfmt.Result) {
+	if result.Valid < 1 {
+		writeStdErrAndExit("There were no valid hashes in the provided file.")
+	}
+	if result.Invalid < 1 {
+		return
+	}
+	var lines []string
+	for i, e := range result.Errors {
+		if i == maxReportedLineErrors {
+			lines = append(lines, fmt.Sprintf("...and %d more.", len(result.Errors)-i))
+			break
+		}
+		lines = append(lines, e.Error())
+	}
+	msg := fmt.Sprintf("%d of %d lines are not valid for this mode.\n\n%s", result.Invalid, result.Lines, strings.Join(lines, "\n"))
+	if !flStripInvalid {
+		writeStdErrAndExit(fmt.Sprintf("%s\n\nUse --strip-invalid to upload only the valid lines or 'hashstack lists validate' to see every error.", msg))
+	}
+	fmt.Printf("%s\n\nThe invalid lines will not be uploaded.\n\n", msg)
+}
 
 func uploadList(pid int64, mode int, filename string) {
-	var (
-		hashMode hashstack.HashMode
-		resp     []byte
-	)
-	if err := getJSON(fmt.Sprintf("/api/hash_modes?mode=%d", mode), &hashMode); err != nil {
+	var list hashstack.List
+	c := apiClient()
+	hashMode, err := c.HashMode(ctx, mode)
+	if err != nil {
 		writeStdErrAndExit("The selected mode is not supported by the server.")
 	}
 	if !hashMode.IsSupported {
 		writeStdErrAndExit("The selected mode is not supported by the server.")
 	}
+	if flWithUsernames && (hashMode.IsBinary || hashMode.Upload != "") {
+		writeStdErrAndExit("--with-usernames is only supported for modes uploaded as text.")
+	}
 	if !hashMode.IsBinary && hashMode.Upload == "" {
-		file, err := os.Open(filename)
-		if err != nil {
-			debug(fmt.Sprintf("Error: %s", err.Error()))
-			writeStdErrAndExit("There was an error opening the provided file.")
-		}
-		defer file.Close()
-
-		_, filenamesplit := filepath.Split(file.Name())
-		filestat, err := file.Stat()
-		if err != nil {
-			debug(fmt.Sprintf("Error: %s", err.Error()))
-			writeStdErrAndExit("There was an error getting stats for the file provided")
-		}
-		filesize := filestat.Size()
-
-		if filesize > int64(64*1024*1024) {
-			writeStdErrAndExit("This list exceeds the maximum size supported by the server (64 MB).")
-		}
-
-		pipeOut, pipeIn := io.Pipe()
-		writer := multipart.NewWriter(pipeIn)
-
-		bar := pb.New64(filesize).SetUnits(pb.U_BYTES)
-		bar.SetWidth(80)
-
-		wg := &sync.WaitGroup{}
-		wg.Add(1)
-		go func() {
-			resp, err = postMultipart(fmt.Sprintf("/api/projects/%d/lists/multi", pid), writer.FormDataContentType(), pipeOut)
-			if err != nil {
-				fmt.Println("")
-				fmt.Println("")
-				fmt.Println("This error likely occurred because you did not have any valid hashes.")
-				writeStdErrAndExit(err.Error())
-			}
-			wg.Done()
-		}()
-
-		part, err := writer.CreateFormFile("file", file.Name())
-		if err != nil {
-			debug(fmt.Sprintf("Error: %s", err.Error()))
-			writeStdErrAndExit(new(requestCreateError).Error())
-		}
-
-		out := io.MultiWriter(part, bar)
-
-		bar.Start()
-
-		if _, err := io.Copy(out, file); err != nil {
-			bar.Finish()
-			debug(fmt.Sprintf("Error: %s", err.Error()))
-			if err.Error() == "io: read/write on closed pipe" {
-				writeStdErrAndExit("The list exceeded the maxmimum size supported by the server (64 MB).")
-			}
-			writeStdErrAndExit("There was an error reading the provided file.")
-		}
-		writer.WriteField("hash_mode", strconv.Itoa(hashMode.HashMode))
-		writer.WriteField("name", filenamesplit)
-		isHexSaltStr := "false"
-		if flIsHexSalt {
-			isHexSaltStr = "true"
-		}
-		writer.WriteField("is_hex_salt", isHexSaltStr)
-
-		writer.Close()
-		pipeIn.Close()
-		wg.Wait()
-
-		bar.Finish()
-		fmt.Println("")
-	} else if hashMode.Upload != "" {
+		displayListGroup(uploadTextList(c, pid, hashMode, filename))
+		return
+	}
+	if hashMode.Upload != "" {
 		_, name := filepath.Split(filename)
 		file, err := os.Open(filename)
 		if err != nil {
@@ -211,7 +187,7 @@ func uploadList(pid int64, mode int, filename string) {
 			writeStdErrAndExit("There was an error opening the provided file.")
 		}
 		defer file.Close()
-		var hashes []multiTrackItem
+		var hashes []client.MultiTrackItem
 		var lineNum int
 		scanner := bufio.NewScanner(file)
 		for scanner.Scan() {
@@ -224,7 +200,7 @@ func uploadList(pid int64, mode int, filename string) {
 			if len(parts) != 2 {
 				writeStdErrAndExit(fmt.Sprintf("Line number %d is not in the format %s!\n\nThis is required for this format.", lineNum, hashMode.Upload))
 			}
-			hashes = append(hashes, multiTrackItem{
+			hashes = append(hashes, client.MultiTrackItem{
 				Filename: parts[0],
 				Hash:     parts[1],
 			})
@@ -236,14 +212,14 @@ func uploadList(pid int64, mode int, filename string) {
 			debug(fmt.Sprintf("Error: %s", err.Error()))
 			writeStdErrAndExit("There was an error reading the file.")
 		}
-		req := multiTrackRequest{
+		req := client.MultiTrackListRequest{
 			ProjectID: pid,
 			HashMode:  hashMode.HashMode,
 			Hashes:    hashes,
 			Name:      name,
 		}
 		fmt.Printf("Uploading %d hashes from %s...\n", len(hashes), name)
-		resp, err = postJSON(fmt.Sprintf("/api/projects/%d/lists/multitrack", pid), req)
+		list, err = c.CreateMultiTrackList(ctx, pid, req)
 		if err != nil {
 			writeStdErrAndExit(err.Error())
 		}
@@ -255,7 +231,7 @@ func uploadList(pid int64, mode int, filename string) {
 			writeStdErrAndExit("There was an error reading the provided file.")
 		}
 		_, name := filepath.Split(filename)
-		req := binaryRequest{
+		req := client.BinaryListRequest{
 			ProjectID:   pid,
 			HashMode:    hashMode.HashMode,
 			EncodedHash: base64.StdEncoding.EncodeToString(data),
@@ -264,7 +240,7 @@ func uploadList(pid int64, mode int, filename string) {
 		}
 
 		fmt.Printf("Uploading binary hash from %s...\n", name)
-		resp, err = postJSON(fmt.Sprintf("/api/projects/%d/lists/binary", pid), req)
+		list, err = c.CreateBinaryList(ctx, pid, req)
 		if err != nil {
 			writeStdErrAndExit(err.Error())
 		}
@@ -273,23 +249,38 @@ func uploadList(pid int64, mode int, filename string) {
 		writeStdErrAndExit("Malformed hash_mode. Please contact support!")
 	}
 
-	var list hashstack.List
-	if err := json.Unmarshal(resp, &list); err != nil {
-		debug(fmt.Sprintf("Error: %s", err.Error()))
-		writeStdErrAndExit(new(jsonServerError).Error())
-	}
 	displayList(list)
 }
 
 var addListCmd = &cobra.Command{
-	Use:   "add <project_name|project_id> <mode> <file>",
+	Use:   "add <project_name|project_id> <mode|auto> <file>",
 	Short: "Add a new list to a project.",
 	Long: `
 Add a new file containing one or more hashes to a project by project_name or project_id. Modes can be viewed
 using the "modes" subcommand. The file name must be unique across projects.
+
+Before upload, duplicate hashes are removed, whitespace and Windows line endings are trimmed, hex hashes are
+lower cased for modes where case does not matter, and usernames are removed from user:hash lines. Removed
+usernames are saved locally and are never sent to the server. Use --no-normalize to keep lines as they are,
+apart from removing duplicates. A username is only removed automatically when the rest of the line is a valid
+hash. Use --with-usernames when every line is user:hash to always remove the first field, for example for
+salted modes or modes without local rules. Use 'hashstack lists cracked --join' to match the saved usernames
+to cracked hashes. Duplicates are found using temporary files so that large files do not need to fit in
+memory, and the order of the hashes in the file is not kept. Files larger than the server limit of 64 MB are split into a group
+of lists named <file>.part001, <file>.part002, and so on. Other list commands accept the file name to act on
+every list in the group, or the name or id of a part to act on that list only.
+
+Hashes for common modes are checked locally before they are uploaded. If any line is not valid for the
+mode, the upload stops and the invalid lines are reported. Use --strip-invalid to upload the valid lines
+anyway, or 'hashstack lists validate' to check a file without uploading it.
+
+Use auto as the mode, or --mode auto, to identify the mode from the hashes in the file. See 'hashstack identify'.
 `,
 	PreRun: ensureAuth,
 	Run: func(cmd *cobra.Command, args []string) {
+		if flListMode != "" && len(args) > 0 {
+			args = append([]string{args[0], flListMode}, args[1:]...)
+		}
 		if len(args) < 3 {
 			writeStdErrAndExit("project_name|project_id, mode, and file are required.")
 		}
@@ -298,18 +289,89 @@ using the "modes" subcommand. The file name must be unique across projects.
 			modeStr  = args[1]
 			filename = args[2]
 		)
+		project := getProject(pidStr)
+		if modeStr == modeAuto {
+			uploadList(project.ID, identifyMode(filename), filename)
+			return
+		}
 		mode, err := strconv.Atoi(modeStr)
 		if err != nil {
 			writeStdErrAndExit("mode is invalid")
 		}
-		project := getProject(pidStr)
 		uploadList(project.ID, mode, filename)
 	},
 }
 
+var validateListCmd = &cobra.Command{
+	Use:   "validate <mode> <file>",
+	Short: "Check a file of hashes against the format for a mode without uploading it.",
+	Long: `
+Check every line of a file against the format for the provided mode without uploading it. Each invalid line
+is reported with its line number. Use --write-valid to save only the valid lines to a new file.
+
+Formats are checked locally, so only common modes are supported.
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) < 2 {
+			writeStdErrAndExit("mode and file are required.")
+		}
+		mode, err := strconv.Atoi(args[0])
+		if err != nil {
+			writeStdErrAndExit("mode is invalid")
+		}
+		format, ok := hashfmt.Lookup(mode)
+		if !ok {
+			writeStdErrAndExit(fmt.Sprintf("There are no local validation rules for mode %d.", mode))
+		}
+		file, err := os.Open(args[1])
+		if err != nil {
+			debug(fmt.Sprintf("Error: %s", err.Error()))
+			writeStdErrAndExit("There was an error opening the provided file.")
+		}
+		defer file.Close()
+		var valid io.Writer
+		if flWriteValidFile != "" {
+			out, err := os.Create(flWriteValidFile)
+			if err != nil {
+				debug(fmt.Sprintf("Error: %s", err.Error()))
+				writeStdErrAndExit("There was an error creating the file for valid lines.")
+			}
+			defer out.Close()
+			valid = out
+		}
+		var normalize func(string) string
+		if !flNoNormalize {
+			n := &hashfmt.Normalizer{
+				Format:   format,
+				HexSalt:  flIsHexSalt,
+				Username: func(user, hash string) {},
+			}
+			normalize = n.Normalize
+		}
+		result, err := format.ValidateReader(file, flIsHexSalt, normalize, valid)
+		if err != nil {
+			debug(fmt.Sprintf("Error: %s", err.Error()))
+			writeStdErrAndExit("There was an error reading the provided file.")
+		}
+		if isStructuredOutput() {
+			renderOutput(result)
+			return
+		}
+		for _, e := range result.Errors {
+			fmt.Println(e.Error())
+		}
+		if len(result.Errors) > 0 {
+			fmt.Println()
+		}
+		fmt.Printf("Hash Mode.......: %d (%s)\n", format.Mode, format.Name)
+		fmt.Printf("Lines...........: %d\n", result.Lines)
+		fmt.Printf("Valid...........: %d\n", result.Valid)
+		fmt.Printf("Invalid.........: %d\n", result.Invalid)
+	},
+}
+
 func deleteList(projectID int64, listID int64) {
-	path := fmt.Sprintf("/api/projects/%d/lists/%d", projectID, listID)
-	if err := deleteHTTP(path); err != nil {
+	if err := apiClient().DeleteList(ctx, projectID, listID); err != nil {
 		writeStdErrAndExit(err.Error())
 	}
 	fmt.Println("The list was deleted successfully.")
@@ -328,54 +390,125 @@ the associated plains.
 			writeStdErrAndExit("project_name|project_id and list_name|list_id are required.")
 		}
 		project := getProject(args[0])
-		list := getList(project.ID, args[1])
-		if ok := promptDelete("this list"); !ok {
+		lists := getListGroup(project.ID, args[1])
+		thing := "this list"
+		if len(lists) > 1 {
+			thing = fmt.Sprintf("the %d lists in this group", len(lists))
+		}
+		if ok := promptDelete(thing); !ok {
 			writeStdErrAndExit("Not deleting list.")
 		}
-		deleteList(project.ID, list.ID)
+		for _, list := range lists {
+			deleteList(project.ID, list.ID)
+		}
 	},
 }
 
 var crackedListCmd = &cobra.Command{
 	Use:   "cracked <project_name|project_id> <list_name|list_id>",
 	Short: "Download cracked hashes for a list.",
-	Long:  "Download cracked hashes for a list.",
+	Long: `
+Download cracked hashes for a list. The hash and plain of each line are split using the fields of the
+hash for the mode of the list, so hashes and plains that contain ':' are kept intact.
+
+Use --format to choose the output:
+
+    hashcat   hash:plain, with plains that contain control characters or are not valid UTF-8 written
+              as $HEX[...] (the default)
+    potfile   hash:plain, with every plain that is not printable ASCII written as $HEX[...], as in a
+              hashcat potfile
+    jsonl     a JSON object on each line with hash, plain encoded as for hashcat, and plain_hex
+    csv       hash, plain encoded as for hashcat, and plain_hex columns with a header row
+
+Plains cracked as $HEX[...] are decoded before they are encoded again, so every format is consistent.
+
+Use --join to add the usernames saved when the list was added, as user:hash:plain for hashcat or a
+username field or column for jsonl and csv. A hash shared by several accounts is printed once for each
+account, and a hash without a saved username is printed with an empty one, as :hash:plain. Usernames are saved by 'hashstack lists add' on this machine only.
+`,
+	PreRun: ensureAuth,
 	Run: func(cmd *cobra.Command, args []string) {
 		if len(args) < 2 {
 			writeStdErrAndExit("project_name|project_id and list_id is required.")
 		}
+		switch flCrackedFormat {
+		case crackedHashcat, crackedPotfile, crackedJSONL, crackedCSV:
+		default:
+			writeStdErrAndExit("The format must be hashcat, potfile, jsonl, or csv.")
+		}
+		if flJoinUsernames && flCrackedFormat == crackedPotfile {
+			writeStdErrAndExit("--join can not be used with --format potfile.")
+		}
 		project := getProject(args[0])
-		list := getList(project.ID, args[1])
-		body, err := getReader(fmt.Sprintf("/api/projects/%d/lists/%d/plains", project.ID, list.ID))
-		if err != nil {
-			writeStdErrAndExit(err.Error())
+		lists := getListGroup(project.ID, args[1])
+		var users map[string][]string
+		if flJoinUsernames {
+			var err error
+			users, err = loadUsernameMap(project.ID, listGroupName(lists))
+			if err != nil {
+				debug(fmt.Sprintf("Error: %s", err.Error()))
+				if os.IsNotExist(err) {
+					writeStdErrAndExit("There are no usernames saved for this list. Use 'hashstack lists add --with-usernames' to save them.")
+				}
+				writeStdErrAndExit("There was an error reading the saved usernames.")
+			}
+		}
+		var invalid int
+		for i, list := range lists {
+			fields := plainFields(getMode(list.HashMode))
+			body, err := apiClient().Plains(ctx, project.ID, list.ID)
+			if err != nil {
+				writeStdErrAndExit(err.Error())
+			}
+			n, err := writeCracked(body, os.Stdout, flCrackedFormat, fields, users, i == 0)
+			body.Close()
+			if err != nil {
+				debug(fmt.Sprintf("Error: %s", err.Error()))
+				writeStdErrAndExit("There was an error downloading the cracked hashes.")
+			}
+			invalid += n
+		}
+		if invalid > 0 {
+			fmt.Fprintf(os.Stderr, "%d lines from the server could not be split into a hash and plain and were skipped.\n", invalid)
 		}
-		io.Copy(os.Stdout, body)
 	},
 }
 
 var uncrackedListCmd = &cobra.Command{
-	Use:   "uncracked <project_name|project_id> <list_name|list_id>",
-	Short: "Download uncracked hashes for a list.",
-	Long:  "Download uncracked hashes for a list.",
+	Use:    "uncracked <project_name|project_id> <list_name|list_id>",
+	Short:  "Download uncracked hashes for a list.",
+	Long:   "Download uncracked hashes for a list.",
+	PreRun: ensureAuth,
 	Run: func(cmd *cobra.Command, args []string) {
 		if len(args) < 2 {
 			writeStdErrAndExit("project_name|project_id and list_id is required.")
 		}
 		project := getProject(args[0])
-		list := getList(project.ID, args[1])
-		body, err := getReader(fmt.Sprintf("/api/projects/%d/lists/%d/hashes", project.ID, list.ID))
-		if err != nil {
-			writeStdErrAndExit(err.Error())
+		for _, list := range getListGroup(project.ID, args[1]) {
+			body, err := apiClient().Hashes(ctx, project.ID, list.ID)
+			if err != nil {
+				writeStdErrAndExit(err.Error())
+			}
+			io.Copy(os.Stdout, body)
+			body.Close()
 		}
-		io.Copy(os.Stdout, body)
 	},
 }
 
 func init() {
 	addListCmd.PersistentFlags().BoolVar(&flIsHexSalt, "hex-salt", false, "Assume is given in hex")
+	addListCmd.PersistentFlags().StringVar(&flListMode, "mode", "", "Hash mode, or auto to identify it from the file; replaces the mode argument")
+	addListCmd.PersistentFlags().BoolVar(&flStripInvalid, "strip-invalid", false, "Upload only the lines that are valid for the mode")
+	validateListCmd.PersistentFlags().BoolVar(&flIsHexSalt, "hex-salt", false, "Assume is given in hex")
+	addListCmd.PersistentFlags().BoolVar(&flNoNormalize, "no-normalize", false, "Do not remove usernames, trim whitespace, or lower case hex hashes")
+	validateListCmd.PersistentFlags().BoolVar(&flNoNormalize, "no-normalize", false, "Do not remove usernames, trim whitespace, or lower case hex hashes")
+	addListCmd.PersistentFlags().BoolVar(&flWithUsernames, "with-usernames", false, "Treat the first field of every line as a username and save the usernames locally")
+	crackedListCmd.PersistentFlags().BoolVar(&flJoinUsernames, "join", false, "Add the usernames saved when the list was added")
+	crackedListCmd.PersistentFlags().StringVar(&flCrackedFormat, "format", crackedHashcat, "Output format: hashcat, potfile, jsonl, or csv")
+	validateListCmd.PersistentFlags().StringVar(&flWriteValidFile, "write-valid", "", "Write the valid lines to this file")
 	listCmd.AddCommand(addListCmd)
 	listCmd.AddCommand(delListCmd)
+	listCmd.AddCommand(validateListCmd)
 	listCmd.AddCommand(crackedListCmd)
 	listCmd.AddCommand(uncrackedListCmd)
 	RootCmd.AddCommand(listCmd)
@@ -0,0 +1,438 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/md5"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime/multipart"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/cheggaaa/pb"
+	"github.com/stricture/hashstack-cli/client"
+	"github.com/stricture/hashstack-cli/hashfmt"
+	hashstack "github.com/stricture/hashstack-server-core-ng"
+)
+
+// listShardSize is the largest shard created when splitting a list. It leaves
+// room under the 64 MB server limit for the rest of the multipart form.
+const listShardSize = 60 * 1024 * 1024
+
+// listShardSuffix matches the suffix of the lists created by splitting a large file.
+var listShardSuffix = regexp.MustCompile(`\.part\d{3}$`)
+
+// shardName returns the name of shard i of the list group name.
+func shardName(name string, i int) string {
+	return fmt.Sprintf("%s.part%03d", name, i+1)
+}
+
+// listDedupBuckets is the number of temporary files that lines are spread
+// over by their hash before duplicates are removed.
+const listDedupBuckets = 64
+
+// shardWriter removes duplicate lines from everything written to it and
+// writes the unique lines to temporary files of at most listShardSize bytes.
+// Lines are first spread over listDedupBuckets temporary files by their hash,
+// so identical lines share a bucket and only the hashes of one bucket, about
+// 1/64th of the unique lines, are held in memory at a time. The unique lines
+// are written in bucket order rather than the order they were read in.
+type shardWriter struct {
+	buckets    []*os.File
+	bucketBufs []*bufio.Writer
+	partial    []byte
+	shards     []*os.File
+	size       int64
+	lines      int
+	duplicates int
+}
+
+func newShardWriter() *shardWriter {
+	return &shardWriter{}
+}
+
+func (w *shardWriter) Write(p []byte) (int, error) {
+	data := append(w.partial, p...)
+	for {
+		i := bytes.IndexByte(data, '\n')
+		if i < 0 {
+			break
+		}
+		if err := w.bucketLine(data[:i]); err != nil {
+			return 0, err
+		}
+		data = data[i+1:]
+	}
+	w.partial = append([]byte(nil), data...)
+	return len(p), nil
+}
+
+// bucketLine writes line to the bucket chosen by its hash.
+func (w *shardWriter) bucketLine(line []byte) error {
+	line = bytes.TrimRight(line, "\r")
+	if len(line) == 0 {
+		return nil
+	}
+	for len(w.buckets) < listDedupBuckets {
+		f, err := ioutil.TempFile("", "hashstack-dedup-")
+		if err != nil {
+			return err
+		}
+		w.buckets = append(w.buckets, f)
+		w.bucketBufs = append(w.bucketBufs, bufio.NewWriter(f))
+	}
+	key := md5.Sum(line)
+	b := w.bucketBufs[int(key[0])%listDedupBuckets]
+	b.Write(line)
+	return b.WriteByte('\n')
+}
+
+// dedupBucket writes the lines of bucket i to the shards once each.
+func (w *shardWriter) dedupBucket(i int) error {
+	if err := w.bucketBufs[i].Flush(); err != nil {
+		return err
+	}
+	f := w.buckets[i]
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	seen := make(map[[md5.Size]byte]struct{})
+	r := bufio.NewReader(f)
+	for {
+		line, err := r.ReadBytes('\n')
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		line = line[:len(line)-1]
+		key := md5.Sum(line)
+		if _, ok := seen[key]; ok {
+			w.duplicates++
+			continue
+		}
+		seen[key] = struct{}{}
+		if err := w.writeLine(line); err != nil {
+			return err
+		}
+	}
+}
+
+// writeLine writes a unique line to the last shard, starting a new shard when
+// it is full.
+func (w *shardWriter) writeLine(line []byte) error {
+	w.lines++
+	if len(w.shards) == 0 || w.size+int64(len(line))+1 > listShardSize {
+		f, err := ioutil.TempFile("", "hashstack-list-")
+		if err != nil {
+			return err
+		}
+		w.shards = append(w.shards, f)
+		w.size = 0
+	}
+	n, err := fmt.Fprintf(w.shards[len(w.shards)-1], "%s\n", line)
+	w.size += int64(n)
+	return err
+}
+
+// Close writes any remaining partial line, then removes the duplicates from
+// each bucket and writes the unique lines to the shards.
+func (w *shardWriter) Close() error {
+	if err := w.bucketLine(w.partial); err != nil {
+		return err
+	}
+	w.partial = nil
+	for i := range w.buckets {
+		if err := w.dedupBucket(i); err != nil {
+			return err
+		}
+	}
+	w.removeBuckets()
+	return nil
+}
+
+func (w *shardWriter) removeBuckets() {
+	for _, f := range w.buckets {
+		f.Close()
+		os.Remove(f.Name())
+	}
+	w.buckets, w.bucketBufs = nil, nil
+}
+
+// Remove deletes the temporary bucket and shard files.
+func (w *shardWriter) Remove() {
+	w.removeBuckets()
+	for _, f := range w.shards {
+		f.Close()
+		os.Remove(f.Name())
+	}
+}
+
+// uploadTextList uploads the hashes in filename, removing duplicates. Files
+// larger than the server limit are uploaded as a group of lists named
+// name.part001, name.part002, and so on.
+func uploadTextList(c *client.Client, pid int64, hashMode hashstack.HashMode, filename string) []hashstack.List {
+	file, err := os.Open(filename)
+	if err != nil {
+		debug(fmt.Sprintf("Error: %s", err.Error()))
+		writeStdErrAndExit("There was an error opening the provided file.")
+	}
+	defer file.Close()
+	_, name := filepath.Split(file.Name())
+
+	shards := newShardWriter()
+	defer shards.Remove()
+	// writeStdErrAndExit does not run deferred calls.
+	prevBeforeExit := beforeExit
+	beforeExit = func() {
+		shards.Remove()
+		if prevBeforeExit != nil {
+			prevBeforeExit()
+		}
+	}
+	defer func() { beforeExit = prevBeforeExit }()
+	var (
+		pairs      []usernamePair
+		normalizer *hashfmt.Normalizer
+		result     hashfmt.Result
+	)
+	format, validated := hashfmt.Lookup(hashMode.HashMode)
+	var normalize func(string) string
+	if validated && !flNoNormalize || flWithUsernames {
+		normalizer = &hashfmt.Normalizer{
+			Format:         format,
+			HexSalt:        flIsHexSalt,
+			SplitUsernames: flWithUsernames,
+			Username: func(user, hash string) {
+				pairs = append(pairs, usernamePair{Username: user, Hash: hash})
+			},
+		}
+		normalize = normalizer.Normalize
+	}
+	if validated {
+		result, err = format.ValidateReader(file, flIsHexSalt, normalize, shards)
+		if err != nil {
+			debug(fmt.Sprintf("Error: %s", err.Error()))
+			writeStdErrAndExit("There was an error reading the provided file.")
+		}
+		checkValidation(result)
+	} else if flWithUsernames {
+		debug(fmt.Sprintf("VALIDATE: no local rules for mode %d", hashMode.HashMode))
+		// Without local rules every line is accepted, but the usernames still
+		// need to be removed.
+		if _, err := format.ValidateReader(file, flIsHexSalt, normalize, shards); err != nil {
+			debug(fmt.Sprintf("Error: %s", err.Error()))
+			writeStdErrAndExit("There was an error reading the provided file.")
+		}
+	} else {
+		debug(fmt.Sprintf("VALIDATE: no local rules for mode %d", hashMode.HashMode))
+		if _, err := io.Copy(shards, file); err != nil {
+			debug(fmt.Sprintf("Error: %s", err.Error()))
+			writeStdErrAndExit("There was an error reading the provided file.")
+		}
+	}
+	if err := shards.Close(); err != nil {
+		debug(fmt.Sprintf("Error: %s", err.Error()))
+		writeStdErrAndExit("There was an error preparing the list for upload.")
+	}
+	if len(shards.shards) < 1 {
+		writeStdErrAndExit("There were no hashes in the provided file.")
+	}
+	displayNormalizeSummary(shards, normalizer, result)
+	if len(pairs) > 0 {
+		defer func() {
+			filename, err := saveUsernameMap(pid, name, pairs)
+			if err != nil {
+				debug(fmt.Sprintf("Error: %s", err.Error()))
+				fmt.Fprintf(os.Stderr, "There was an error saving the usernames to %s.\n", filename)
+				return
+			}
+			fmt.Printf("The usernames were saved locally to %s.\n\n", filename)
+		}()
+	}
+	if len(shards.shards) == 1 {
+		list, err := postListFile(c, pid, hashMode, name, file.Name(), shards.shards[0], validated)
+		if err != nil {
+			writeStdErrAndExit(err.Error())
+		}
+		return []hashstack.List{list}
+	}
+
+	fmt.Printf("Splitting %d hashes into %d lists named %s through %s.\n\n", shards.lines, len(shards.shards), shardName(name, 0), shardName(name, len(shards.shards)-1))
+	var lists []hashstack.List
+	for i, shard := range shards.shards {
+		list, err := postListFile(c, pid, hashMode, shardName(name, i), shardName(name, i), shard, validated)
+		if err != nil {
+			deleteListGroup(c, pid, lists)
+			writeStdErrAndExit(err.Error())
+		}
+		lists = append(lists, list)
+	}
+	return lists
+}
+
+// displayNormalizeSummary reports the changes made to a file before it is uploaded.
+func displayNormalizeSummary(shards *shardWriter, n *hashfmt.Normalizer, result hashfmt.Result) {
+	fmt.Printf("Hashes..........: %d\n", shards.lines)
+	fmt.Printf("Duplicates......: %d removed\n", shards.duplicates)
+	if n != nil {
+		fmt.Printf("Usernames.......: %d removed\n", n.Stats.Usernames)
+		fmt.Printf("Lower Cased.....: %d\n", n.Stats.Lowercased)
+		fmt.Printf("Trimmed.........: %d\n", n.Stats.Trimmed)
+	}
+	if result.Invalid > 0 {
+		fmt.Printf("Invalid.........: %d removed\n", result.Invalid)
+	}
+	fmt.Println()
+}
+
+// postListFile uploads the contents of f as a new list. The error is a
+// message for the user.
+func postListFile(c *client.Client, pid int64, hashMode hashstack.HashMode, name, filename string, f *os.File, validated bool) (hashstack.List, error) {
+	var (
+		list      hashstack.List
+		uploadErr error
+	)
+	filestat, err := f.Stat()
+	if err != nil {
+		debug(fmt.Sprintf("Error: %s", err.Error()))
+		return list, errors.New("There was an error preparing the list for upload.")
+	}
+
+	pipeOut, pipeIn := io.Pipe()
+	writer := multipart.NewWriter(pipeIn)
+
+	bar := pb.New64(filestat.Size()).SetUnits(pb.U_BYTES)
+	bar.SetWidth(80)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		list, uploadErr = c.UploadList(ctx, pid, writer.FormDataContentType(), pipeOut)
+		// Stop the writer below if the server did not read the whole form.
+		pipeOut.CloseWithError(io.ErrClosedPipe)
+	}()
+
+	part, err := writer.CreateFormFile("file", filename)
+	if err == nil {
+		bar.Start()
+		_, err = io.Copy(io.MultiWriter(part, bar), io.NewSectionReader(f, 0, filestat.Size()))
+		bar.Finish()
+	}
+	if err == nil {
+		writer.WriteField("hash_mode", strconv.Itoa(hashMode.HashMode))
+		writer.WriteField("name", name)
+		isHexSaltStr := "false"
+		if flIsHexSalt {
+			isHexSaltStr = "true"
+		}
+		writer.WriteField("is_hex_salt", isHexSaltStr)
+		err = writer.Close()
+	}
+	pipeIn.CloseWithError(err)
+	<-done
+	fmt.Println("")
+
+	if err != nil {
+		debug(fmt.Sprintf("Error: %s", err.Error()))
+	}
+	if _, ok := uploadErr.(*client.RequestError); ok && err == io.ErrClosedPipe {
+		return list, errors.New("The list exceeded the maxmimum size supported by the server (64 MB).")
+	}
+	if uploadErr != nil {
+		if !validated {
+			return list, fmt.Errorf("%s\n\nThis error likely occurred because you did not have any valid hashes.", uploadErr.Error())
+		}
+		return list, uploadErr
+	}
+	if err != nil {
+		return list, errors.New("There was an error reading the provided file.")
+	}
+	return list, nil
+}
+
+// deleteListGroup deletes the lists uploaded before a later part of the group
+// failed, so that an incomplete group is not left on the server.
+func deleteListGroup(c *client.Client, pid int64, lists []hashstack.List) {
+	if len(lists) < 1 {
+		return
+	}
+	fmt.Fprintf(os.Stderr, "Deleting the %d lists that were uploaded before the error.\n", len(lists))
+	for _, l := range lists {
+		if err := c.DeleteList(ctx, pid, l.ID); err != nil {
+			debug(fmt.Sprintf("Error: %s", err.Error()))
+			fmt.Fprintf(os.Stderr, "The list %s could not be deleted, use hashstack lists delete to remove it.\n", l.Name)
+		}
+	}
+}
+
+// getListGroup returns the list identified by arg. When arg is the name of a
+// group of split lists, every list in the group is returned. The id or full
+// name of a part returns only that part.
+func getListGroup(projectID int64, arg string) []hashstack.List {
+	lists, err := listGroup(apiClient(), projectID, arg)
+	if err != nil {
+		writeStdErrAndExit(err.Error())
+	}
+	return lists
+}
+
+// listGroup is getListGroup returning the error.
+func listGroup(c *client.Client, projectID int64, arg string) ([]hashstack.List, error) {
+	var (
+		list hashstack.List
+		err  error
+	)
+	i, converr := strconv.Atoi(arg)
+	if converr == nil {
+		list, err = c.List(ctx, projectID, int64(i))
+	} else {
+		list, err = c.ListByName(ctx, projectID, arg)
+	}
+	if err == nil {
+		return []hashstack.List{list}, nil
+	}
+	if _, ok := err.(*client.NotFoundError); !ok || converr == nil {
+		return nil, err
+	}
+
+	lists, err := c.Lists(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+	var parts []hashstack.List
+	for _, l := range lists {
+		if strings.HasPrefix(l.Name, arg) && listShardSuffix.MatchString(l.Name) && len(l.Name) == len(shardName(arg, 0)) {
+			parts = append(parts, l)
+		}
+	}
+	if len(parts) < 1 {
+		return nil, new(client.NotFoundError)
+	}
+	sort.Slice(parts, func(i, j int) bool {
+		return parts[i].Name < parts[j].Name
+	})
+	return parts, nil
+}
+
+func displayListGroup(lists []hashstack.List) {
+	if len(lists) == 1 || !isStructuredOutput() {
+		for _, l := range lists {
+			displayList(l)
+		}
+		return
+	}
+	views := make([]listView, 0, len(lists))
+	for _, l := range lists {
+		views = append(views, newListView(l))
+	}
+	renderOutput(views)
+}
@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/stricture/hashstack-cli/client"
+	hashstack "github.com/stricture/hashstack-server-core-ng"
+)
+
+func TestShardWriter(t *testing.T) {
+	Convey("Given hashes written in pieces with duplicates", t, func() {
+		w := newShardWriter()
+		defer w.Remove()
+		w.Write([]byte("aaaa\r\nbb"))
+		w.Write([]byte("bb\naaaa\n\ncccc"))
+		So(w.Close(), ShouldBeNil)
+
+		Convey("Each unique hash is written once", func() {
+			So(w.lines, ShouldEqual, 3)
+			So(w.duplicates, ShouldEqual, 1)
+			So(len(w.shards), ShouldEqual, 1)
+			data, err := ioutil.ReadFile(w.shards[0].Name())
+			So(err, ShouldBeNil)
+			lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+			sort.Strings(lines)
+			So(lines, ShouldResemble, []string{"aaaa", "bbbb", "cccc"})
+		})
+	})
+
+	Convey("Shard names sort in upload order", t, func() {
+		So(shardName("dump.txt", 0), ShouldEqual, "dump.txt.part001")
+		So(listShardSuffix.MatchString(shardName("dump.txt", 11)), ShouldBeTrue)
+	})
+}
+
+func TestPostListFileError(t *testing.T) {
+	Convey("Given a server that rejects uploads", t, func() {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ioutil.ReadAll(r.Body)
+			w.WriteHeader(500)
+		}))
+		defer ts.Close()
+		w := newShardWriter()
+		defer w.Remove()
+		w.Write([]byte("aaaa\n"))
+		w.Close()
+		httpClient = nil
+		c := newClient(ts.URL, "secret")
+
+		Convey("The error is returned instead of exiting", func() {
+			_, err := postListFile(c, 1, hashstack.HashMode{HashMode: 0}, "dump.txt", "dump.txt", w.shards[0], true)
+			So(err, ShouldHaveSameTypeAs, new(client.InternalServerError))
+		})
+	})
+}
+
+func TestGetListGroup(t *testing.T) {
+	Convey("Given a list split into two parts", t, func() {
+		lists := []hashstack.List{{ID: 1, Name: "dump.txt.part001"}, {ID: 2, Name: "dump.txt.part002"}}
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			name := r.URL.Query().Get("name")
+			if name == "" && r.URL.Path == "/api/projects/1/lists" {
+				w.Header().Set("Content-Range", "0-1/2")
+				json.NewEncoder(w).Encode(lists)
+				return
+			}
+			for _, l := range lists {
+				if l.Name == name || r.URL.Path == fmt.Sprintf("/api/projects/1/lists/%d", l.ID) {
+					json.NewEncoder(w).Encode(l)
+					return
+				}
+			}
+			w.WriteHeader(404)
+		}))
+		defer ts.Close()
+		defer func(serverURL, token string) { flServerURL, flToken = serverURL, token }(flServerURL, flToken)
+		flServerURL, flToken, httpClient = ts.URL, "secret", nil
+
+		Convey("The file name returns every part", func() {
+			So(len(getListGroup(1, "dump.txt")), ShouldEqual, 2)
+		})
+
+		Convey("The name or id of a part returns only that part", func() {
+			So(getListGroup(1, "dump.txt.part002"), ShouldResemble, lists[1:])
+			So(getListGroup(1, "1"), ShouldResemble, lists[:1])
+		})
+	})
+}
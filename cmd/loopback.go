@@ -0,0 +1,255 @@
+package cmd
+
+import (
+	"bufio"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/stricture/hashstack-cli/client"
+	hashstack "github.com/stricture/hashstack-server-core-ng"
+)
+
+// wordlistEntry returns plain as a line of a wordlist. Plains that contain a
+// line break, or that would be read as hex, are written as $HEX[...], which
+// hashcat decodes when it reads the wordlist.
+func wordlistEntry(plain string) string {
+	if strings.ContainsAny(plain, "\r\n") || strings.HasPrefix(plain, "$HEX[") {
+		return fmt.Sprintf("$HEX[%s]", hex.EncodeToString([]byte(plain)))
+	}
+	return plain
+}
+
+// writeLoopback writes every unique cracked plain in lists to w, one per line,
+// and returns the number written. Plains cracked as $HEX[...] are decoded
+// before they are compared.
+func writeLoopback(w io.Writer, projectID int64, lists []hashstack.List) (int, error) {
+	var (
+		seen  = make(map[string]struct{})
+		bw    = bufio.NewWriter(w)
+		modes = make(map[int]hashstack.HashMode)
+		err   error
+	)
+	for _, list := range lists {
+		mode, ok := modes[list.HashMode]
+		if !ok {
+			mode = getMode(list.HashMode)
+			modes[list.HashMode] = mode
+		}
+		readPlains(projectID, list, mode, func(hash, plain string) {
+			if _, ok := seen[plain]; ok || plain == "" || err != nil {
+				return
+			}
+			seen[plain] = struct{}{}
+			_, err = fmt.Fprintln(bw, wordlistEntry(plain))
+		})
+	}
+	if err != nil {
+		return len(seen), err
+	}
+	return len(seen), bw.Flush()
+}
+
+// uploadLoopback uploads the cracked plains in lists as a wordlist named name.
+// It returns false without uploading anything when nothing has been cracked.
+// An existing wordlist is never replaced, since a job may still be using it.
+func uploadLoopback(projectID int64, lists []hashstack.List, name string) bool {
+	c := apiClient()
+	if _, err := c.File(ctx, client.WordlistFile, name); err == nil {
+		writeStdErrAndExit(fmt.Sprintf("A wordlist named %s already exists. Delete it or use another name.", name))
+	}
+	dir, err := ioutil.TempDir("", "hashstack-loopback-")
+	if err != nil {
+		debug(fmt.Sprintf("Error: %s", err.Error()))
+		writeStdErrAndExit("There was an error creating the loopback wordlist.")
+	}
+	defer os.RemoveAll(dir)
+	// writeStdErrAndExit does not run deferred calls.
+	prevBeforeExit := beforeExit
+	beforeExit = func() {
+		os.RemoveAll(dir)
+		if prevBeforeExit != nil {
+			prevBeforeExit()
+		}
+	}
+	defer func() { beforeExit = prevBeforeExit }()
+	filename := filepath.Join(dir, name)
+	fh, err := os.Create(filename)
+	if err != nil {
+		debug(fmt.Sprintf("Error: %s", err.Error()))
+		writeStdErrAndExit("There was an error creating the loopback wordlist.")
+	}
+	n, err := writeLoopback(fh, projectID, lists)
+	fh.Close()
+	if err != nil {
+		debug(fmt.Sprintf("Error: %s", err.Error()))
+		writeStdErrAndExit("There was an error writing the loopback wordlist.")
+	}
+	if n == 0 {
+		return false
+	}
+	fmt.Printf("Uploading %d cracked passwords as the wordlist %s.\n", n, name)
+	uploadFile(client.WordlistFile, filename)
+	return true
+}
+
+// loopbackPrefix returns the start of the names of the loopback wordlists
+// uploaded for the list group in project.
+func loopbackPrefix(project, group string) string {
+	name := fmt.Sprintf("loopback-%s-%s-", project, strings.TrimSuffix(group, ".txt"))
+	return strings.NewReplacer("/", "_", "\\", "_", " ", "_").Replace(name)
+}
+
+// wordlistsInUse returns the ids of the wordlists used by jobs that have not
+// finished. Wordlists are shared by every project, so the jobs of every project
+// you can see are checked, and for administrators every active job as well.
+func wordlistsInUse(c *client.Client) map[int64]bool {
+	projects, err := c.Projects(ctx)
+	if err != nil {
+		writeStdErrAndExit(err.Error())
+	}
+	var jobs []hashstack.Job
+	for _, p := range projects {
+		pjobs, err := c.Jobs(ctx, p.ID)
+		if err != nil {
+			writeStdErrAndExit(err.Error())
+		}
+		jobs = append(jobs, pjobs...)
+	}
+	adminJobs, err := c.AdminJobs(ctx)
+	switch err.(type) {
+	case nil:
+		jobs = append(jobs, adminJobs...)
+	case *client.AuthorizeError:
+		debug("LOOPBACK: only the jobs of your projects are checked without admin access")
+	default:
+		writeStdErrAndExit(err.Error())
+	}
+	used := make(map[int64]bool)
+	attacks := make(map[int64]bool)
+	for _, j := range jobs {
+		if j.IsExhausted || attacks[j.AttackID] {
+			continue
+		}
+		attacks[j.AttackID] = true
+		attack, err := c.Attack(ctx, j.AttackID)
+		if err != nil {
+			writeStdErrAndExit(err.Error())
+		}
+		for _, step := range attack.Steps {
+			used[step.WordlistID] = true
+			used[step.WordlistCombinationID] = true
+		}
+	}
+	return used
+}
+
+// pruneLoopback deletes the loopback wordlists that start with prefix, other
+// than keep, unless a job that has not finished uses them.
+func pruneLoopback(c *client.Client, prefix, keep string) {
+	files, err := c.Files(ctx, client.WordlistFile)
+	if err != nil {
+		writeStdErrAndExit(err.Error())
+	}
+	var used map[int64]bool
+	for _, f := range files {
+		if !strings.HasPrefix(f.Filename, prefix) || f.Filename == keep {
+			continue
+		}
+		if used == nil {
+			used = wordlistsInUse(c)
+		}
+		if used[f.ID] {
+			fmt.Printf("Keeping the wordlist %s, a job that has not finished uses it.\n", f.Filename)
+			continue
+		}
+		if err := c.DeleteFile(ctx, client.WordlistFile, f.ID); err != nil {
+			writeStdErrAndExit(err.Error())
+		}
+		fmt.Printf("Deleted the wordlist %s from an earlier run.\n", f.Filename)
+	}
+}
+
+var (
+	flLoopbackName    string
+	flLoopbackRules   string
+	flLoopbackJobName string
+)
+
+var loopbackListCmd = &cobra.Command{
+	Use:   "loopback <project_name|project_id> <list_name|list_id>",
+	Short: "Upload the cracked passwords of a list as a wordlist.",
+	Long: `
+Upload the unique cracked passwords of a list, or group of lists, as a wordlist named
+loopback-<project>-<list>-<time>.txt. Passwords cracked as $HEX[...] are decoded first, and passwords
+that contain a line break are written as $HEX[...]. Once the upload succeeds the wordlists from earlier
+runs are deleted, except those used by a job in any project that has not finished. Only the jobs of
+your own projects can be checked unless you are an administrator.
+
+Use --rules-file to add a straight job that runs the wordlist with the rules against the hashes that
+are not cracked yet:
+
+    hashstack lists loopback acme ntlm.txt --rules-file dive.rule
+`,
+	PreRun: ensureAuth,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) < 2 {
+			writeStdErrAndExit("project_name|project_id and list_name|list_id are required.")
+		}
+		project := getProject(args[0])
+		lists := getListGroup(project.ID, args[1])
+		group := listGroupName(lists)
+		prefix := loopbackPrefix(project.Name, group)
+		name := flLoopbackName
+		if name == "" {
+			name = fmt.Sprintf("%s%d.txt", prefix, time.Now().Unix())
+		}
+		if strings.ContainsAny(name, "/\\") {
+			writeStdErrAndExit("The wordlist name can not contain a slash.")
+		}
+		c := apiClient()
+		// Check the rules file first so that a wrong name does not leave a
+		// wordlist without a job after the earlier ones were deleted.
+		if flLoopbackRules != "" {
+			if _, err := c.File(ctx, client.RuleFile, flLoopbackRules); err != nil {
+				if _, ok := err.(*client.NotFoundError); ok {
+					writeStdErrAndExit(fmt.Sprintf("There is no rules file named %s.", flLoopbackRules))
+				}
+				writeStdErrAndExit(err.Error())
+			}
+		}
+		if !uploadLoopback(project.ID, lists, name) {
+			writeStdErrAndExit("There are no cracked passwords in the list.")
+		}
+		if flLoopbackName == "" {
+			pruneLoopback(c, prefix, name)
+		}
+		if flLoopbackRules == "" {
+			return
+		}
+		plan := attackPlan{Steps: []planStep{{AttackMode: 0, Wordlist: name, RulesFile: flLoopbackRules}}}
+		steps, err := plan.attackSteps(c)
+		if err != nil {
+			writeStdErrAndExit(err.Error())
+		}
+		jobName := flLoopbackJobName
+		if jobName == "" {
+			jobName = fmt.Sprintf("loopback-%s", filepath.Base(flLoopbackRules))
+		}
+		fmt.Println()
+		launchJobs(c, project, lists, jobName, 0, steps)
+	},
+}
+
+func init() {
+	loopbackListCmd.PersistentFlags().StringVar(&flLoopbackName, "name", "", "Name of the wordlist, which must not exist yet (default: loopback-<project>-<list>-<time>.txt)")
+	loopbackListCmd.PersistentFlags().StringVarP(&flLoopbackRules, "rules-file", "r", "", "Add a straight job that runs the wordlist with this rules file")
+	loopbackListCmd.PersistentFlags().StringVar(&flLoopbackJobName, "job-name", "", "Name of the job added with --rules-file (default: loopback-<rules file>)")
+	listCmd.AddCommand(loopbackListCmd)
+}
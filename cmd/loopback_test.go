@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/stricture/hashstack-cli/client"
+	hashstack "github.com/stricture/hashstack-server-core-ng"
+)
+
+func TestWordlistEntry(t *testing.T) {
+	Convey("Given cracked plains", t, func() {
+		Convey("Plains are written as they are", func() {
+			So(wordlistEntry("Summer2024!"), ShouldEqual, "Summer2024!")
+			So(wordlistEntry("pass:word"), ShouldEqual, "pass:word")
+		})
+
+		Convey("Plains with a line break or a $HEX prefix are hex encoded", func() {
+			So(wordlistEntry("a\nb"), ShouldEqual, "$HEX[610a62]")
+			So(wordlistEntry("$HEX[41]"), ShouldEqual, "$HEX[244845585b34315d]")
+		})
+	})
+}
+
+func TestLoopbackPrefix(t *testing.T) {
+	Convey("Loopback wordlist names are safe file names", t, func() {
+		So(loopbackPrefix("acme corp", "ntlm.txt"), ShouldEqual, "loopback-acme_corp-ntlm-")
+		So(loopbackPrefix("acme", "a/b"), ShouldEqual, "loopback-acme-a_b-")
+	})
+}
+
+func TestWordlistsInUse(t *testing.T) {
+	Convey("Given a job in another project that uses a wordlist", t, func() {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var v interface{}
+			switch r.URL.Path {
+			case "/api/admin/jobs":
+				w.WriteHeader(403)
+				return
+			case "/api/projects":
+				v = []hashstack.Project{{ID: 1, Name: "acme"}, {ID: 2, Name: "globex"}}
+			case "/api/projects/1/jobs":
+				v = []hashstack.Job{{ID: 1, ProjectID: 1, AttackID: 8, IsExhausted: true}}
+			case "/api/projects/2/jobs":
+				v = []hashstack.Job{{ID: 2, ProjectID: 2, AttackID: 9, IsActive: true}}
+			case "/api/attacks/8":
+				v = hashstack.Attack{ID: 8, Steps: []hashstack.AttackStep{{WordlistID: 4}}}
+			case "/api/attacks/9":
+				v = hashstack.Attack{ID: 9, Steps: []hashstack.AttackStep{{WordlistID: 5}}}
+			}
+			w.Header().Set("Content-Range", "0-1/1")
+			json.NewEncoder(w).Encode(v)
+		}))
+		defer ts.Close()
+
+		Convey("The wordlist is in use and those of finished jobs are not", func() {
+			used := wordlistsInUse(client.New(ts.URL, "secret"))
+			So(used[5], ShouldBeTrue)
+			So(used[4], ShouldBeFalse)
+		})
+	})
+}
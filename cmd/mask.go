@@ -0,0 +1,202 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/gosuri/uitable"
+	"github.com/spf13/cobra"
+	"github.com/stricture/hashstack-cli/audit"
+	"github.com/stricture/hashstack-cli/hcmask"
+	hashstack "github.com/stricture/hashstack-server-core-ng"
+)
+
+var (
+	flMaskMinLength int
+	flMaskMaxLength int
+	flMaskRequire   []string
+	flMaskCorpus    string
+	flMaskMax       int
+	flMaskOutFile   string
+)
+
+// maskView is a generated mask along with its hits in the corpus and keyspace.
+type maskView struct {
+	Mask     string `json:"mask"`
+	Hits     int    `json:"hits"`
+	Keyspace string `json:"keyspace"`
+}
+
+// corpusMasks returns the number of passwords in the corpus matching each
+// mask. The corpus is the cracked hashes of lists or, when lists is empty,
+// the file given by --corpus with one password per line.
+func corpusMasks(project hashstack.Project, lists []hashstack.List) map[string]int {
+	masks := make(map[string]int)
+	if len(lists) > 0 {
+		c := apiClient()
+		for _, l := range lists {
+			hashMode, err := c.HashMode(ctx, l.HashMode)
+			if err != nil {
+				writeStdErrAndExit(err.Error())
+			}
+			readPlains(project.ID, l, hashMode, func(hash, plain string) {
+				masks[audit.Mask(plain)]++
+			})
+		}
+		return masks
+	}
+	file, err := os.Open(flMaskCorpus)
+	if err != nil {
+		debug(fmt.Sprintf("Error: %s", err.Error()))
+		writeStdErrAndExit("There was an error opening the corpus file.")
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		if plain := audit.DecodePlain(strings.TrimRight(scanner.Text(), "\r")); plain != "" {
+			masks[audit.Mask(plain)]++
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		debug(fmt.Sprintf("Error: %s", err.Error()))
+		writeStdErrAndExit("There was an error reading the corpus file.")
+	}
+	return masks
+}
+
+func validateMaskPolicy(corpus bool) hcmask.Policy {
+	for _, r := range flMaskRequire {
+		valid := false
+		for _, name := range hcmask.ClassNames() {
+			if r == name {
+				valid = true
+			}
+		}
+		if !valid {
+			writeStdErrAndExit(fmt.Sprintf("%s is not a character class. Use %s.", r, strings.Join(hcmask.ClassNames(), ", ")))
+		}
+	}
+	if flMaskMaxLength > 0 && flMaskMaxLength < flMaskMinLength {
+		writeStdErrAndExit("--max-length must not be less than --min-length.")
+	}
+	if !corpus {
+		if flMaskMinLength < 1 || flMaskMaxLength < 1 {
+			writeStdErrAndExit("--min-length and --max-length are required without a corpus.")
+		}
+		if flMaskMax < 1 {
+			writeStdErrAndExit("--max-masks must be at least 1 without a corpus.")
+		}
+	}
+	return hcmask.Policy{
+		MinLength: flMaskMinLength,
+		MaxLength: flMaskMaxLength,
+		Required:  flMaskRequire,
+	}
+}
+
+var maskCmd = &cobra.Command{
+	Use:   "masks",
+	Short: "Generate masks for brute-force jobs (-h or --help for subcommands).",
+	Long:  "Generate masks for brute-force jobs (-h or --help for subcommands).",
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Usage()
+	},
+}
+
+var generateMaskCmd = &cobra.Command{
+	Use:   "generate [project_name|project_id list_name|list_id]",
+	Short: "Generate an .hcmask file from a password policy or cracked passwords.",
+	Long: `
+Generate an ordered .hcmask file for use with 'hashstack jobs add -a 3 --mask-file'.
+
+With a project and list, the masks of the cracked passwords in the list are ranked by the number of
+passwords they matched divided by their keyspace, so that the masks most likely to crack a password
+quickly are tried first. Use --corpus instead to rank the masks of a local file of passwords, one per line.
+--min-length, --max-length, and --require only keep the masks that meet the policy.
+
+Without a corpus, every mask made of ?l, ?u, ?d, and ?s that meets the policy given by --min-length,
+--max-length, and --require is generated, smallest keyspace first, up to --max-masks masks.
+
+Character classes for --require: lower, upper, digit, and special. For example:
+
+hashstack masks generate --min-length 8 --max-length 9 --require upper,lower,digit --file policy.hcmask
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		var (
+			project hashstack.Project
+			lists   []hashstack.List
+		)
+		switch {
+		case len(args) == 2:
+			if flMaskCorpus != "" {
+				writeStdErrAndExit("--corpus can not be used with a list.")
+			}
+			ensureAuth(cmd, args)
+			project = getProject(args[0])
+			lists = getListGroup(project.ID, args[1])
+		case len(args) != 0:
+			writeStdErrAndExit("Both project_name|project_id and list_name|list_id are required.")
+		}
+		corpus := len(lists) > 0 || flMaskCorpus != ""
+		policy := validateMaskPolicy(corpus)
+
+		var candidates []hcmask.Candidate
+		if corpus {
+			candidates = hcmask.FromCorpus(corpusMasks(project, lists), policy, flMaskMax)
+		} else {
+			candidates = hcmask.FromPolicy(policy, flMaskMax)
+		}
+		if len(candidates) < 1 {
+			writeStdErrAndExit("No masks meet the provided policy.")
+		}
+		var lines []hcmask.Line
+		views := make([]maskView, 0, len(candidates))
+		for _, c := range candidates {
+			lines = append(lines, c.Line)
+			views = append(views, maskView{Mask: c.Line.String(), Hits: c.Hits, Keyspace: c.Keyspace.String()})
+		}
+		if isStructuredOutput() {
+			renderOutput(views)
+			return
+		}
+		var w io.Writer = os.Stdout
+		if flMaskOutFile != "" {
+			fh, err := os.OpenFile(flMaskOutFile, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+			if err != nil {
+				debug(fmt.Sprintf("Error: %s", err.Error()))
+				writeStdErrAndExit("There was an error creating the mask file.")
+			}
+			defer fh.Close()
+			w = fh
+		}
+		if err := hcmask.Write(w, lines); err != nil {
+			debug(fmt.Sprintf("Error: %s", err.Error()))
+			writeStdErrAndExit("There was an error writing the mask file.")
+		}
+		if flMaskOutFile == "" {
+			return
+		}
+		tbl := uitable.New()
+		tbl.AddRow("Mask", "Hits", "Keyspace")
+		for _, v := range views {
+			tbl.AddRow(v.Mask, v.Hits, v.Keyspace)
+		}
+		fmt.Println(tbl)
+		fmt.Printf("\n%d masks were written to %s.\n", len(lines), flMaskOutFile)
+	},
+}
+
+func init() {
+	generateMaskCmd.PersistentFlags().IntVar(&flMaskMinLength, "min-length", 0, "Minimum password length")
+	generateMaskCmd.PersistentFlags().IntVar(&flMaskMaxLength, "max-length", 0, "Maximum password length")
+	generateMaskCmd.PersistentFlags().StringSliceVar(&flMaskRequire, "require", nil, "Character classes every password must contain")
+	generateMaskCmd.PersistentFlags().StringVar(&flMaskCorpus, "corpus", "", "File of passwords, one per line, to rank masks by")
+	generateMaskCmd.PersistentFlags().IntVar(&flMaskMax, "max-masks", 100, "Maximum number of masks to generate, 0 for all masks from a corpus")
+	generateMaskCmd.PersistentFlags().StringVar(&flMaskOutFile, "file", "", "Write the masks to this file instead of stdout")
+	maskCmd.AddCommand(generateMaskCmd)
+	RootCmd.AddCommand(maskCmd)
+}
@@ -11,9 +11,8 @@ import (
 )
 
 func getMode(mode int) hashstack.HashMode {
-	var hashmode hashstack.HashMode
-	path := fmt.Sprintf("/api/hash_modes?mode=%d", mode)
-	if err := getJSON(path, &hashmode); err != nil {
+	hashmode, err := apiClient().HashMode(ctx, mode)
+	if err != nil {
 		writeStdErrAndExit(err.Error())
 	}
 	return hashmode
@@ -25,13 +24,17 @@ var modeCmd = &cobra.Command{
 	Long:   "Prints a list of supported hash modes.",
 	PreRun: ensureAuth,
 	Run: func(cmd *cobra.Command, args []string) {
-		var hashModes []hashstack.HashMode
-		if err := getJSON("/api/hash_modes", &hashModes); err != nil {
+		hashModes, err := apiClient().HashModes(ctx)
+		if err != nil {
 			writeStdErrAndExit(err.Error())
 		}
 		sort.Slice(hashModes, func(i, j int) bool {
 			return hashModes[i].HashMode < hashModes[j].HashMode
 		})
+		if isStructuredOutput() {
+			renderOutput(hashModes)
+			return
+		}
 		tbl := uitable.New()
 		tbl.AddRow("#", "Name")
 		for _, mode := range hashModes {
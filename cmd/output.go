@@ -0,0 +1,165 @@
+package cmd
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+
+	"github.com/ghodss/yaml"
+)
+
+// Supported values for --output.
+const (
+	outputText = "text"
+	outputJSON = "json"
+	outputYAML = "yaml"
+	outputCSV  = "csv"
+)
+
+var flOutput string
+
+// isStructuredOutput returns true when --output requests a machine readable format.
+func isStructuredOutput() bool {
+	return flOutput != "" && flOutput != outputText
+}
+
+func validateOutput() {
+	switch flOutput {
+	case "", outputText, outputJSON, outputYAML, outputCSV:
+	default:
+		writeStdErrAndExit(fmt.Sprintf("Unsupported output format %s. Use text, json, yaml, or csv.", flOutput))
+	}
+}
+
+// renderOutput writes v to stdout in the format selected by --output. v should be
+// a struct or a slice of structs. For csv, each struct is written as a row with
+// nested fields flattened into dotted column names.
+func renderOutput(v interface{}) {
+	if rv := reflect.ValueOf(v); rv.Kind() == reflect.Slice && rv.IsNil() {
+		v = reflect.MakeSlice(rv.Type(), 0, 0).Interface()
+	}
+	switch flOutput {
+	case outputJSON:
+		data, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			debug(fmt.Sprintf("Error: %s", err.Error()))
+			writeStdErrAndExit("There was an error generating the JSON output.")
+		}
+		fmt.Println(string(data))
+	case outputYAML:
+		data, err := yaml.Marshal(v)
+		if err != nil {
+			debug(fmt.Sprintf("Error: %s", err.Error()))
+			writeStdErrAndExit("There was an error generating the YAML output.")
+		}
+		fmt.Print(string(data))
+	case outputCSV:
+		writeCSV(v)
+	}
+}
+
+// writeCSV writes a header row built from the type of v, or of its elements
+// when v is a slice, followed by a row for each struct. The header is written
+// even when there are no rows.
+func writeCSV(v interface{}) {
+	rv := reflect.ValueOf(v)
+	t := rv.Type()
+	var rows []reflect.Value
+	if rv.Kind() == reflect.Slice {
+		t = t.Elem()
+		for i := 0; i < rv.Len(); i++ {
+			rows = append(rows, rv.Index(i))
+		}
+	} else {
+		rows = append(rows, rv)
+	}
+	w := csv.NewWriter(os.Stdout)
+	var keys []string
+	flattenCSV("", t, reflect.Value{}, &keys, nil)
+	w.Write(keys)
+	for _, row := range rows {
+		var values []string
+		flattenCSV("", t, row, nil, &values)
+		w.Write(values)
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		debug(fmt.Sprintf("Error: %s", err.Error()))
+		writeStdErrAndExit("There was an error generating the CSV output.")
+	}
+}
+
+// flattenCSV appends a column for every exported field of t using the json
+// field names, and the value of the field in v. Embedded structs are inlined,
+// nested structs are prefixed with their parent's name, and slices or maps are
+// encoded as JSON. The columns only depend on t, so the fields of a nil pointer
+// have empty values. v may be the zero Value to write empty values, and keys
+// or values may be nil when they are not needed.
+func flattenCSV(prefix string, t reflect.Type, v reflect.Value, keys, values *[]string) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+		if !v.IsValid() {
+			continue
+		}
+		if v.IsNil() {
+			v = reflect.Value{}
+		} else {
+			v = v.Elem()
+		}
+	}
+	if t.Kind() != reflect.Struct {
+		if keys != nil {
+			*keys = append(*keys, prefix)
+		}
+		if values != nil {
+			*values = append(*values, csvValue(v))
+		}
+		return
+	}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		name := field.Name
+		if tag := field.Tag.Get("json"); tag != "" {
+			parts := strings.Split(tag, ",")
+			if parts[0] == "-" {
+				continue
+			}
+			if parts[0] != "" {
+				name = parts[0]
+			}
+		}
+		var fv reflect.Value
+		if v.IsValid() {
+			fv = v.Field(i)
+		}
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			flattenCSV(prefix, field.Type, fv, keys, values)
+			continue
+		}
+		if prefix != "" {
+			name = prefix + "." + name
+		}
+		flattenCSV(name, field.Type, fv, keys, values)
+	}
+}
+
+func csvValue(v reflect.Value) string {
+	if !v.IsValid() || (v.Kind() == reflect.Interface && v.IsNil()) {
+		return ""
+	}
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array, reflect.Map:
+		data, err := json.Marshal(v.Interface())
+		if err != nil {
+			return ""
+		}
+		return string(data)
+	}
+	return fmt.Sprintf("%v", v.Interface())
+}
@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+	hashstack "github.com/stricture/hashstack-server-core-ng"
+)
+
+func TestFlattenCSV(t *testing.T) {
+	Convey("Given a list view", t, func() {
+		view := newListView(hashstack.List{
+			ID:             7,
+			Name:           "ntlm.txt",
+			HashMode:       1000,
+			DigestCount:    4,
+			RecoveredCount: 1,
+		})
+		var keys, values []string
+		flattenCSV("", reflect.TypeOf(view), reflect.ValueOf(view), &keys, &values)
+
+		Convey("Embedded fields are inlined using their json names", func() {
+			So(keys, ShouldContain, "name")
+			So(keys, ShouldNotContain, "List.name")
+			So(keys[len(keys)-1], ShouldEqual, "cracked_percent")
+			So(values[len(values)-1], ShouldEqual, "25")
+		})
+	})
+
+	Convey("Given a job view with a nested list", t, func() {
+		view := jobView{
+			List: hashstack.List{Name: "md5.hash"},
+			events: []hashstack.AgentEvent{
+				{Buffer: "error"},
+			},
+		}
+		var keys, values []string
+		flattenCSV("", reflect.TypeOf(view), reflect.ValueOf(view), &keys, &values)
+
+		Convey("Nested fields are prefixed and unexported fields are skipped", func() {
+			So(keys, ShouldContain, "list.name")
+			So(values, ShouldContain, "md5.hash")
+			So(values, ShouldNotContain, "error")
+			So(len(keys), ShouldEqual, len(values))
+		})
+	})
+
+	Convey("Given rows with a nil and a set pointer to a struct", t, func() {
+		type row struct {
+			ID   int             `json:"id"`
+			List *hashstack.List `json:"list"`
+		}
+		var nilKeys, nilValues, setKeys, setValues []string
+		flattenCSV("", reflect.TypeOf(row{}), reflect.ValueOf(row{ID: 1}), &nilKeys, &nilValues)
+		flattenCSV("", reflect.TypeOf(row{}), reflect.ValueOf(row{ID: 2, List: &hashstack.List{Name: "md5.hash"}}), &setKeys, &setValues)
+
+		Convey("Both rows have the columns of the struct", func() {
+			So(nilKeys, ShouldResemble, setKeys)
+			So(len(nilValues), ShouldEqual, len(setValues))
+			So(nilKeys, ShouldContain, "list.name")
+			So(setValues, ShouldContain, "md5.hash")
+		})
+	})
+}
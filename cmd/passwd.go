@@ -3,54 +3,49 @@ package cmd
 import (
 	"fmt"
 
-	"github.com/howeyc/gopass"
 	"github.com/spf13/cobra"
-	hashstack "github.com/stricture/hashstack-server-core-ng"
+	"github.com/stricture/hashstack-cli/client"
 )
 
-type updateSelf struct {
-	Username    string `json:"username"`
-	Password    string `json:"password"`
-	NewPassword string `json:"new_password"`
-}
-
 var passwdCmd = &cobra.Command{
 	Use:   "passwd",
 	Short: "Change your current password.",
 	Long: `
-Change your current password.
+Change your current password. With --password-stdin the current and new passwords are read from the
+first two lines of stdin.
 `,
 	PreRun: ensureAuth,
 	Run: func(cmd *cobra.Command, args []string) {
-		var user hashstack.User
-		if err := getJSON("/api/users/self", &user); err != nil {
+		user, err := apiClient().Self(ctx)
+		if err != nil {
 			writeStdErrAndExit(err.Error())
 		}
-		fmt.Printf("Current password: ")
-		currentpass, err := gopass.GetPasswdMasked()
+		currentpass, err := readPassword("Current password: ")
 		if err != nil {
 			debug(fmt.Sprintf("Error: %s", err.Error()))
 			writeStdErrAndExit("There was an error reading your password.")
 		}
 
-		fmt.Printf("New password: ")
-		pass, err := gopass.GetPasswdMasked()
+		pass, err := readPassword("New password: ")
 		if err != nil {
 			debug(fmt.Sprintf("Error: %s", err.Error()))
 			writeStdErrAndExit("There was an error reading your password.")
 		}
-		fmt.Printf("Confirm password: ")
-		confirmpass, err := gopass.GetPasswdMasked()
-		if err != nil {
-			debug(fmt.Sprintf("Error: %s", err.Error()))
-			writeStdErrAndExit("There was an error reading your password.")
+		if !flPasswordStdin {
+			confirmpass, err := readPassword("Confirm password: ")
+			if err != nil {
+				debug(fmt.Sprintf("Error: %s", err.Error()))
+				writeStdErrAndExit("There was an error reading your password.")
+			}
+			if string(pass) != string(confirmpass) {
+				writeStdErrAndExit("Passwords did not match")
+			}
 		}
-
-		if string(pass) != string(confirmpass) {
-			writeStdErrAndExit("Passwords did not match")
+		if len(pass) == 0 {
+			writeStdErrAndExit("The new password can not be empty.")
 		}
 
-		if _, err := postJSON("/api/users/self", &updateSelf{
+		if err := apiClient().UpdateSelf(ctx, client.SelfUpdate{
 			Username:    user.Username,
 			Password:    string(currentpass),
 			NewPassword: string(pass),
@@ -63,5 +58,6 @@ Change your current password.
 }
 
 func init() {
+	passwdCmd.Flags().BoolVar(&flPasswordStdin, "password-stdin", false, "Read the current and new passwords from stdin")
 	RootCmd.AddCommand(passwdCmd)
 }
@@ -0,0 +1,485 @@
+package cmd
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/ghodss/yaml"
+	"github.com/gosuri/uitable"
+	"github.com/spf13/cobra"
+	"github.com/stricture/hashstack-cli/client"
+	hashstack "github.com/stricture/hashstack-server-core-ng"
+)
+
+var flPipelineRestart bool
+
+// loopbackWordlist is the wordlist name in a pipeline step that is replaced
+// by the passwords cracked so far.
+const loopbackWordlist = "$loopback"
+
+// Stage states.
+const (
+	stagePending = "pending"
+	stageRunning = "running"
+	stageDone    = "done"
+	stageSkipped = "skipped"
+)
+
+// pipelineStage is a set of jobs, one for each list in the group, that must
+// exhaust before the next stage starts.
+type pipelineStage struct {
+	Name string `toml:"name" json:"name"`
+	// Steps run as a single attack, as in a --plan file.
+	Steps []planStep `toml:"steps" json:"steps,omitempty"`
+	// Attack is the name or id of a saved attack to run instead of Steps.
+	Attack     string `toml:"attack" json:"attack,omitempty"`
+	Priority   int    `toml:"priority" json:"priority,omitempty"`
+	MaxDevices int    `toml:"max_devices" json:"max_devices,omitempty"`
+	// SkipIfCracked skips the stage when at least this percent of the hashes
+	// are cracked before it starts.
+	SkipIfCracked float64 `toml:"skip_if_cracked" json:"skip_if_cracked,omitempty"`
+}
+
+// loopback reports whether a step of the stage uses the cracked passwords as a wordlist.
+func (s pipelineStage) loopback() bool {
+	for _, step := range s.Steps {
+		if step.Wordlist == loopbackWordlist || step.CombinationWordlist == loopbackWordlist {
+			return true
+		}
+	}
+	return false
+}
+
+// pipeline is a pipeline file.
+type pipeline struct {
+	Name         string          `toml:"name" json:"name"`
+	Project      string          `toml:"project" json:"project"`
+	List         string          `toml:"list" json:"list"`
+	PollInterval string          `toml:"poll_interval" json:"poll_interval,omitempty"`
+	Stages       []pipelineStage `toml:"stages" json:"stages"`
+
+	interval time.Duration
+}
+
+// loadPipeline reads a pipeline from a TOML, YAML, or JSON file. The format is
+// chosen by the file extension.
+func loadPipeline(filename string) (pipeline, error) {
+	var p pipeline
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		debug(fmt.Sprintf("Error: %s", err.Error()))
+		return p, fmt.Errorf("There was an error reading the pipeline file %s.", filename)
+	}
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".toml":
+		err = toml.Unmarshal(data, &p)
+	case ".yaml", ".yml", ".json":
+		err = yaml.Unmarshal(data, &p)
+	default:
+		return p, fmt.Errorf("The pipeline file must end in .toml, .yaml, .yml, or .json.")
+	}
+	if err != nil {
+		return p, fmt.Errorf("There was an error parsing the pipeline file %s.\n\n%s", filename, err.Error())
+	}
+	if p.Name == "" {
+		p.Name = strings.TrimSuffix(filepath.Base(filename), filepath.Ext(filename))
+	}
+	if p.Project == "" || p.List == "" {
+		return p, fmt.Errorf("The pipeline file %s must set project and list.", filename)
+	}
+	p.interval = 30 * time.Second
+	if p.PollInterval != "" {
+		if p.interval, err = time.ParseDuration(p.PollInterval); err != nil || p.interval < 5*time.Second {
+			return p, fmt.Errorf("The poll_interval in %s must be a duration of at least 5s.", filename)
+		}
+	}
+	if len(p.Stages) < 1 {
+		return p, fmt.Errorf("The pipeline file %s does not contain any stages.", filename)
+	}
+	names := make(map[string]bool)
+	for i, s := range p.Stages {
+		if s.Name == "" {
+			p.Stages[i].Name = fmt.Sprintf("stage%d", i+1)
+		}
+		if names[p.Stages[i].Name] {
+			return p, fmt.Errorf("Stage %d: the name %s is used by another stage.", i+1, p.Stages[i].Name)
+		}
+		names[p.Stages[i].Name] = true
+		if (len(s.Steps) > 0) == (s.Attack != "") {
+			return p, fmt.Errorf("Stage %d: set either steps or attack.", i+1)
+		}
+		plan := attackPlan{Steps: s.Steps}
+		for j, step := range s.Steps {
+			if err := step.validate(); err != nil {
+				return p, fmt.Errorf("Stage %d: %s", i+1, plan.stepError(j, err).Error())
+			}
+		}
+	}
+	return p, nil
+}
+
+// stageState is the progress of a stage saved between runs.
+type stageState struct {
+	Name   string  `json:"name"`
+	Status string  `json:"status"`
+	JobIDs []int64 `json:"job_ids,omitempty"`
+	// Wordlist is the loopback wordlist uploaded for the stage.
+	Wordlist string `json:"wordlist,omitempty"`
+}
+
+// pipelineState is the progress of a pipeline saved between runs, so that it
+// can resume after the CLI is restarted.
+type pipelineState struct {
+	File      string       `json:"file"`
+	ProjectID int64        `json:"project_id"`
+	Stages    []stageState `json:"stages"`
+}
+
+// pipelineStatePath returns the location of the state of the pipeline file
+// filename on the current server.
+func pipelineStatePath(filename string) string {
+	path, err := filepath.Abs(filename)
+	if err != nil {
+		path = filename
+	}
+	sum := sha256.Sum256([]byte(path))
+	return filepath.Join(serverDataDir("pipelines"), hex.EncodeToString(sum[:8])+".json")
+}
+
+// loadPipelineState returns the saved state of the pipeline, or a new state
+// when there is none. Stages added to the file since the state was saved
+// start as pending.
+func loadPipelineState(filename string, p pipeline) (pipelineState, error) {
+	var state pipelineState
+	data, err := ioutil.ReadFile(pipelineStatePath(filename))
+	if err != nil && !os.IsNotExist(err) {
+		return state, err
+	}
+	if err == nil {
+		if err := json.Unmarshal(data, &state); err != nil {
+			return state, err
+		}
+	}
+	for i, s := range p.Stages {
+		if i < len(state.Stages) {
+			if state.Stages[i].Name != s.Name {
+				return state, fmt.Errorf("stage %d was renamed from %s to %s", i+1, state.Stages[i].Name, s.Name)
+			}
+			continue
+		}
+		state.Stages = append(state.Stages, stageState{Name: s.Name, Status: stagePending})
+	}
+	state.File = filename
+	return state, nil
+}
+
+func (s pipelineState) save(filename string) {
+	path := pipelineStatePath(filename)
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err == nil {
+		if err = os.MkdirAll(filepath.Dir(path), 0700); err == nil {
+			err = ioutil.WriteFile(path, data, 0600)
+		}
+	}
+	if err != nil {
+		debug(fmt.Sprintf("Error: %s", err.Error()))
+		writeStdErrAndExit("There was an error saving the state of the pipeline.")
+	}
+}
+
+// crackedRatio returns the percent of hashes in lists that are cracked.
+func crackedRatio(lists []hashstack.List) (int64, int64, float64) {
+	var cracked, digests int64
+	for _, l := range lists {
+		cracked += l.RecoveredCount
+		digests += l.DigestCount
+	}
+	if digests == 0 {
+		return cracked, digests, 0
+	}
+	return cracked, digests, float64(cracked) / float64(digests) * 100
+}
+
+// startStage creates the jobs for a stage and records them in state.
+func startStage(c *client.Client, p pipeline, project hashstack.Project, lists []hashstack.List, i int, state *pipelineState, filename string) {
+	stage := p.Stages[i]
+	st := &state.Stages[i]
+	if stage.loopback() && st.Wordlist == "" {
+		name := fmt.Sprintf("%s-%s-loopback-%d.txt", p.Name, stage.Name, time.Now().Unix())
+		if !uploadLoopback(project.ID, lists, name) {
+			fmt.Printf("Skipping stage %s, there are no cracked passwords to loop back.\n\n", stage.Name)
+			st.Status = stageSkipped
+			state.save(filename)
+			return
+		}
+		st.Wordlist = name
+		state.save(filename)
+	}
+
+	var (
+		attackID int64
+		steps    []client.AttackStep
+		err      error
+	)
+	if stage.Attack != "" {
+		attackID = getAttack(stage.Attack).ID
+	} else {
+		plan := attackPlan{Steps: make([]planStep, len(stage.Steps))}
+		for j, step := range stage.Steps {
+			if step.Wordlist == loopbackWordlist {
+				step.Wordlist = st.Wordlist
+			}
+			if step.CombinationWordlist == loopbackWordlist {
+				step.CombinationWordlist = st.Wordlist
+			}
+			plan.Steps[j] = step
+		}
+		if steps, err = plan.attackSteps(c); err != nil {
+			writeStdErrAndExit(fmt.Sprintf("Stage %s: %s", stage.Name, err.Error()))
+		}
+	}
+
+	// createJob reads the priority and device limit from the jobs add flags.
+	flPriority = stage.Priority
+	if flPriority == 0 {
+		flPriority = 1
+	}
+	flMaxDedicatedDevices = stage.MaxDevices
+	// The stage is running from its first job, so that resuming it creates
+	// the remaining jobs instead of checking skip_if_cracked again.
+	st.Status = stageRunning
+	state.save(filename)
+	name := fmt.Sprintf("%s-%s", p.Name, stage.Name)
+	for j, list := range lists {
+		// Jobs created before the CLI was stopped are not added again.
+		if j < len(st.JobIDs) {
+			continue
+		}
+		jobName := name
+		if len(lists) > 1 {
+			jobName = shardName(name, j)
+		}
+		var job hashstack.Job
+		if attackID != 0 {
+			job, err = createJob(c, project, list, jobName, attackID)
+		} else {
+			job, err = startJob(c, project, list, jobName, steps)
+		}
+		if err != nil {
+			state.save(filename)
+			writeStdErrAndExit(fmt.Sprintf("Stage %s: %s\n\nRun the same command again to resume the pipeline.", stage.Name, err.Error()))
+		}
+		st.JobIDs = append(st.JobIDs, job.ID)
+		state.save(filename)
+	}
+	fmt.Printf("Started stage %s with %d jobs.\n", stage.Name, len(st.JobIDs))
+}
+
+// waitStage polls the jobs of a stage until every one is exhausted. Jobs that
+// were deleted are treated as finished. Paused jobs are reported, since the
+// stage does not finish until they are started again.
+func waitStage(c *client.Client, p pipeline, project hashstack.Project, listArg string, st *stageState) {
+	var last string
+	for {
+		var exhausted, paused int
+		for _, id := range st.JobIDs {
+			job, err := c.Job(ctx, project.ID, id)
+			if _, ok := err.(*client.NotFoundError); ok {
+				debug(fmt.Sprintf("PIPELINE: job %d no longer exists", id))
+				exhausted++
+				continue
+			}
+			if err != nil {
+				writeStdErrAndExit(fmt.Sprintf("%s\n\nRun the same command again to resume the pipeline.", err.Error()))
+			}
+			if job.IsExhausted {
+				exhausted++
+			} else if !job.IsActive {
+				paused++
+			}
+		}
+		lists, err := listGroup(c, project.ID, listArg)
+		if err != nil {
+			writeStdErrAndExit(fmt.Sprintf("%s\n\nRun the same command again to resume the pipeline.", err.Error()))
+		}
+		cracked, digests, ratio := crackedRatio(lists)
+		status := fmt.Sprintf("Stage %s: %d of %d jobs exhausted, %d of %d hashes cracked (%.2f%%)", st.Name, exhausted, len(st.JobIDs), cracked, digests, ratio)
+		if paused > 0 {
+			status += fmt.Sprintf(", %d jobs paused (use hashstack jobs start to resume them)", paused)
+		}
+		if status != last {
+			fmt.Printf("%s  %s\n", time.Now().Format("2006-01-02 15:04:05"), status)
+			last = status
+		}
+		if exhausted == len(st.JobIDs) {
+			return
+		}
+		time.Sleep(p.interval)
+	}
+}
+
+// runPipeline runs every stage that has not finished, resuming a running stage
+// where it left off.
+func runPipeline(filename string, p pipeline, state pipelineState) {
+	c := apiClient()
+	project := getProject(p.Project)
+	if state.ProjectID != 0 && state.ProjectID != project.ID {
+		writeStdErrAndExit("The project in the pipeline file has changed. Use --restart to run the pipeline from the first stage.")
+	}
+	state.ProjectID = project.ID
+	state.save(filename)
+	for i, stage := range p.Stages {
+		st := &state.Stages[i]
+		switch st.Status {
+		case stageDone, stageSkipped:
+			continue
+		case stagePending:
+			lists := getListGroup(project.ID, p.List)
+			if _, _, ratio := crackedRatio(lists); stage.SkipIfCracked > 0 && ratio >= stage.SkipIfCracked {
+				fmt.Printf("Skipping stage %s, %.2f%% of the hashes are cracked.\n", stage.Name, ratio)
+				st.Status = stageSkipped
+				state.save(filename)
+				continue
+			}
+			startStage(c, p, project, lists, i, &state, filename)
+			if st.Status == stageSkipped {
+				continue
+			}
+		case stageRunning:
+			fmt.Printf("Resuming stage %s.\n", stage.Name)
+			if lists := getListGroup(project.ID, p.List); len(st.JobIDs) < len(lists) {
+				startStage(c, p, project, lists, i, &state, filename)
+			}
+		}
+		waitStage(c, p, project, p.List, st)
+		st.Status = stageDone
+		state.save(filename)
+		fmt.Println()
+	}
+	cracked, digests, ratio := crackedRatio(getListGroup(project.ID, p.List))
+	fmt.Printf("The pipeline %s is complete. %d of %d hashes are cracked (%.2f%%).\n", p.Name, cracked, digests, ratio)
+}
+
+var pipelineCmd = &cobra.Command{
+	Use:   "pipeline",
+	Short: "Run attacks against a list in stages (-h or --help for subcommands).",
+	Long: `
+Run attacks against a list in stages (-h or --help for subcommands).
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Usage()
+	},
+}
+
+var runPipelineCmd = &cobra.Command{
+	Use:   "run <pipeline_file>",
+	Short: "Run the stages of a pipeline file in order.",
+	Long: `
+Run the stages of a pipeline file in order. Each stage adds a job for each list in the group, then
+waits for every job to exhaust before the next stage starts. The pipeline file may be TOML, YAML, or
+JSON. For example:
+
+    name: acme-nt
+    project: acme
+    list: ntlm.txt
+    poll_interval: 1m
+    stages:
+      - name: quick
+        priority: 10
+        steps:
+          - attack_mode: 0
+            wordlist: rockyou.txt
+            rules_file: best64.rule
+      - name: loopback
+        skip_if_cracked: 90
+        steps:
+          - attack_mode: 0
+            wordlist: $loopback
+            rules_file: dive.rule
+      - name: masks
+        skip_if_cracked: 90
+        attack: eight-char-masks
+
+Stage steps use the same keys as a --plan file, see 'hashstack jobs add'. Use attack instead of steps
+to run a saved attack. A stage is skipped when at least skip_if_cracked percent of the hashes are
+cracked before it starts. A wordlist of $loopback is replaced by a wordlist of every password cracked
+so far, which is uploaded before the stage starts.
+
+The progress of the pipeline is saved locally, so running the same command again after the CLI is
+stopped resumes it where it left off. Use --restart to run the pipeline from the first stage.
+`,
+	PreRun: ensureAuth,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) < 1 {
+			writeStdErrAndExit("pipeline_file is required.")
+		}
+		if isStructuredOutput() {
+			writeStdErrAndExit("hashstack pipeline run does not support --output. Use 'hashstack pipeline status' instead.")
+		}
+		p, err := loadPipeline(args[0])
+		if err != nil {
+			writeStdErrAndExit(err.Error())
+		}
+		if flPipelineRestart {
+			os.Remove(pipelineStatePath(args[0]))
+		}
+		state, err := loadPipelineState(args[0], p)
+		if err != nil {
+			debug(fmt.Sprintf("Error: %s", err.Error()))
+			writeStdErrAndExit("The saved state does not match the pipeline file. Use --restart to run the pipeline from the first stage.")
+		}
+		runPipeline(args[0], p, state)
+	},
+}
+
+var statusPipelineCmd = &cobra.Command{
+	Use:   "status <pipeline_file>",
+	Short: "Display the saved progress of each stage of a pipeline file.",
+	Long: `
+Display the saved progress of each stage of a pipeline file.
+`,
+	PreRun: ensureAuth,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) < 1 {
+			writeStdErrAndExit("pipeline_file is required.")
+		}
+		p, err := loadPipeline(args[0])
+		if err != nil {
+			writeStdErrAndExit(err.Error())
+		}
+		state, err := loadPipelineState(args[0], p)
+		if err != nil {
+			debug(fmt.Sprintf("Error: %s", err.Error()))
+			writeStdErrAndExit("The saved state does not match the pipeline file. Use --restart to run the pipeline from the first stage.")
+		}
+		if isStructuredOutput() {
+			renderOutput(state)
+			return
+		}
+		tbl := uitable.New()
+		tbl.AddRow("Stage", "Status", "Jobs", "Wordlist")
+		for _, s := range state.Stages {
+			ids := make([]string, 0, len(s.JobIDs))
+			for _, id := range s.JobIDs {
+				ids = append(ids, fmt.Sprintf("%d", id))
+			}
+			tbl.AddRow(s.Name, s.Status, strings.Join(ids, ","), s.Wordlist)
+		}
+		fmt.Println(tbl)
+	},
+}
+
+func init() {
+	runPipelineCmd.PersistentFlags().BoolVar(&flPipelineRestart, "restart", false, "Discard the saved progress and run the pipeline from the first stage")
+	pipelineCmd.AddCommand(runPipelineCmd)
+	pipelineCmd.AddCommand(statusPipelineCmd)
+	RootCmd.AddCommand(pipelineCmd)
+}
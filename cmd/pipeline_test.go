@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestLoadPipeline(t *testing.T) {
+	Convey("Given a YAML pipeline file", t, func() {
+		p, err := loadPipeline("../fixtures/pipeline.yaml")
+
+		Convey("Every stage is loaded in order with defaults", func() {
+			So(err, ShouldBeNil)
+			So(p.Name, ShouldEqual, "pipeline")
+			So(p.interval, ShouldEqual, time.Minute)
+			So(len(p.Stages), ShouldEqual, 3)
+			So(p.Stages[0].Priority, ShouldEqual, 10)
+			So(p.Stages[0].loopback(), ShouldBeFalse)
+			So(p.Stages[1].Name, ShouldEqual, "stage2")
+			So(p.Stages[1].SkipIfCracked, ShouldEqual, 90)
+			So(p.Stages[1].loopback(), ShouldBeTrue)
+			So(p.Stages[2].Attack, ShouldEqual, "eight-char-masks")
+		})
+	})
+
+	Convey("Given a stage with both steps and an attack", t, func() {
+		dir, _ := ioutil.TempDir("", "hashstack-pipeline-")
+		defer os.RemoveAll(dir)
+		filename := filepath.Join(dir, "p.yaml")
+		ioutil.WriteFile(filename, []byte("project: a\nlist: b\nstages:\n  - attack: x\n    steps:\n      - attack_mode: 3\n        mask: ?a\n"), 0600)
+
+		Convey("Loading fails", func() {
+			_, err := loadPipeline(filename)
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldStartWith, "Stage 1:")
+		})
+	})
+}
+
+func TestPipelineState(t *testing.T) {
+	Convey("Given a saved state for a pipeline", t, func() {
+		dir, _ := ioutil.TempDir("", "hashstack-pipeline-")
+		defer os.RemoveAll(dir)
+		defer func(cfgFile string) { flCfgFile = cfgFile }(flCfgFile)
+		flCfgFile = filepath.Join(dir, "config")
+		p, err := loadPipeline("../fixtures/pipeline.yaml")
+		So(err, ShouldBeNil)
+		state, err := loadPipelineState("../fixtures/pipeline.yaml", p)
+		So(err, ShouldBeNil)
+		So(len(state.Stages), ShouldEqual, 3)
+		state.Stages[0].Status = stageDone
+		state.Stages[1].JobIDs = []int64{4, 5}
+		state.save("../fixtures/pipeline.yaml")
+
+		Convey("The progress is loaded again", func() {
+			state, err := loadPipelineState("../fixtures/pipeline.yaml", p)
+			So(err, ShouldBeNil)
+			So(state.Stages[0].Status, ShouldEqual, stageDone)
+			So(state.Stages[1].JobIDs, ShouldResemble, []int64{4, 5})
+			So(state.Stages[2].Status, ShouldEqual, stagePending)
+		})
+
+		Convey("A renamed stage does not match", func() {
+			p.Stages[0].Name = "fast"
+			_, err := loadPipelineState("../fixtures/pipeline.yaml", p)
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
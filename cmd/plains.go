@@ -0,0 +1,137 @@
+package cmd
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/stricture/hashstack-cli/audit"
+	"github.com/stricture/hashstack-cli/hashfmt"
+	hashstack "github.com/stricture/hashstack-server-core-ng"
+)
+
+// Formats for lists cracked.
+const (
+	crackedHashcat = "hashcat"
+	crackedPotfile = "potfile"
+	crackedJSONL   = "jsonl"
+	crackedCSV     = "csv"
+)
+
+// plainFields returns the number of ':' separated fields before the plain in
+// the hash:plain lines of a list of mode. Modes without local rules are
+// assumed to have a single field, or two when they are salted.
+func plainFields(mode hashstack.HashMode) int {
+	if f, ok := hashfmt.Lookup(mode.HashMode); ok {
+		return f.HashFields()
+	}
+	if mode.IsSalted {
+		return 2
+	}
+	return 1
+}
+
+// encodePlain returns plain as hashcat writes it, as $HEX[...] when it
+// contains control characters, is not valid UTF-8, or would itself be read as
+// $HEX[...]. When ascii is true any byte outside printable ASCII is encoded,
+// as hashcat does in its potfile.
+func encodePlain(plain string, ascii bool) string {
+	encode := strings.HasPrefix(plain, "$HEX[") || !utf8.ValidString(plain)
+	for i := 0; i < len(plain) && !encode; i++ {
+		c := plain[i]
+		encode = c < 0x20 || c == 0x7f || (ascii && c > 0x7f)
+	}
+	if encode {
+		return fmt.Sprintf("$HEX[%s]", hex.EncodeToString([]byte(plain)))
+	}
+	return plain
+}
+
+// crackedPlain is a cracked hash as written by lists cracked --format jsonl.
+type crackedPlain struct {
+	Username string `json:"username,omitempty"`
+	Hash     string `json:"hash"`
+	// Plain is encoded as hashcat would, and PlainHex is always hex.
+	Plain    string `json:"plain"`
+	PlainHex string `json:"plain_hex"`
+}
+
+// writeCracked parses the hash:plain lines read from r and writes them to w in
+// format. When users is not nil a line is written for each account that has
+// the hash, with an empty username for hashes without one. It returns the number of lines that could not be parsed.
+func writeCracked(r io.Reader, w io.Writer, format string, fields int, users map[string][]string, header bool) (int, error) {
+	var (
+		bw      = bufio.NewWriter(w)
+		cw      = csv.NewWriter(bw)
+		enc     = json.NewEncoder(bw)
+		invalid int
+	)
+	if format == crackedCSV && header {
+		row := []string{"hash", "plain", "plain_hex"}
+		if users != nil {
+			row = append([]string{"username"}, row...)
+		}
+		cw.Write(row)
+	}
+	write := func(user, hash, plain string) error {
+		switch format {
+		case crackedPotfile:
+			_, err := fmt.Fprintf(bw, "%s:%s\n", hash, encodePlain(plain, true))
+			return err
+		case crackedJSONL:
+			return enc.Encode(crackedPlain{Username: user, Hash: hash, Plain: encodePlain(plain, false), PlainHex: hex.EncodeToString([]byte(plain))})
+		case crackedCSV:
+			row := []string{hash, encodePlain(plain, false), hex.EncodeToString([]byte(plain))}
+			if users != nil {
+				row = append([]string{user}, row...)
+			}
+			return cw.Write(row)
+		}
+		if users != nil {
+			_, err := fmt.Fprintf(bw, "%s:%s:%s\n", user, hash, encodePlain(plain, false))
+			return err
+		}
+		_, err := fmt.Fprintf(bw, "%s:%s\n", hash, encodePlain(plain, false))
+		return err
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			continue
+		}
+		hash, plain, ok := audit.SplitPlain(line, fields)
+		if !ok {
+			debug(fmt.Sprintf("CRACKED: could not parse %q", line))
+			invalid++
+			continue
+		}
+		names := users[hash]
+		if len(names) == 0 {
+			names = users[strings.ToLower(hash)]
+		}
+		if len(names) == 0 {
+			names = []string{""}
+		}
+		for _, name := range names {
+			if err := write(name, hash, plain); err != nil {
+				return invalid, err
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return invalid, err
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return invalid, err
+	}
+	return invalid, bw.Flush()
+}
@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestWriteCracked(t *testing.T) {
+	Convey("Given cracked NTLM hashes with awkward plains", t, func() {
+		plains := "b4b9b02e6f09a9bd760f388b67351e2b:pass:word\n" +
+			"8846f7eaee8fb117ad06bdd830b7586c:$HEX[6361666509]\n" +
+			"31d6cfe0d16ae931b73c59d7e0c089c0:$HEX[ff00]\n" +
+			"not-a-line\n"
+		write := func(format string) (string, int) {
+			var out bytes.Buffer
+			invalid, err := writeCracked(strings.NewReader(plains), &out, format, 1, nil, true)
+			So(err, ShouldBeNil)
+			return out.String(), invalid
+		}
+
+		Convey("hashcat keeps colons and encodes control characters and invalid UTF-8", func() {
+			out, invalid := write(crackedHashcat)
+			So(invalid, ShouldEqual, 1)
+			So(out, ShouldEqual, "b4b9b02e6f09a9bd760f388b67351e2b:pass:word\n"+
+				"8846f7eaee8fb117ad06bdd830b7586c:$HEX[6361666509]\n"+
+				"31d6cfe0d16ae931b73c59d7e0c089c0:$HEX[ff00]\n")
+		})
+
+		Convey("jsonl has the plain and its hex", func() {
+			out, _ := write(crackedJSONL)
+			lines := strings.Split(strings.TrimSpace(out), "\n")
+			So(len(lines), ShouldEqual, 3)
+			So(lines[0], ShouldEqual, `{"hash":"b4b9b02e6f09a9bd760f388b67351e2b","plain":"pass:word","plain_hex":"706173733a776f7264"}`)
+		})
+
+		Convey("csv quotes fields and has a header", func() {
+			out, _ := write(crackedCSV)
+			So(strings.HasPrefix(out, "hash,plain,plain_hex\n"), ShouldBeTrue)
+			So(out, ShouldContainSubstring, "b4b9b02e6f09a9bd760f388b67351e2b,pass:word,706173733a776f7264\n")
+		})
+	})
+
+	Convey("Given plains outside printable ASCII", t, func() {
+		Convey("Only the potfile encoding hex encodes valid UTF-8", func() {
+			So(encodePlain("café", false), ShouldEqual, "café")
+			So(encodePlain("café", true), ShouldEqual, "$HEX[636166c3a9]")
+			So(encodePlain("$HEX[41]", false), ShouldEqual, "$HEX[244845585b34315d]")
+		})
+	})
+}
@@ -0,0 +1,284 @@
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/ghodss/yaml"
+	"github.com/stricture/hashstack-cli/client"
+	"github.com/stricture/hashstack-cli/hcmask"
+)
+
+var attackModeNames = map[int]string{
+	0: "Straight",
+	1: "Combination",
+	3: "Brute-force",
+	6: "Hybrid Wordlist + Mask",
+	7: "Hybrid Mask + Wordlist",
+}
+
+// planStep is a single attack step as written in a plan file. Files are
+// referenced by name and resolved to ids before the attack is created.
+type planStep struct {
+	AttackMode          int    `toml:"attack_mode" json:"attack_mode"`
+	Wordlist            string `toml:"wordlist" json:"wordlist,omitempty"`
+	CombinationWordlist string `toml:"combination_wordlist" json:"combination_wordlist,omitempty"`
+	RulesFile           string `toml:"rules_file" json:"rules_file,omitempty"`
+	RuleLeft            string `toml:"rule_left" json:"rule_left,omitempty"`
+	RuleRight           string `toml:"rule_right" json:"rule_right,omitempty"`
+	Mask                string `toml:"mask" json:"mask,omitempty"`
+	HexCharset          bool   `toml:"hex_charset" json:"hex_charset,omitempty"`
+	MarkovHcstat        string `toml:"markov_hcstat" json:"markov_hcstat,omitempty"`
+	MarkovThreshold     int    `toml:"markov_threshold" json:"markov_threshold,omitempty"`
+	CustomCharset1      string `toml:"custom_charset1" json:"custom_charset1,omitempty"`
+	CustomCharset2      string `toml:"custom_charset2" json:"custom_charset2,omitempty"`
+	CustomCharset3      string `toml:"custom_charset3" json:"custom_charset3,omitempty"`
+	CustomCharset4      string `toml:"custom_charset4" json:"custom_charset4,omitempty"`
+}
+
+// attackPlan is an ordered set of steps that run as a single attack.
+type attackPlan struct {
+	Steps []planStep `toml:"steps" json:"steps"`
+}
+
+// loadAttackPlan reads a plan from a TOML, YAML, or JSON file. The format is
+// chosen by the file extension.
+func loadAttackPlan(filename string) (attackPlan, error) {
+	var plan attackPlan
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		debug(fmt.Sprintf("Error: %s", err.Error()))
+		return plan, fmt.Errorf("There was an error reading the plan file %s.", filename)
+	}
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".toml":
+		err = toml.Unmarshal(data, &plan)
+	case ".yaml", ".yml", ".json":
+		err = yaml.Unmarshal(data, &plan)
+	default:
+		return plan, fmt.Errorf("The plan file must end in .toml, .yaml, .yml, or .json.")
+	}
+	if err != nil {
+		return plan, fmt.Errorf("There was an error parsing the plan file %s.\n\n%s", filename, err.Error())
+	}
+	if len(plan.Steps) < 1 {
+		return plan, fmt.Errorf("The plan file %s does not contain any steps.", filename)
+	}
+	for i, step := range plan.Steps {
+		if err := step.validate(); err != nil {
+			return plan, plan.stepError(i, err)
+		}
+	}
+	return plan, nil
+}
+
+// loadMaskPlan reads an .hcmask file and returns a brute-force step for each
+// mask. The custom charsets from the jobs add flags are used for masks that
+// do not set their own.
+func loadMaskPlan(filename string) (attackPlan, error) {
+	var plan attackPlan
+	file, err := os.Open(filename)
+	if err != nil {
+		debug(fmt.Sprintf("Error: %s", err.Error()))
+		return plan, fmt.Errorf("There was an error reading the mask file %s.", filename)
+	}
+	defer file.Close()
+	lines, err := hcmask.Parse(file)
+	if err != nil {
+		return plan, fmt.Errorf("There was an error parsing the mask file %s.\n\n%s", filename, err.Error())
+	}
+	if len(lines) < 1 {
+		return plan, fmt.Errorf("The mask file %s does not contain any masks.", filename)
+	}
+	for _, l := range lines {
+		step := planStepFromFlags([]string{l.Mask})
+		if l.Charsets != [4]string{} {
+			step.CustomCharset1, step.CustomCharset2, step.CustomCharset3, step.CustomCharset4 = l.Charsets[0], l.Charsets[1], l.Charsets[2], l.Charsets[3]
+		}
+		l.Charsets = [4]string{step.CustomCharset1, step.CustomCharset2, step.CustomCharset3, step.CustomCharset4}
+		if _, err := l.Keyspace(step.HexCharset); err != nil {
+			plan.Steps = append(plan.Steps, step)
+			return plan, plan.stepError(len(plan.Steps)-1, fmt.Errorf("The mask %s is not valid: %s.", l.String(), err.Error()))
+		}
+		plan.Steps = append(plan.Steps, step)
+	}
+	return plan, nil
+}
+
+// planStepFromFlags builds a step from the jobs add flags and the positional
+// arguments that follow the job name.
+func planStepFromFlags(args []string) planStep {
+	step := planStep{
+		AttackMode:      flAttackMode,
+		RulesFile:       flRulesFile,
+		RuleLeft:        flRuleLeft,
+		RuleRight:       flRuleRight,
+		HexCharset:      flIsHexCharset,
+		MarkovHcstat:    flMarkovHcstat,
+		MarkovThreshold: flMarkovThreshold,
+		CustomCharset1:  flCustomCharset1,
+		CustomCharset2:  flCustomCharset2,
+		CustomCharset3:  flCustomCharset3,
+		CustomCharset4:  flCustomCharset4,
+	}
+	arg := func(i int) string {
+		if i < len(args) {
+			return args[i]
+		}
+		return ""
+	}
+	switch flAttackMode {
+	case 0:
+		step.Wordlist = arg(0)
+	case 1:
+		step.Wordlist = arg(0)
+		step.CombinationWordlist = arg(1)
+	case 3:
+		step.Mask = arg(0)
+	case 6:
+		step.Wordlist = arg(0)
+		step.Mask = arg(1)
+	case 7:
+		step.Mask = arg(0)
+		step.Wordlist = arg(1)
+	}
+	return step
+}
+
+// validate checks that the step has the inputs required by its attack mode.
+func (s planStep) validate() error {
+	switch s.AttackMode {
+	case 0:
+		if s.Wordlist == "" {
+			return fmt.Errorf("A wordlist is required for a straight attack.")
+		}
+	case 1:
+		if s.Wordlist == "" || s.CombinationWordlist == "" {
+			return fmt.Errorf("Two wordlist files are required for a combination attack.")
+		}
+	case 3:
+		if s.Mask == "" {
+			return fmt.Errorf("A mask is required for a brute-force attack.")
+		}
+	case 6:
+		if s.Wordlist == "" || s.Mask == "" {
+			return fmt.Errorf("A wordlist file and mask are required for this attack mode.")
+		}
+	case 7:
+		if s.Wordlist == "" || s.Mask == "" {
+			return fmt.Errorf("A mask and wordlist file are required for this attack mode.")
+		}
+	default:
+		return fmt.Errorf("The attack-mode %d is not valid.", s.AttackMode)
+	}
+	if s.RulesFile != "" && s.AttackMode != 0 {
+		return fmt.Errorf("A rules file can only be used with a straight attack.")
+	}
+	// hashcat applies -j to the wordlist of -a 6 and -k to the wordlist of -a 7.
+	if s.RuleLeft != "" && s.AttackMode != 1 && s.AttackMode != 6 {
+		return fmt.Errorf("rule_left can only be used with a combination attack or attack-mode 6.")
+	}
+	if s.RuleRight != "" && s.AttackMode != 1 && s.AttackMode != 7 {
+		return fmt.Errorf("rule_right can only be used with a combination attack or attack-mode 7.")
+	}
+	if s.MarkovHcstat != "" && s.Mask == "" {
+		return fmt.Errorf("A markov hcstat file can only be used with a mask.")
+	}
+	return nil
+}
+
+// fileResolver looks up server file ids by name, caching each result.
+type fileResolver struct {
+	client *client.Client
+	ids    map[string]int64
+}
+
+func newFileResolver(c *client.Client) *fileResolver {
+	return &fileResolver{
+		client: c,
+		ids:    make(map[string]int64),
+	}
+}
+
+func (r *fileResolver) resolve(kind client.FileKind, filename string) (int64, error) {
+	key := fmt.Sprintf("%s/%s", kind, filename)
+	if id, ok := r.ids[key]; ok {
+		return id, nil
+	}
+	f, err := r.client.File(ctx, kind, filename)
+	if err != nil {
+		debug(fmt.Sprintf("Error: %s", err.Error()))
+		return 0, fmt.Errorf("The %s file %s does not exist on the server.", strings.TrimSuffix(string(kind), "s"), filename)
+	}
+	r.ids[key] = f.ID
+	return f.ID, nil
+}
+
+// attackStep resolves the files referenced by the step and returns the step
+// to send to the server.
+func (s planStep) attackStep(idx int, r *fileResolver) (client.AttackStep, error) {
+	step := client.AttackStep{
+		IDX:             idx,
+		AttackMode:      s.AttackMode,
+		RuleBufLeft:     s.RuleLeft,
+		RuleBufRight:    s.RuleRight,
+		Mask:            s.Mask,
+		IsHexCharset:    s.HexCharset,
+		MarkovThreshold: s.MarkovThreshold,
+		CustomCharset1:  s.CustomCharset1,
+		CustomCharset2:  s.CustomCharset2,
+		CustomCharset3:  s.CustomCharset3,
+		CustomCharset4:  s.CustomCharset4,
+	}
+	var err error
+	if s.Wordlist != "" {
+		if step.WordlistID, err = r.resolve(client.WordlistFile, s.Wordlist); err != nil {
+			return step, err
+		}
+	}
+	if s.CombinationWordlist != "" {
+		if step.WordlistCombinationID, err = r.resolve(client.WordlistFile, s.CombinationWordlist); err != nil {
+			return step, err
+		}
+	}
+	if s.RulesFile != "" {
+		if step.RuleID, err = r.resolve(client.RuleFile, s.RulesFile); err != nil {
+			return step, err
+		}
+	}
+	if s.MarkovHcstat != "" {
+		if step.MarkovHCStatFileID, err = r.resolve(client.HCStatFile, s.MarkovHcstat); err != nil {
+			return step, err
+		}
+	}
+	return step, nil
+}
+
+// attackSteps validates and resolves every step in the plan in order.
+func (p attackPlan) attackSteps(c *client.Client) ([]client.AttackStep, error) {
+	r := newFileResolver(c)
+	var steps []client.AttackStep
+	for i, s := range p.Steps {
+		if err := s.validate(); err != nil {
+			return nil, p.stepError(i, err)
+		}
+		step, err := s.attackStep(i, r)
+		if err != nil {
+			return nil, p.stepError(i, err)
+		}
+		steps = append(steps, step)
+	}
+	return steps, nil
+}
+
+// stepError prefixes err with the step number when the plan has more than one step.
+func (p attackPlan) stepError(i int, err error) error {
+	if len(p.Steps) < 2 {
+		return err
+	}
+	return fmt.Errorf("Step %d: %s", i+1, err.Error())
+}
@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestLoadAttackPlan(t *testing.T) {
+	Convey("Given a TOML plan file", t, func() {
+		plan, err := loadAttackPlan("../fixtures/plan.toml")
+
+		Convey("Every step is loaded in order", func() {
+			So(err, ShouldBeNil)
+			So(len(plan.Steps), ShouldEqual, 2)
+			So(plan.Steps[0].RulesFile, ShouldEqual, "best64.rule")
+			So(plan.Steps[1].Mask, ShouldEqual, "?u?l?l?l?l?d?d")
+		})
+	})
+
+	Convey("Given a step without its required inputs", t, func() {
+		plan := attackPlan{Steps: []planStep{{AttackMode: 0, Wordlist: "a"}, {AttackMode: 6, Wordlist: "a"}}}
+
+		Convey("The error names the step", func() {
+			err := plan.Steps[1].validate()
+			So(err, ShouldNotBeNil)
+			So(plan.stepError(1, err).Error(), ShouldStartWith, "Step 2:")
+		})
+	})
+
+	Convey("Given a rules file on a brute-force step", t, func() {
+		step := planStep{AttackMode: 3, Mask: "?a", RulesFile: "best64.rule"}
+
+		Convey("Validation fails", func() {
+			So(step.validate(), ShouldNotBeNil)
+		})
+	})
+
+	Convey("Given single rules on hybrid steps", t, func() {
+		Convey("rule_left applies to the wordlist of attack-mode 6", func() {
+			So(planStep{AttackMode: 6, Wordlist: "a", Mask: "?d", RuleLeft: "c"}.validate(), ShouldBeNil)
+			So(planStep{AttackMode: 6, Wordlist: "a", Mask: "?d", RuleRight: "c"}.validate(), ShouldNotBeNil)
+		})
+
+		Convey("rule_right applies to the wordlist of attack-mode 7", func() {
+			So(planStep{AttackMode: 7, Wordlist: "a", Mask: "?d", RuleRight: "c"}.validate(), ShouldBeNil)
+			So(planStep{AttackMode: 7, Wordlist: "a", Mask: "?d", RuleLeft: "c"}.validate(), ShouldNotBeNil)
+		})
+
+		Convey("Straight attacks use a rules file instead", func() {
+			So(planStep{AttackMode: 0, Wordlist: "a", RuleLeft: "c"}.validate(), ShouldNotBeNil)
+		})
+	})
+}
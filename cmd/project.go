@@ -1,7 +1,6 @@
 package cmd
 
 import (
-	"encoding/json"
 	"fmt"
 	"sort"
 	"strconv"
@@ -10,6 +9,7 @@ import (
 
 	humanize "github.com/dustin/go-humanize"
 	"github.com/spf13/cobra"
+	"github.com/stricture/hashstack-cli/client"
 	hashstack "github.com/stricture/hashstack-server-core-ng"
 )
 
@@ -18,15 +18,11 @@ var (
 )
 
 func getListCount(projectID int64) (int, error) {
-	path := fmt.Sprintf("/api/projects/%d/lists", projectID)
-	return getTotal(path)
+	return apiClient().ListCount(ctx, projectID)
 }
 
 func getJobs(projectID int64) ([]hashstack.Job, error) {
-	path := fmt.Sprintf("/api/projects/%d/jobs", projectID)
-	var jobs []hashstack.Job
-	err := getRangeJSON(path, &jobs)
-	return jobs, err
+	return apiClient().Jobs(ctx, projectID)
 }
 
 func getProject(arg string) hashstack.Project {
@@ -43,20 +39,33 @@ func getProject(arg string) hashstack.Project {
 }
 
 func getProjectByName(p *hashstack.Project) {
-	path := fmt.Sprintf("/api/projects?name=%s", p.Name)
-	if err := getJSON(path, &p); err != nil {
+	project, err := apiClient().ProjectByName(ctx, p.Name)
+	if err != nil {
 		writeStdErrAndExit(err.Error())
 	}
+	*p = project
 }
 
 func getProjectByID(p *hashstack.Project) {
-	path := fmt.Sprintf("/api/projects/%d", p.ID)
-	if err := getJSON(path, p); err != nil {
+	project, err := apiClient().Project(ctx, p.ID)
+	if err != nil {
 		writeStdErrAndExit(err.Error())
 	}
+	*p = project
 }
 
-func displayProject(p hashstack.Project) {
+// projectView is a project along with its job and list counts.
+type projectView struct {
+	hashstack.Project
+	OwnerUsername string `json:"owner_username"`
+	ActiveJobs    int    `json:"active_jobs"`
+	PausedJobs    int    `json:"paused_jobs"`
+	CompletedJobs int    `json:"completed_jobs"`
+	JobCount      int    `json:"job_count"`
+	ListCount     int    `json:"list_count"`
+}
+
+func newProjectView(p hashstack.Project) projectView {
 	if p.Name == "" && p.ID != 0 {
 		getProjectByID(&p)
 	}
@@ -67,42 +76,50 @@ func displayProject(p hashstack.Project) {
 	if err != nil {
 		writeStdErrAndExit(err.Error())
 	}
-	var (
-		isActive    int
-		isPaused    int
-		isCompleted int
-	)
+	view := projectView{
+		Project:  p,
+		JobCount: len(jobs),
+	}
 	for _, j := range jobs {
 		if j.IsExhausted {
-			isCompleted++
+			view.CompletedJobs++
 		} else if j.IsActive {
-			isActive++
+			view.ActiveJobs++
 		} else {
-			isPaused++
+			view.PausedJobs++
 		}
 	}
-	jobstat := fmt.Sprintf("%d/%d Active %d/%d Complete", isActive, isActive+isPaused, isCompleted, len(jobs))
-	listCount, err := getListCount(p.ID)
+	view.ListCount, err = getListCount(p.ID)
 	if err != nil {
 		writeStdErrAndExit(err.Error())
 	}
-	var owner string
 
 	if glDisplayMulti {
 		user := hashstack.User{
 			ID: p.OwnerUserID,
 		}
 		getUser(&user)
-		owner = user.Username
+		view.OwnerUsername = user.Username
 	} else {
-		owner = p.Owner.Username
+		view.OwnerUsername = p.Owner.Username
 	}
+	return view
+}
+
+func displayProject(p hashstack.Project) {
+	view := newProjectView(p)
+	if isStructuredOutput() {
+		renderOutput(view)
+		return
+	}
+	p = view.Project
+	jobstat := fmt.Sprintf("%d/%d Active %d/%d Complete", view.ActiveJobs, view.ActiveJobs+view.PausedJobs, view.CompletedJobs, view.JobCount)
 	fmt.Printf("ID...............: %d\n", p.ID)
 	fmt.Printf("Name.............: %s\n", p.Name)
 	fmt.Printf("Description......: %s\n", p.Description)
 	fmt.Printf("Jobs.............: %s\n", jobstat)
-	fmt.Printf("Lists............: %d\n", listCount)
-	fmt.Printf("Owner............: %s\n", owner)
+	fmt.Printf("Lists............: %d\n", view.ListCount)
+	fmt.Printf("Owner............: %s\n", view.OwnerUsername)
 	if !glDisplayMulti {
 		var names []string
 		for _, u := range p.Contributors {
@@ -121,8 +138,8 @@ func displayProject(p hashstack.Project) {
 }
 
 func getProjects() []hashstack.Project {
-	var projects []hashstack.Project
-	if err := getRangeJSON("/api/projects", &projects); err != nil {
+	projects, err := apiClient().Projects(ctx)
+	if err != nil {
 		writeStdErrAndExit(err.Error())
 	}
 	sort.Slice(projects, func(i, j int) bool {
@@ -132,6 +149,14 @@ func getProjects() []hashstack.Project {
 }
 
 func displayProjects(projects []hashstack.Project) {
+	if isStructuredOutput() {
+		views := make([]projectView, 0, len(projects))
+		for _, p := range projects {
+			views = append(views, newProjectView(p))
+		}
+		renderOutput(views)
+		return
+	}
 	for _, p := range projects {
 		displayProject(p)
 	}
@@ -162,48 +187,36 @@ Once a project is created, you will upload your lists using the project's name.
 		}
 		glDisplayMulti = true
 		projects := getProjects()
-		if len(projects) < 1 {
+		if len(projects) < 1 && !isStructuredOutput() {
 			writeStdErrAndExit("You have not created any projects!")
 		}
 		displayProjects(projects)
 	},
 }
 
-type updateContributor struct {
-	ID int64 `json:"id"`
-}
-
-type updateProjectRequest struct {
-	Name         string `json:"name"`
-	Description  string `json:"description"`
-	IsActive     bool   `json:"is_active"`
-	OwnerUserID  int64  `json:"owner_user_id"`
-	Contributors []updateContributor
-	Teams        []updateContributor
-}
-
 var addProjectTeamCmd = &cobra.Command{
-	Use:   "add-team <project_name|project_id> <team_name>",
-	Short: "Adds a team to the project.",
-	Long:  "Adds a team to the project.",
+	Use:    "add-team <project_name|project_id> <team_name>",
+	Short:  "Adds a team to the project.",
+	Long:   "Adds a team to the project.",
+	PreRun: ensureAuth,
 	Run: func(cmd *cobra.Command, args []string) {
 		if len(args) < 2 {
 			writeStdErrAndExit("project_name or project_id and team_name is required.")
 		}
 		project := getProject(args[0])
-		var contribs []updateContributor
-		var teams []updateContributor
+		var contribs []client.Contributor
+		var teams []client.Contributor
 		for _, c := range project.Contributors {
-			contribs = append(contribs, updateContributor{ID: c.ID})
+			contribs = append(contribs, client.Contributor{ID: c.ID})
 		}
 		team := getTeam(hashstack.Team{
 			Name: args[1],
 		})
 		project.Teams = append(project.Teams, team)
 		for _, t := range project.Teams {
-			teams = append(teams, updateContributor{ID: t.ID})
+			teams = append(teams, client.Contributor{ID: t.ID})
 		}
-		update := updateProjectRequest{
+		update := client.ProjectUpdate{
 			Name:         project.Name,
 			Description:  project.Description,
 			IsActive:     project.IsActive,
@@ -211,7 +224,7 @@ var addProjectTeamCmd = &cobra.Command{
 			Contributors: contribs,
 			Teams:        teams,
 		}
-		if _, err := patchJSON(fmt.Sprintf("/api/projects/%d", project.ID), update); err != nil {
+		if err := apiClient().UpdateProject(ctx, project.ID, update); err != nil {
 			writeStdErrAndExit(err.Error())
 		}
 		fmt.Println("Team has been added to the project.")
@@ -219,18 +232,19 @@ var addProjectTeamCmd = &cobra.Command{
 }
 
 var removeProjectTeamCmd = &cobra.Command{
-	Use:   "remove-team <project_name|project_id> <team_name>",
-	Short: "Removes a team from the project.",
-	Long:  "Removes a team from the project.",
+	Use:    "remove-team <project_name|project_id> <team_name>",
+	Short:  "Removes a team from the project.",
+	Long:   "Removes a team from the project.",
+	PreRun: ensureAuth,
 	Run: func(cmd *cobra.Command, args []string) {
 		if len(args) < 2 {
 			writeStdErrAndExit("project_name or project_id and team_name is required.")
 		}
 		project := getProject(args[0])
-		var contribs []updateContributor
-		var teams []updateContributor
+		var contribs []client.Contributor
+		var teams []client.Contributor
 		for _, c := range project.Contributors {
-			contribs = append(contribs, updateContributor{ID: c.ID})
+			contribs = append(contribs, client.Contributor{ID: c.ID})
 		}
 		team := getTeam(hashstack.Team{
 			Name: args[1],
@@ -239,9 +253,9 @@ var removeProjectTeamCmd = &cobra.Command{
 			if t.ID == team.ID {
 				continue
 			}
-			teams = append(teams, updateContributor{ID: t.ID})
+			teams = append(teams, client.Contributor{ID: t.ID})
 		}
-		update := updateProjectRequest{
+		update := client.ProjectUpdate{
 			Name:         project.Name,
 			Description:  project.Description,
 			IsActive:     project.IsActive,
@@ -249,7 +263,7 @@ var removeProjectTeamCmd = &cobra.Command{
 			Contributors: contribs,
 			Teams:        teams,
 		}
-		if _, err := patchJSON(fmt.Sprintf("/api/projects/%d", project.ID), update); err != nil {
+		if err := apiClient().UpdateProject(ctx, project.ID, update); err != nil {
 			writeStdErrAndExit(err.Error())
 		}
 		fmt.Println("Team has been removed from the project.")
@@ -271,15 +285,15 @@ var addProjectContributorCmd = &cobra.Command{
 		}
 		getUser(&user)
 		project.Contributors = append(project.Contributors, user)
-		var contribs []updateContributor
-		var teams []updateContributor
+		var contribs []client.Contributor
+		var teams []client.Contributor
 		for _, c := range project.Contributors {
-			contribs = append(contribs, updateContributor{ID: c.ID})
+			contribs = append(contribs, client.Contributor{ID: c.ID})
 		}
 		for _, t := range project.Teams {
-			teams = append(teams, updateContributor{ID: t.ID})
+			teams = append(teams, client.Contributor{ID: t.ID})
 		}
-		update := updateProjectRequest{
+		update := client.ProjectUpdate{
 			Name:         project.Name,
 			Description:  project.Description,
 			IsActive:     project.IsActive,
@@ -287,7 +301,7 @@ var addProjectContributorCmd = &cobra.Command{
 			Contributors: contribs,
 			Teams:        teams,
 		}
-		if _, err := patchJSON(fmt.Sprintf("/api/projects/%d", project.ID), update); err != nil {
+		if err := apiClient().UpdateProject(ctx, project.ID, update); err != nil {
 			writeStdErrAndExit(err.Error())
 		}
 		fmt.Println("User added to the project.")
@@ -311,18 +325,18 @@ var removeProjectContributorCmd = &cobra.Command{
 			Username: args[1],
 		}
 		getUser(&user)
-		var teams []updateContributor
-		var contribs []updateContributor
+		var teams []client.Contributor
+		var contribs []client.Contributor
 		for _, c := range project.Contributors {
 			if c.ID == user.ID {
 				continue
 			}
-			contribs = append(contribs, updateContributor{ID: c.ID})
+			contribs = append(contribs, client.Contributor{ID: c.ID})
 		}
 		for _, t := range project.Teams {
-			teams = append(teams, updateContributor{ID: t.ID})
+			teams = append(teams, client.Contributor{ID: t.ID})
 		}
-		update := updateProjectRequest{
+		update := client.ProjectUpdate{
 			Name:         project.Name,
 			Description:  project.Description,
 			IsActive:     project.IsActive,
@@ -331,7 +345,7 @@ var removeProjectContributorCmd = &cobra.Command{
 			Teams:        teams,
 		}
 
-		if _, err := patchJSON(fmt.Sprintf("/api/projects/%d", project.ID), update); err != nil {
+		if err := apiClient().UpdateProject(ctx, project.ID, update); err != nil {
 			writeStdErrAndExit(err.Error())
 		}
 		fmt.Println("User removed from the project.")
@@ -347,23 +361,22 @@ func deleteProject(arg string) {
 	} else {
 		p.ID = int64(i)
 	}
+	c := apiClient()
 	jobs, _ := getJobs(p.ID)
 	for _, job := range jobs {
-		jobPath := fmt.Sprintf("/api/projects/%d/jobs/%d", p.ID, job.ID)
-		if err := deleteHTTP(jobPath); err != nil {
+		if err := c.DeleteJob(ctx, p.ID, job.ID); err != nil {
 			continue
 		}
-		var attack hashstack.Attack
-		if err := getJSON(fmt.Sprintf("/api/attacks/%d", job.AttackID), &attack); err != nil {
+		attack, err := c.Attack(ctx, job.AttackID)
+		if err != nil {
 			continue
 		}
 		if attack.Title == fmt.Sprintf("hashstack-cli-%d-%d-%s", job.ProjectID, job.ListID, job.Name) {
-			deleteHTTP(fmt.Sprintf("/api/attacks/%d", job.AttackID))
+			c.DeleteAttack(ctx, job.AttackID)
 		}
 	}
 
-	path := fmt.Sprintf("/api/projects/%d", p.ID)
-	if err := deleteHTTP(path); err != nil {
+	if err := c.DeleteProject(ctx, p.ID); err != nil {
 		writeStdErrAndExit(err.Error())
 	}
 	fmt.Println("The project was deleted successfully.")
@@ -387,11 +400,6 @@ Delete a project by name or id. Deleting a project will delete any associated li
 	},
 }
 
-type projectRequest struct {
-	Name        string `json:"name"`
-	Description string `json:"description"`
-}
-
 var addProjectCmd = &cobra.Command{
 	Use:   "add <project_name> <description>",
 	Short: "Add a new project.",
@@ -404,19 +412,14 @@ The name should not include special characters or spaces. The description is req
 		if len(args) < 2 {
 			writeStdErrAndExit("project_name and description are required.")
 		}
-		req := projectRequest{
+		req := client.ProjectRequest{
 			Name:        args[0],
 			Description: args[1],
 		}
-		body, err := postJSON("/api/projects", req)
+		project, err := apiClient().CreateProject(ctx, req)
 		if err != nil {
 			writeStdErrAndExit(err.Error())
 		}
-		var project hashstack.Project
-		if err := json.Unmarshal(body, &project); err != nil {
-			debug(fmt.Sprintf("Error: %s", err.Error()))
-			writeStdErrAndExit(new(jsonServerError).Error())
-		}
 		displayProject(project)
 	},
 }
@@ -0,0 +1,242 @@
+package cmd
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/stricture/hashstack-cli/audit"
+	hashstack "github.com/stricture/hashstack-server-core-ng"
+)
+
+const (
+	reportMarkdown = "markdown"
+	reportHTML     = "html"
+	reportJSON     = "json"
+)
+
+var (
+	flReportFormat     string
+	flReportFile       string
+	flReportTop        int
+	flReportMinLength  int
+	flReportMinClasses int
+)
+
+// reportHistoryPath returns the file holding the crack ratio history for the
+// list group name, or for the whole project when name is empty.
+func reportHistoryPath(projectID int64, name string) string {
+	dir := filepath.Join(serverDataDir("reports"), fmt.Sprintf("%d", projectID))
+	if name == "" {
+		return filepath.Join(dir, "project.json")
+	}
+	return filepath.Join(dir, "lists", name+".json")
+}
+
+// updateReportHistory adds the current crack ratio to the history saved by
+// previous reports and returns the result. The history starts when the oldest
+// list was created.
+func updateReportHistory(filename string, lists []hashstack.List, now audit.Snapshot) []audit.Snapshot {
+	var history []audit.Snapshot
+	if data, err := ioutil.ReadFile(filename); err == nil {
+		if err := json.Unmarshal(data, &history); err != nil {
+			debug(fmt.Sprintf("Error: %s", err.Error()))
+		}
+	}
+	if len(history) == 0 {
+		created := now.Time
+		for _, l := range lists {
+			if l.CreatedAt > 0 && l.CreatedAt < created {
+				created = l.CreatedAt
+			}
+		}
+		history = append(history, audit.NewSnapshot(created, now.Digests, 0))
+	}
+	if last := history[len(history)-1]; last.Cracked != now.Cracked || last.Digests != now.Digests {
+		history = append(history, now)
+	}
+	data, err := json.Marshal(history)
+	if err == nil {
+		if err = os.MkdirAll(filepath.Dir(filename), 0700); err == nil {
+			err = ioutil.WriteFile(filename, data, 0600)
+		}
+	}
+	if err != nil {
+		debug(fmt.Sprintf("Error: %s", err.Error()))
+		fmt.Fprintf(os.Stderr, "There was an error saving the report history to %s.\n", filename)
+	}
+	return history
+}
+
+// readPlains calls fn with the hash and decoded plain of every cracked hash in list.
+func readPlains(projectID int64, list hashstack.List, hashMode hashstack.HashMode, fn func(hash, plain string)) {
+	fields := plainFields(hashMode)
+	body, err := apiClient().Plains(ctx, projectID, list.ID)
+	if err != nil {
+		writeStdErrAndExit(err.Error())
+	}
+	defer body.Close()
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		if hash, plain, ok := audit.SplitPlain(scanner.Text(), fields); ok {
+			fn(hash, plain)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		debug(fmt.Sprintf("Error: %s", err.Error()))
+		writeStdErrAndExit("There was an error downloading the cracked hashes.")
+	}
+}
+
+// analyzePlains adds the cracked passwords for list to a. Hashes shared by
+// several accounts, according to the usernames saved by lists add, are
+// counted once for each account.
+func analyzePlains(a *audit.Analyzer, project hashstack.Project, list hashstack.List, hashMode hashstack.HashMode) {
+	users, err := loadUsernameMap(project.ID, listGroupName([]hashstack.List{list}))
+	if err != nil && !os.IsNotExist(err) {
+		debug(fmt.Sprintf("Error: %s", err.Error()))
+	}
+	readPlains(project.ID, list, hashMode, func(hash, plain string) {
+		accounts := len(users[hash])
+		if accounts == 0 {
+			accounts = len(users[strings.ToLower(hash)])
+		}
+		a.Add(plain, accounts)
+	})
+}
+
+// buildReport returns a password audit for lists. name is the list group the
+// report is for, or empty for the whole project.
+func buildReport(project hashstack.Project, lists []hashstack.List, name string) audit.Report {
+	c := apiClient()
+	a := audit.NewAnalyzer(audit.Policy{MinLength: flReportMinLength, MinClasses: flReportMinClasses}, flReportTop)
+	hashModes := make(map[int]hashstack.HashMode)
+	var digests, cracked int64
+	for _, l := range lists {
+		hashMode, ok := hashModes[l.HashMode]
+		if !ok {
+			m, err := c.HashMode(ctx, l.HashMode)
+			if err != nil {
+				writeStdErrAndExit(err.Error())
+			}
+			hashMode, hashModes[l.HashMode] = m, m
+		}
+		analyzePlains(a, project, l, hashMode)
+		digests += l.DigestCount
+		cracked += l.RecoveredCount
+	}
+	now := audit.NewSnapshot(time.Now().Unix(), digests, cracked)
+	report := a.Report()
+	report.Title = fmt.Sprintf("Password Audit: %s", project.Name)
+	if name != "" {
+		report.Title = fmt.Sprintf("%s / %s", report.Title, name)
+	}
+	report.Generated = now.Time
+	report.Digests = digests
+	report.Cracked = cracked
+	report.CrackedPercent = now.CrackedPercent
+	report.History = updateReportHistory(reportHistoryPath(project.ID, name), lists, now)
+	return report
+}
+
+func writeReport(w io.Writer, report audit.Report) error {
+	switch flReportFormat {
+	case reportHTML:
+		return report.HTML(w)
+	case reportJSON:
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, string(data))
+		return err
+	default:
+		return report.Markdown(w)
+	}
+}
+
+var reportCmd = &cobra.Command{
+	Use:   "report <project_name|project_id> [list_name|list_id]",
+	Short: "Generate a password audit from the cracked hashes of a project or list.",
+	Long: `
+Generate a password audit from the cracked hashes of every list in a project, or of a single list or group of
+split lists. The report includes the crack ratio over time, the length distribution, character classes, top
+base words, top masks, reused passwords, and how many passwords fail the policy set by --min-length and
+--min-classes.
+
+The crack ratio is recorded each time a report is generated, so the history grows as reports are run on
+this machine. Hashes shared by several accounts are counted once for each account when the list was added
+with usernames, see 'hashstack lists add --help'.
+
+The report is written as Markdown by default. Use --format html or --format json for other formats and
+--file to write it to a file.
+`,
+	PreRun: ensureAuth,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) < 1 {
+			writeStdErrAndExit("project_name|project_id is required.")
+		}
+		switch flReportFormat {
+		case reportMarkdown, reportHTML, reportJSON:
+		default:
+			writeStdErrAndExit(fmt.Sprintf("Unsupported report format %s. Use markdown, html, or json.", flReportFormat))
+		}
+		project := getProject(args[0])
+		var (
+			lists []hashstack.List
+			name  string
+		)
+		if len(args) > 1 {
+			lists = getListGroup(project.ID, args[1])
+			name = listGroupName(lists)
+		} else {
+			var err error
+			lists, err = apiClient().Lists(ctx, project.ID)
+			if err != nil {
+				writeStdErrAndExit(err.Error())
+			}
+			if len(lists) < 1 {
+				writeStdErrAndExit("The project does not have any lists.")
+			}
+		}
+		report := buildReport(project, lists, name)
+		if isStructuredOutput() {
+			renderOutput(report)
+			return
+		}
+		var w io.Writer = os.Stdout
+		if flReportFile != "" {
+			fh, err := os.OpenFile(flReportFile, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+			if err != nil {
+				debug(fmt.Sprintf("Error: %s", err.Error()))
+				writeStdErrAndExit("There was an error creating the report file.")
+			}
+			defer fh.Close()
+			w = fh
+		}
+		if err := writeReport(w, report); err != nil {
+			debug(fmt.Sprintf("Error: %s", err.Error()))
+			writeStdErrAndExit("There was an error writing the report.")
+		}
+		if flReportFile != "" {
+			fmt.Printf("The report was written to %s.\n", flReportFile)
+		}
+	},
+}
+
+func init() {
+	reportCmd.PersistentFlags().StringVar(&flReportFormat, "format", reportMarkdown, "Report format: markdown, html, or json")
+	reportCmd.PersistentFlags().StringVar(&flReportFile, "file", "", "Write the report to this file instead of stdout")
+	reportCmd.PersistentFlags().IntVar(&flReportTop, "top", 10, "Number of base words, masks, and reused passwords to include, 0 for all")
+	reportCmd.PersistentFlags().IntVar(&flReportMinLength, "min-length", 8, "Minimum password length required by the policy")
+	reportCmd.PersistentFlags().IntVar(&flReportMinClasses, "min-classes", 3, "Minimum number of character classes (lower, upper, digit, special) required by the policy")
+	RootCmd.AddCommand(reportCmd)
+}
@@ -1,13 +1,12 @@
 package cmd
 
 import (
-	"crypto/tls"
 	"fmt"
 	"math/big"
-	"net/http"
 	"os"
 	"os/user"
 	"path/filepath"
+	"strings"
 
 	"github.com/BurntSushi/toml"
 	"github.com/spf13/cobra"
@@ -15,19 +14,35 @@ import (
 
 // Global command line flags.
 var (
-	flCfgFile   string
-	flInsecure  bool
-	flDebug     bool
-	flServerURL string
-	flToken     string
+	flCfgFile    string
+	flInsecure   bool
+	flDebug      bool
+	flServerURL  string
+	flToken      string
+	flProfile    string
+	flCACert     string
+	flClientCert string
+	flClientKey  string
+	flPins       []string
 )
 
+// defaultProfile is the profile used when none is selected.
+const defaultProfile = "default"
+
+// percentOf returns current as a percentage of all, or 0 when all is 0 so that
+// the result can always be encoded as JSON.
 func percentOf(current int, all int) float64 {
+	if all == 0 {
+		return 0
+	}
 	percent := (float64(current) * float64(100)) / float64(all)
 	return percent
 }
 
 func bigPercentOf(current *big.Int, all *big.Int) float64 {
+	if all.Sign() == 0 {
+		return 0
+	}
 	current.Mul(current, big.NewInt(100))
 	current.Div(current, all)
 	return float64(current.Int64())
@@ -74,25 +89,79 @@ func prettyUptime(uptime int64) string {
 }
 
 type config struct {
+	// ServerURL, Token, and Insecure are only read from files written before
+	// profiles were added. They are moved to the default profile.
+	ServerURL string `toml:"server_url,omitempty"`
+	Token     string `toml:"token,omitempty"`
+	Insecure  bool   `toml:"insecure,omitempty"`
+	// TokenStore is where login saves session tokens: keyring, file, or
+	// plaintext. The keyring is used when it is empty, or the encrypted file
+	// when there is no keyring.
+	TokenStore string `toml:"token_store,omitempty"`
+	// CurrentProfile is the profile selected by hashstack context use.
+	CurrentProfile string              `toml:"current_profile,omitempty"`
+	Profiles       map[string]*profile `toml:"profiles,omitempty"`
+	// Watch is the [watch] section used by hashstack watch.
+	Watch *watchConfig `toml:"watch,omitempty"`
+}
+
+// profile is a server and the credentials used for it.
+type profile struct {
 	ServerURL string `toml:"server_url"`
-	Token     string `toml:"token"`
-	Insecure  bool   `toml:"insecure"`
+	// Token is only set when token_store is plaintext, or by older versions.
+	Token string `toml:"token,omitempty"`
+	// RefreshToken is stored with Token, and only by servers that return one.
+	RefreshToken string `toml:"refresh_token,omitempty"`
+	// TokenRef is the store that holds the token, keyring or file.
+	TokenRef string `toml:"token_ref,omitempty"`
+	// Username is the user that logged in, used to log in again.
+	Username string `toml:"username,omitempty"`
+	Insecure bool   `toml:"insecure"`
+	// CACert, ClientCert, and ClientKey are absolute paths to PEM files.
+	CACert     string `toml:"ca_cert,omitempty"`
+	ClientCert string `toml:"client_cert,omitempty"`
+	ClientKey  string `toml:"client_key,omitempty"`
+	// Pins are the SHA-256 hashes of the server's public key that are trusted.
+	Pins []string `toml:"pins,omitempty"`
 }
 
+// cfg is the configuration file as it was loaded, so that writecfg can keep
+// the sections and profiles it does not change.
+var cfg config
+
+// activeProfile is the name of the profile used by this command, chosen by
+// --profile, then HASHSTACK_PROFILE, then hashstack context use.
+var activeProfile string
+
 func debug(msg string) {
 	if flDebug {
+		if flToken != "" {
+			msg = strings.Replace(msg, flToken, redactToken(flToken), -1)
+		}
 		fmt.Printf("DEBUG: %s\n", msg)
 	}
 }
 
+// redactToken returns a form of token that is safe to print.
+func redactToken(token string) string {
+	if token == "" {
+		return "(none)"
+	}
+	return fmt.Sprintf("(redacted, %d characters)", len(token))
+}
+
 var authedSubCommands = []string{
 	"project",
 }
 
 func ensureAuth(cmd *cobra.Command, args []string) {
+	if flToken == "" {
+		loadToken()
+	}
 	if flServerURL == "" || flToken == "" {
 		writeStdErrAndExit("Use hashstack login before continuing.")
 	}
+	checkExpiry()
 }
 
 // initcfg will load the configurationfile in the user's home directory.
@@ -105,44 +174,157 @@ func initcfg() {
 		flCfgFile = filepath.Join(usr.HomeDir, ".hashstack", "config")
 	}
 	debug(fmt.Sprintf("configuration file: %s", flCfgFile))
-	var cfg config
 	if _, err := toml.DecodeFile(flCfgFile, &cfg); err != nil {
 		debug("CONFIG: Could not decode configuration file")
+	}
+	// Files written by older versions were readable by every user.
+	if fi, err := os.Stat(flCfgFile); err == nil && fi.Mode().Perm()&0077 != 0 {
+		debug("CONFIG: restricting the configuration file to the current user")
+		os.Chmod(flCfgFile, 0600)
+	}
+	if cfg.Profiles == nil {
+		cfg.Profiles = make(map[string]*profile)
+	}
+	if cfg.ServerURL != "" {
+		if _, ok := cfg.Profiles[defaultProfile]; !ok {
+			debug("CONFIG: moving server_url and token to the default profile")
+			cfg.Profiles[defaultProfile] = &profile{ServerURL: cfg.ServerURL, Token: cfg.Token, Insecure: cfg.Insecure}
+		}
+		if cfg.CurrentProfile == "" {
+			cfg.CurrentProfile = defaultProfile
+		}
+		cfg.ServerURL, cfg.Token, cfg.Insecure = "", "", false
+	}
+	switch {
+	case flProfile != "":
+		activeProfile = flProfile
+	case os.Getenv("HASHSTACK_PROFILE") != "":
+		activeProfile = os.Getenv("HASHSTACK_PROFILE")
+	case cfg.CurrentProfile != "":
+		activeProfile = cfg.CurrentProfile
+	default:
+		activeProfile = defaultProfile
+	}
+	debug(fmt.Sprintf("CONFIG: PROFILE - %s", activeProfile))
+	p, ok := cfg.Profiles[activeProfile]
+	if !ok {
+		envOverrides()
 		return
 	}
-	flServerURL = cfg.ServerURL
-	flToken = cfg.Token
-	if cfg.Insecure {
+	flServerURL = p.ServerURL
+	flToken = p.Token
+	refreshToken = p.RefreshToken
+	if p.Insecure {
 		flInsecure = true
 	}
+	if flCACert == "" {
+		flCACert = p.CACert
+	}
+	if flClientCert == "" && flClientKey == "" {
+		flClientCert, flClientKey = p.ClientCert, p.ClientKey
+	}
+	if len(flPins) == 0 {
+		flPins = p.Pins
+	}
+	envOverrides()
+	if p.Token != "" && cfg.TokenStore != tokenStorePlaintext && !tokenFromEnv {
+		fmt.Fprintf(os.Stderr, "Warning: the session token for the profile %s is stored in plain text in %s. Use 'hashstack context migrate' to move it to the keyring.\n", activeProfile, flCfgFile)
+	}
 	debug(fmt.Sprintf("CONFIG: INSECURE - %v", flInsecure))
+	debug(fmt.Sprintf("CONFIG: CA_CERT - %s", flCACert))
+	debug(fmt.Sprintf("CONFIG: CLIENT_CERT - %s", flClientCert))
+	debug(fmt.Sprintf("CONFIG: PINS - %s", strings.Join(flPins, ", ")))
 	debug(fmt.Sprintf("CONFIG: SERVER_URL - %s", flServerURL))
-	debug(fmt.Sprintf("CONFIG: TOKEN - %s", flToken))
+	debug(fmt.Sprintf("CONFIG: TOKEN - %s", redactToken(flToken)))
+	debug(fmt.Sprintf("CONFIG: TOKEN_REF - %s", p.TokenRef))
 }
 
-// writecfg will save the required data to the user's configuration file.
+// tokenFromEnv is set when the token was read from HASHSTACK_TOKEN. It is
+// never saved to the configuration file.
+var tokenFromEnv bool
+
+// serverFromEnv is set when the server was read from HASHSTACK_SERVER_URL.
+var serverFromEnv bool
+
+// envOverrides replaces the server and token of the profile with
+// HASHSTACK_SERVER_URL and HASHSTACK_TOKEN, so that scripts can run without a
+// configuration file.
+func envOverrides() {
+	serverFromEnv, tokenFromEnv = false, false
+	if serverURL := os.Getenv("HASHSTACK_SERVER_URL"); serverURL != "" {
+		flServerURL = strings.TrimRight(serverURL, "/")
+		serverFromEnv = true
+	}
+	if token := os.Getenv("HASHSTACK_TOKEN"); token != "" {
+		flToken, refreshToken = token, ""
+		tokenFromEnv = true
+	}
+}
+
+// envOverridden reports whether HASHSTACK_SERVER_URL or HASHSTACK_TOKEN is set.
+func envOverridden() bool {
+	return serverFromEnv || tokenFromEnv
+}
+
+// writecfg will save the server and token to the active profile in the
+// user's configuration file. Other profiles are left unchanged. Nothing is
+// saved while the environment overrides the server or token, so that they do
+// not replace the profile.
 func writecfg() {
-	tomlString := fmt.Sprintf("server_url = \"%s\"\ntoken = \"%s\"\ninsecure = %v\n", flServerURL, flToken, flInsecure)
+	if envOverridden() {
+		debug("CONFIG: not saving the profile, HASHSTACK_SERVER_URL or HASHSTACK_TOKEN is set")
+		return
+	}
+	p, ok := cfg.Profiles[activeProfile]
+	if !ok {
+		p = &profile{}
+		cfg.Profiles[activeProfile] = p
+	}
+	p.ServerURL = flServerURL
+	saveToken(activeProfile, p, flToken)
+	saveRefreshToken(activeProfile, p, refreshToken)
+	p.Insecure = flInsecure
+	p.CACert, p.ClientCert, p.ClientKey = absPath(flCACert), absPath(flClientCert), absPath(flClientKey)
+	p.Pins = flPins
+	if cfg.CurrentProfile == "" {
+		cfg.CurrentProfile = activeProfile
+	}
+	savecfg()
+}
+
+// savecfg writes cfg to the user's configuration file.
+func savecfg() {
 	os.Remove(flCfgFile)
 	os.Mkdir(filepath.Dir(flCfgFile), 0755)
-	fh, err := os.OpenFile(flCfgFile, os.O_CREATE|os.O_WRONLY, 0655)
+	// The file may hold notifier passwords as well as the token.
+	fh, err := os.OpenFile(flCfgFile, os.O_CREATE|os.O_WRONLY, 0600)
 	if err != nil {
 		debug(fmt.Sprintf("Error: %s", err.Error()))
 		writeStdErrAndExit("There was an error opening the configuration file.")
 	}
 	defer fh.Close()
-	if _, err := fh.WriteString(tomlString); err != nil {
+	if err := toml.NewEncoder(fh).Encode(cfg); err != nil {
 		debug(fmt.Sprintf("Error: %s", err.Error()))
 		writeStdErrAndExit("There was an error writing to the configuration file.")
 	}
 }
 
+// absPath returns path as an absolute path, so that it can be read from any
+// directory. An empty path is returned unchanged.
+func absPath(path string) string {
+	if path == "" {
+		return ""
+	}
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return path
+}
+
 func initenv() {
+	validateOutput()
 	if flInsecure {
 		debug("SECURITY: All requests are set to insecure")
-		http.DefaultTransport = &http.Transport{
-			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
-		}
 	}
 }
 
@@ -164,5 +346,11 @@ func init() {
 	cobra.OnInitialize(initcfg, initenv)
 	RootCmd.PersistentFlags().StringVar(&flCfgFile, "config", "", "config file (default: $HOME/.hashstack/config)")
 	RootCmd.PersistentFlags().BoolVar(&flInsecure, "insecure", false, "skip TLS certificate validation")
+	RootCmd.PersistentFlags().StringVar(&flCACert, "ca-cert", "", "PEM file of certificate authorities to trust for the server")
+	RootCmd.PersistentFlags().StringVar(&flClientCert, "client-cert", "", "PEM file of the client certificate sent to the server")
+	RootCmd.PersistentFlags().StringVar(&flClientKey, "client-key", "", "PEM file of the key for --client-cert")
+	RootCmd.PersistentFlags().StringSliceVar(&flPins, "pin", nil, "SHA-256 hash of the server's public key to trust, as sha256//<base64>")
 	RootCmd.PersistentFlags().BoolVar(&flDebug, "debug", false, "enable debug output")
+	RootCmd.PersistentFlags().StringVar(&flProfile, "profile", "", "server profile to use (default: $HASHSTACK_PROFILE or the current context)")
+	RootCmd.PersistentFlags().StringVarP(&flOutput, "output", "o", outputText, "output format: text, json, yaml, or csv")
 }
@@ -1,11 +1,16 @@
 package cmd
 
 import (
+	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"math/big"
+	"os"
+	"path/filepath"
 	"testing"
 
 	. "github.com/smartystreets/goconvey/convey"
+	"github.com/spf13/cobra"
 )
 
 func TestBigPercentOf(t *testing.T) {
@@ -17,4 +22,135 @@ func TestBigPercentOf(t *testing.T) {
 		So(z, ShouldEqual, 5)
 		So(perc, ShouldEqual, "5.00%")
 	})
+
+	Convey("Given a zero denominator", t, func() {
+		So(percentOf(0, 0), ShouldEqual, 0)
+		So(bigPercentOf(big.NewInt(0), big.NewInt(0)), ShouldEqual, 0)
+		_, err := json.Marshal(map[string]float64{"percent": percentOf(1, 0)})
+		So(err, ShouldBeNil)
+	})
+}
+
+func TestInitcfgProfiles(t *testing.T) {
+	Convey("Given a configuration file written before profiles", t, func() {
+		dir, _ := ioutil.TempDir("", "hashstack-config-")
+		defer os.RemoveAll(dir)
+		flCfgFile = filepath.Join(dir, "config")
+		ioutil.WriteFile(flCfgFile, []byte("token_store = \"file\"\nserver_url = \"https://old\"\ntoken = \"t1\"\ninsecure = false\n"), 0600)
+		cfg, flProfile, flServerURL, flToken, tokenFile = config{}, "", "", "", nil
+		os.Unsetenv("HASHSTACK_PROFILE")
+		os.Setenv("HASHSTACK_PASSPHRASE", "correct horse")
+		defer os.Unsetenv("HASHSTACK_PASSPHRASE")
+		initcfg()
+
+		Convey("The server is moved to the default profile", func() {
+			So(activeProfile, ShouldEqual, defaultProfile)
+			So(flServerURL, ShouldEqual, "https://old")
+			So(flToken, ShouldEqual, "t1")
+		})
+
+		Convey("Logging in to another profile keeps the default profile", func() {
+			activeProfile = "lab"
+			flServerURL, flToken = "https://lab", "t2"
+			writecfg()
+			cfg, flServerURL, flToken, tokenFile = config{}, "", "", nil
+			os.Setenv("HASHSTACK_PROFILE", "lab")
+			defer os.Unsetenv("HASHSTACK_PROFILE")
+			initcfg()
+			loadToken()
+			So(activeProfile, ShouldEqual, "lab")
+			So(flServerURL, ShouldEqual, "https://lab")
+			So(flToken, ShouldEqual, "t2")
+			So(cfg.Profiles["lab"].Token, ShouldBeEmpty)
+			So(cfg.Profiles["lab"].TokenRef, ShouldEqual, tokenStoreFile)
+			So(cfg.Profiles[defaultProfile].Token, ShouldEqual, "t1")
+			So(cfg.CurrentProfile, ShouldEqual, defaultProfile)
+			So(cfg.ServerURL, ShouldBeEmpty)
+			data, _ := ioutil.ReadFile(flCfgFile)
+			So(string(data), ShouldNotContainSubstring, "t2")
+		})
+
+		Convey("The environment overrides the server and token", func() {
+			os.Setenv("HASHSTACK_SERVER_URL", "https://ci/")
+			os.Setenv("HASHSTACK_TOKEN", "t3")
+			defer os.Unsetenv("HASHSTACK_SERVER_URL")
+			defer os.Unsetenv("HASHSTACK_TOKEN")
+			cfg = config{}
+			initcfg()
+			So(flServerURL, ShouldEqual, "https://ci")
+			So(flToken, ShouldEqual, "t3")
+			So(tokenFromEnv, ShouldBeTrue)
+
+			Convey("The overrides are not saved to the profile", func() {
+				writecfg()
+				os.Unsetenv("HASHSTACK_SERVER_URL")
+				os.Unsetenv("HASHSTACK_TOKEN")
+				cfg, flServerURL, flToken, tokenFile = config{}, "", "", nil
+				initcfg()
+				loadToken()
+				So(flServerURL, ShouldEqual, "https://old")
+				So(flToken, ShouldEqual, "t1")
+			})
+		})
+
+		Convey("Debug output does not contain the token", func() {
+			So(redactToken("t1"), ShouldNotContainSubstring, "t1")
+		})
+	})
+}
+
+func TestForgetServerTrust(t *testing.T) {
+	Convey("Given a profile with TLS settings for a server", t, func() {
+		cfg = config{Profiles: map[string]*profile{
+			defaultProfile: {ServerURL: "https://old", Pins: []string{"sha256//old"}, CACert: "/old.pem", Insecure: true, ClientCert: "/client.pem", ClientKey: "/client.key"},
+		}}
+		activeProfile = defaultProfile
+		cmd := &cobra.Command{}
+		cmd.Flags().StringSliceVar(&flPins, "pin", nil, "")
+		cmd.Flags().StringVar(&flCACert, "ca-cert", "", "")
+		cmd.Flags().BoolVar(&flInsecure, "insecure", false, "")
+		cmd.Flags().StringVar(&flClientCert, "client-cert", "", "")
+		cmd.Flags().StringVar(&flClientKey, "client-key", "", "")
+		flPins, flCACert, flInsecure = []string{"sha256//old"}, "/old.pem", true
+		flClientCert, flClientKey = "/client.pem", "/client.key"
+		defer func() {
+			flPins, flCACert, flInsecure = nil, "", false
+			flClientCert, flClientKey = "", ""
+		}()
+
+		Convey("Logging in to the same server keeps them", func() {
+			forgetServerTrust(cmd, "https://old")
+			So(flPins, ShouldResemble, []string{"sha256//old"})
+			So(flCACert, ShouldEqual, "/old.pem")
+			So(flInsecure, ShouldBeTrue)
+			So(flClientCert, ShouldEqual, "/client.pem")
+		})
+
+		Convey("Logging in to another server clears them", func() {
+			forgetServerTrust(cmd, "https://new")
+			So(flPins, ShouldBeEmpty)
+			So(flCACert, ShouldBeEmpty)
+			So(flInsecure, ShouldBeFalse)
+			So(flClientCert, ShouldBeEmpty)
+			So(flClientKey, ShouldBeEmpty)
+		})
+
+		Convey("Logging in to another server keeps a pin given as a flag", func() {
+			cmd.Flags().Set("pin", "sha256//new")
+			forgetServerTrust(cmd, "https://new")
+			So(flPins, ShouldResemble, []string{"sha256//new"})
+			So(flCACert, ShouldBeEmpty)
+		})
+
+		Convey("Logging in to another server keeps --insecure and a client certificate given as flags", func() {
+			cmd.Flags().Set("insecure", "true")
+			cmd.Flags().Set("client-cert", "/new.pem")
+			cmd.Flags().Set("client-key", "/new.key")
+			forgetServerTrust(cmd, "https://new")
+			So(flInsecure, ShouldBeTrue)
+			So(flClientCert, ShouldEqual, "/new.pem")
+			So(flClientKey, ShouldEqual, "/new.key")
+			So(flPins, ShouldBeEmpty)
+		})
+	})
 }
@@ -7,20 +7,26 @@ import (
 
 	humanize "github.com/dustin/go-humanize"
 	"github.com/spf13/cobra"
+	"github.com/stricture/hashstack-cli/client"
 	hashstack "github.com/stricture/hashstack-server-core-ng"
 )
 
 func getRule(f *hashstack.File) {
-	path := fmt.Sprintf("/api/rules?filename=%s", f.Filename)
-	if err := getJSON(path, &f); err != nil {
+	file, err := apiClient().Rule(ctx, f.Filename)
+	if err != nil {
 		writeStdErrAndExit(err.Error())
 	}
+	*f = file
 }
 
 func displayRule(f hashstack.File) {
 	if f.ID == 0 {
 		getRule(&f)
 	}
+	if isStructuredOutput() {
+		renderOutput(f)
+		return
+	}
 	fmt.Printf("Name.............: %s\n", f.Filename)
 	fmt.Printf("Rules............: %d\n", f.Lines)
 	fmt.Printf("Size.............: %s\n", humanize.Bytes(uint64(f.Size)))
@@ -29,13 +35,17 @@ func displayRule(f hashstack.File) {
 }
 
 func displayRules() {
-	var rules []hashstack.File
-	if err := getRangeJSON("/api/rules", &rules); err != nil {
+	rules, err := apiClient().Files(ctx, client.RuleFile)
+	if err != nil {
 		writeStdErrAndExit(err.Error())
 	}
 	sort.Slice(rules, func(i, j int) bool {
 		return rules[i].Filename < rules[j].Filename
 	})
+	if isStructuredOutput() {
+		renderOutput(rules)
+		return
+	}
 	for _, w := range rules {
 		displayRule(w)
 	}
@@ -61,16 +71,23 @@ Rule files can be used in jobs. Additional subcommands are available to add and
 }
 
 var addRuleCmd = &cobra.Command{
-	Use:    "add <file>",
-	Short:  "Upload the provided file to the server.",
-	Long:   "Upload the provided file to the server.",
+	Use:   "add <file>",
+	Short: "Upload the provided file to the server.",
+	Long: `
+Upload the provided file to the server. Files are sent in chunks and the checksum of the
+server copy is verified once the upload finishes. If the upload is interrupted, run the same
+command again to resume it.
+
+Files ending in .gz, .zst, or .bz2 are decompressed before they are sent. Uploads are compressed
+with zstd or gzip when the server supports it. Use --compress to choose the compression.
+`,
 	PreRun: ensureAuth,
 	Run: func(cmd *cobra.Command, args []string) {
 		if len(args) < 1 {
 			writeStdErrAndExit("file is required.")
 		}
 		file := args[0]
-		uploadFile("/api/rules", file)
+		uploadFile(client.RuleFile, file)
 	},
 }
 
@@ -79,8 +96,7 @@ func deleteRule(filename string) {
 		Filename: filename,
 	}
 	getRule(&f)
-	path := fmt.Sprintf("/api/rules/%d", f.ID)
-	if err := deleteHTTP(path); err != nil {
+	if err := apiClient().DeleteFile(ctx, client.RuleFile, f.ID); err != nil {
 		writeStdErrAndExit(err.Error())
 	}
 	fmt.Println("The rule was deleted successfully.")
@@ -103,6 +119,7 @@ var delRuleCmd = &cobra.Command{
 }
 
 func init() {
+	addRuleCmd.PersistentFlags().StringVar(&flCompress, "compress", compressAuto, "upload compression: auto, none, gzip, or zstd")
 	ruleCmd.AddCommand(addRuleCmd)
 	ruleCmd.AddCommand(delRuleCmd)
 	RootCmd.AddCommand(ruleCmd)
@@ -0,0 +1,127 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/howeyc/gopass"
+	"github.com/stricture/hashstack-cli/client"
+)
+
+// tokenExpiryWarning is how long before the token expires a warning is shown.
+const tokenExpiryWarning = time.Hour
+
+// refreshToken is loaded with the token of the active profile.
+var refreshToken string
+
+// reauthMu stops clients used at the same time from each asking for the password.
+var reauthMu sync.Mutex
+
+// noPasswordPrompt is set while a full-screen dashboard reads the terminal, so
+// that a rejected token is only renewed with the refresh token.
+var noPasswordPrompt bool
+
+// flPasswordStdin is set by login and passwd to read passwords from stdin.
+var flPasswordStdin bool
+
+// stdinReader is shared so that each password is read from its own line.
+var stdinReader *bufio.Reader
+
+// readPassword reads a password from the next line of stdin with
+// --password-stdin, or asks for it with prompt.
+func readPassword(prompt string) ([]byte, error) {
+	if !flPasswordStdin {
+		fmt.Print(prompt)
+		return gopass.GetPasswdMasked()
+	}
+	if stdinReader == nil {
+		stdinReader = bufio.NewReader(os.Stdin)
+	}
+	line, err := stdinReader.ReadString('\n')
+	if err != nil && line == "" {
+		return nil, err
+	}
+	return []byte(strings.TrimRight(line, "\r\n")), nil
+}
+
+// isTerminal reports whether f is a terminal.
+func isTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+// reauthenticate returns a new token for the active profile after stale was
+// rejected. The refresh token is used when the server returned one, otherwise
+// the password is asked for unless noPasswordPrompt is set. The new session is
+// saved to the profile.
+func reauthenticate(stale string) (string, error) {
+	reauthMu.Lock()
+	defer reauthMu.Unlock()
+	if flToken != stale && flToken != "" {
+		return flToken, nil
+	}
+	if tokenFromEnv {
+		return "", fmt.Errorf("the token in HASHSTACK_TOKEN was rejected")
+	}
+	c := newClient(flServerURL, "")
+	if refreshToken != "" {
+		session, err := c.Refresh(ctx, refreshToken)
+		if err == nil {
+			debug("AUTH: the session was renewed with the refresh token")
+			saveSession(session)
+			return session.Token, nil
+		}
+		debug(fmt.Sprintf("Error: %s", err.Error()))
+	}
+	if noPasswordPrompt {
+		return "", fmt.Errorf("the session can not be renewed with a password while the dashboard is open")
+	}
+	p, ok := cfg.Profiles[activeProfile]
+	if !ok || p.Username == "" || !isTerminal(os.Stdin) {
+		return "", fmt.Errorf("the session can not be renewed without a terminal and a username")
+	}
+	fmt.Fprintf(os.Stderr, "Your session has expired. Password for %s at %s: ", p.Username, flServerURL)
+	pass, err := gopass.GetPasswdMasked()
+	if err != nil {
+		return "", err
+	}
+	session, err := c.LoginSession(ctx, p.Username, string(pass))
+	if err != nil {
+		return "", err
+	}
+	saveSession(session)
+	return session.Token, nil
+}
+
+// saveSession saves the tokens of session to the active profile.
+func saveSession(session client.Session) {
+	flToken = session.Token
+	if session.RefreshToken != "" {
+		refreshToken = session.RefreshToken
+	}
+	writecfg()
+}
+
+// checkExpiry warns when the token is a JWT that is about to expire, and logs
+// in again when it has expired so that the command is not stopped part way.
+func checkExpiry() {
+	exp, ok := client.TokenExpiry(flToken)
+	if !ok {
+		return
+	}
+	left := time.Until(exp)
+	debug(fmt.Sprintf("AUTH: the token expires at %s", exp.Format(time.RFC3339)))
+	switch {
+	case left <= 0:
+		if _, err := reauthenticate(flToken); err != nil {
+			debug(fmt.Sprintf("Error: %s", err.Error()))
+			writeStdErrAndExit("Your session has expired. Use hashstack login before continuing.")
+		}
+	case left < tokenExpiryWarning:
+		fmt.Fprintf(os.Stderr, "Warning: the session token for the profile %s expires in %s. Use hashstack login to renew it.\n", activeProfile, left.Round(time.Minute))
+	}
+}
@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+	hashstack "github.com/stricture/hashstack-server-core-ng"
+)
+
+func TestReauthenticate(t *testing.T) {
+	Convey("Given a server that renews sessions with a refresh token", t, func() {
+		mux := http.NewServeMux()
+		mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+			var req map[string]string
+			json.NewDecoder(r.Body).Decode(&req)
+			if req["refresh_token"] != "r1" {
+				w.WriteHeader(401)
+				return
+			}
+			w.WriteHeader(201)
+			json.NewEncoder(w).Encode(map[string]string{"token": "t2", "refresh_token": "r2"})
+		})
+		mux.HandleFunc("/api/users/self", func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") != "bearer t2" {
+				w.WriteHeader(401)
+				return
+			}
+			json.NewEncoder(w).Encode(hashstack.User{ID: 1, Username: "alice"})
+		})
+		ts := httptest.NewServer(mux)
+		defer ts.Close()
+
+		dir, _ := ioutil.TempDir("", "hashstack-config-")
+		defer os.RemoveAll(dir)
+		flCfgFile = filepath.Join(dir, "config")
+		cfg = config{TokenStore: tokenStorePlaintext, Profiles: map[string]*profile{}}
+		activeProfile, httpClient = defaultProfile, nil
+		flServerURL, flToken, refreshToken = ts.URL, "t1", "r1"
+
+		Convey("A rejected token is renewed and the request succeeds", func() {
+			user, err := apiClient().Self(ctx)
+			So(err, ShouldBeNil)
+			So(user.Username, ShouldEqual, "alice")
+			So(flToken, ShouldEqual, "t2")
+			So(cfg.Profiles[defaultProfile].Token, ShouldEqual, "t2")
+			So(cfg.Profiles[defaultProfile].RefreshToken, ShouldEqual, "r2")
+		})
+
+		Convey("A dashboard never asks for the password when the refresh token is rejected", func() {
+			refreshToken = "expired"
+			noPasswordPrompt = true
+			defer func() { noPasswordPrompt = false }()
+			_, err := reauthenticate("t1")
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "dashboard")
+		})
+	})
+}
+
+func TestStoredTokenWithoutEnsureAuth(t *testing.T) {
+	Convey("Given a token saved to the encrypted file", t, func() {
+		dir, _ := ioutil.TempDir("", "hashstack-config-")
+		defer os.RemoveAll(dir)
+		os.Setenv("HASHSTACK_PASSPHRASE", "correct horse")
+		defer os.Unsetenv("HASHSTACK_PASSPHRASE")
+		flCfgFile = filepath.Join(dir, "config")
+		cfg = config{TokenStore: tokenStoreFile, Profiles: map[string]*profile{}}
+		activeProfile, httpClient, tokenFile = defaultProfile, nil, nil
+		flServerURL, flToken, refreshToken = "https://hashstack", "t1", ""
+		writecfg()
+		flToken, tokenFile = "", nil
+
+		Convey("A command that does not use ensureAuth sends it", func() {
+			So(cfg.Profiles[defaultProfile].Token, ShouldBeEmpty)
+			So(apiClient().Token, ShouldEqual, "t1")
+		})
+
+		Convey("Profiles with the same name in another configuration file use another key", func() {
+			key := tokenKey(defaultProfile)
+			flCfgFile = filepath.Join(dir, "other")
+			So(tokenKey(defaultProfile), ShouldNotEqual, key)
+		})
+	})
+}
+
+func TestPasswordStdinTokenFile(t *testing.T) {
+	Convey("Given --password-stdin without HASHSTACK_PASSPHRASE", t, func() {
+		defer func(stdin bool, c config) { flPasswordStdin, cfg = stdin, c }(flPasswordStdin, cfg)
+		os.Unsetenv("HASHSTACK_PASSPHRASE")
+		flPasswordStdin = true
+
+		Convey("The encrypted file is detected and its passphrase is not prompted for", func() {
+			cfg = config{TokenStore: tokenStoreFile}
+			So(usesTokenFile(), ShouldBeTrue)
+			_, err := tokenPassphrase(false)
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "HASHSTACK_PASSPHRASE")
+		})
+
+		Convey("A plaintext token store does not need a passphrase", func() {
+			cfg = config{TokenStore: tokenStorePlaintext}
+			So(usesTokenFile(), ShouldBeFalse)
+		})
+	})
+}
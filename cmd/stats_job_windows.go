@@ -10,6 +10,14 @@ import (
 )
 
 func statsJob(job hashstack.Job) {
+	if isStructuredOutput() {
+		displayJob(os.Stdout, job)
+		return
+	}
+	if useDashboard() {
+		runJobDashboard(job)
+		return
+	}
 	ch := make(chan os.Signal, 1)
 	signal.Notify(ch, os.Interrupt)
 	go func() {
@@ -14,11 +14,43 @@ var (
 	flStatusSimple bool
 )
 
+// statsView is the cluster statistics along with computed load and each agent.
+type statsView struct {
+	hashstack.ClusterStats
+	GPULoadPercent float64     `json:"gpu_load_percent"`
+	CPULoadPercent float64     `json:"cpu_load_percent"`
+	Agents         []agentView `json:"agents,omitempty"`
+}
+
 func displayStats() {
-	var stats hashstack.ClusterStats
-	if err := getJSON("/api/stats", &stats); err != nil {
+	stats, err := apiClient().Stats(ctx)
+	if err != nil {
 		writeStdErrAndExit(err.Error())
 	}
+	if isStructuredOutput() {
+		view := statsView{
+			ClusterStats:   stats,
+			GPULoadPercent: percentOf(int(stats.GPULoad), int(stats.GPULoadMax)),
+			CPULoadPercent: percentOf(int(stats.CPULoad), int(stats.CPULoadMax)),
+		}
+		if stats.GPULoadMax == 0 {
+			view.GPULoadPercent = 0
+		}
+		if stats.CPULoadMax == 0 {
+			view.CPULoadPercent = 0
+		}
+		if !flStatusSimple {
+			agents, err := apiClient().Agents(ctx)
+			if err != nil {
+				writeStdErrAndExit(err.Error())
+			}
+			for _, a := range agents {
+				view.Agents = append(view.Agents, newAgentView(a))
+			}
+		}
+		renderOutput(view)
+		return
+	}
 
 	var (
 		gpuload string
@@ -49,8 +81,8 @@ func displayStats() {
 		return
 	}
 
-	var agents []hashstack.Agent
-	if err := getRangeJSON("/api/agents", &agents); err != nil {
+	agents, err := apiClient().Agents(ctx)
+	if err != nil {
 		writeStdErrAndExit(err.Error())
 	}
 	sort.Slice(agents, func(i, j int) bool {
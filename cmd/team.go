@@ -1,13 +1,13 @@
 package cmd
 
 import (
-	"encoding/json"
 	"fmt"
 	"sort"
 	"strconv"
 	"strings"
 
 	"github.com/spf13/cobra"
+	"github.com/stricture/hashstack-cli/client"
 	hashstack "github.com/stricture/hashstack-server-core-ng"
 )
 
@@ -18,14 +18,32 @@ var (
 	flTeamOwnerUserID   int
 )
 
-func displayTeam(isMulti bool, team hashstack.Team) {
+// teamView is a team along with its owner's username.
+type teamView struct {
+	hashstack.Team
+	OwnerUsername string `json:"owner_username"`
+}
+
+func newTeamView(team hashstack.Team) teamView {
 	user := hashstack.User{
 		ID: team.OwnerUserID,
 	}
 	getUser(&user)
+	return teamView{
+		Team:          team,
+		OwnerUsername: user.Username,
+	}
+}
+
+func displayTeam(isMulti bool, team hashstack.Team) {
+	view := newTeamView(team)
+	if isStructuredOutput() {
+		renderOutput(view)
+		return
+	}
 	fmt.Printf("ID...............: %d\n", team.ID)
 	fmt.Printf("Name.............: %s\n", team.Name)
-	fmt.Printf("Owner............: %s\n", user.Username)
+	fmt.Printf("Owner............: %s\n", view.OwnerUsername)
 	if isMulti {
 		return
 	}
@@ -38,6 +56,14 @@ func displayTeam(isMulti bool, team hashstack.Team) {
 }
 
 func displayTeams(teams []hashstack.Team) {
+	if isStructuredOutput() {
+		views := make([]teamView, 0, len(teams))
+		for _, t := range teams {
+			views = append(views, newTeamView(t))
+		}
+		renderOutput(views)
+		return
+	}
 	for _, t := range teams {
 		displayTeam(true, t)
 		fmt.Println()
@@ -45,8 +71,8 @@ func displayTeams(teams []hashstack.Team) {
 }
 
 func getTeams() []hashstack.Team {
-	var teams []hashstack.Team
-	if err := getRangeJSON("/api/teams", &teams); err != nil {
+	teams, err := apiClient().Teams(ctx)
+	if err != nil {
 		writeStdErrAndExit(err.Error())
 	}
 	sort.Slice(teams, func(i, j int) bool {
@@ -56,13 +82,13 @@ func getTeams() []hashstack.Team {
 }
 
 func getTeam(team hashstack.Team) hashstack.Team {
-	var path string
+	var err error
 	if team.Name == "" && team.ID != 0 {
-		path = fmt.Sprintf("/api/teams/%d", team.ID)
+		team, err = apiClient().Team(ctx, team.ID)
 	} else {
-		path = fmt.Sprintf("/api/teams?name=%s", team.Name)
+		team, err = apiClient().TeamByName(ctx, team.Name)
 	}
-	if err := getJSON(path, &team); err != nil {
+	if err != nil {
 		writeStdErrAndExit(err.Error())
 	}
 	return team
@@ -94,11 +120,6 @@ Teams are used to provide access to projects by adding and remove indiviual user
 	},
 }
 
-type teamRequest struct {
-	Name        string `json:"name"`
-	Description string `json:"description"`
-}
-
 var addTeamCmd = &cobra.Command{
 	Use:   "add <name> <description>",
 	Short: "Add a new team with the provided name and description.",
@@ -112,19 +133,14 @@ access to projects.
 			writeStdErrAndExit("name and description are required.")
 		}
 
-		req := teamRequest{
+		req := client.TeamRequest{
 			Name:        args[0],
 			Description: args[1],
 		}
-		body, err := postJSON("/api/teams", req)
+		team, err := apiClient().CreateTeam(ctx, req)
 		if err != nil {
 			writeStdErrAndExit(err.Error())
 		}
-		var team hashstack.Team
-		if err := json.Unmarshal(body, &team); err != nil {
-			debug(fmt.Sprintf("Error: %s", err.Error()))
-			writeStdErrAndExit(new(jsonServerError).Error())
-		}
 		displayTeam(false, team)
 	},
 }
@@ -151,22 +167,13 @@ Delete a team by name or id.
 			team.ID = int64(teamID)
 		}
 		team = getTeam(team)
-		path := fmt.Sprintf("/api/teams/%d", team.ID)
-		if err := deleteHTTP(path); err != nil {
+		if err := apiClient().DeleteTeam(ctx, team.ID); err != nil {
 			writeStdErrAndExit(err.Error())
 		}
 		fmt.Println("The team was deleted successfully.")
 	},
 }
 
-type updateTeamReq struct {
-	Name         string `json:"name"`
-	Description  string `json:"description"`
-	IsActive     bool   `json:"is_active"`
-	OwnerUserID  int64  `json:"owner_user_id"`
-	Contributors []updateContributor
-}
-
 var updateTeamCmd = &cobra.Command{
 	Use:   "update <name|id>",
 	Short: "Modifies a team by it's name or id.",
@@ -186,11 +193,11 @@ Modifies a team by it's name or id. All values are optional and only provided op
 			team.ID = int64(teamID)
 		}
 		team = getTeam(team)
-		var contribs []updateContributor
+		var contribs []client.Contributor
 		for _, c := range team.Contributors {
-			contribs = append(contribs, updateContributor{ID: c.ID})
+			contribs = append(contribs, client.Contributor{ID: c.ID})
 		}
-		update := updateTeamReq{
+		update := client.TeamUpdate{
 			Name:         team.Name,
 			Description:  team.Description,
 			IsActive:     true,
@@ -217,13 +224,10 @@ Modifies a team by it's name or id. All values are optional and only provided op
 			getUser(&user)
 			update.OwnerUserID = user.ID
 		}
-		data, err := patchJSON(fmt.Sprintf("/api/teams/%d", team.ID), update)
+		team, err = apiClient().UpdateTeam(ctx, team.ID, update)
 		if err != nil {
 			writeStdErrAndExit(err.Error())
 		}
-		if err := json.Unmarshal(data, &team); err != nil {
-			writeStdErrAndExit(err.Error())
-		}
 		displayTeam(false, team)
 	},
 }
@@ -252,18 +256,18 @@ Adds a user to a team by the team's name or id and the user's username.
 		}
 		getUser(&user)
 		team.Contributors = append(team.Contributors, user)
-		var contribs []updateContributor
+		var contribs []client.Contributor
 		for _, c := range team.Contributors {
-			contribs = append(contribs, updateContributor{ID: c.ID})
+			contribs = append(contribs, client.Contributor{ID: c.ID})
 		}
-		update := updateTeamReq{
+		update := client.TeamUpdate{
 			Name:         team.Name,
 			Description:  team.Description,
 			IsActive:     true,
 			OwnerUserID:  team.OwnerUserID,
 			Contributors: contribs,
 		}
-		if _, err := patchJSON(fmt.Sprintf("/api/teams/%d", team.ID), update); err != nil {
+		if _, err := apiClient().UpdateTeam(ctx, team.ID, update); err != nil {
 			writeStdErrAndExit(err.Error())
 		}
 		fmt.Println("User has been added to the team.")
@@ -293,21 +297,21 @@ Removes a user from a team by the team's name or id and the user's username.
 			Username: args[1],
 		}
 		getUser(&user)
-		var contribs []updateContributor
+		var contribs []client.Contributor
 		for _, c := range team.Contributors {
 			if c.ID == user.ID {
 				continue
 			}
-			contribs = append(contribs, updateContributor{ID: c.ID})
+			contribs = append(contribs, client.Contributor{ID: c.ID})
 		}
-		update := updateTeamReq{
+		update := client.TeamUpdate{
 			Name:         team.Name,
 			Description:  team.Description,
 			OwnerUserID:  team.OwnerUserID,
 			IsActive:     true,
 			Contributors: contribs,
 		}
-		if _, err := patchJSON(fmt.Sprintf("/api/teams/%d", team.ID), update); err != nil {
+		if _, err := apiClient().UpdateTeam(ctx, team.ID, update); err != nil {
 			writeStdErrAndExit(err.Error())
 		}
 		fmt.Println("User has been removed from the team.")
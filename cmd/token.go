@@ -1,36 +1,38 @@
 package cmd
 
 import (
-	"bytes"
-	"encoding/json"
 	"fmt"
-	"net/http"
 	"os"
 	"strings"
+	"time"
 
 	"net/url"
 
-	"github.com/howeyc/gopass"
+	"github.com/segmentio/go-prompt"
 	"github.com/spf13/cobra"
+	"github.com/stricture/hashstack-cli/client"
 )
 
-type tokenRequest struct {
-	Username string `json:"username"`
-	Password string `json:"password"`
-}
-
-type tokenResponse struct {
-	Token string `json:"token"`
-}
+// envOverrideMessage is shown by the commands that change the profile while
+// the environment overrides it.
+const envOverrideMessage = "HASHSTACK_SERVER_URL or HASHSTACK_TOKEN is set, so the profile is not changed. Unset them and try again."
 
 var logoutCmd = &cobra.Command{
 	Use:   "logout",
 	Short: "Logout by removing your session token from the configuration file.",
 	Long: `
-Logout by removing your session token from the configuration file.
+Logout by removing the session token of the active profile from the keyring, encrypted file, or
+configuration file. The server of the profile is kept, see 'hashstack context'.
 `,
 	Run: func(cmd *cobra.Command, args []string) {
-		os.Remove(flCfgFile)
+		if envOverridden() {
+			writeStdErrAndExit(envOverrideMessage)
+		}
+		if _, ok := cfg.Profiles[activeProfile]; !ok {
+			return
+		}
+		flToken, refreshToken = "", ""
+		writecfg()
 	},
 }
 
@@ -40,11 +42,33 @@ var loginCmd = &cobra.Command{
 	Long: `
 This command will prompt for your password and send it along with your username to the server at server_url.
 The token returned along with the server_url will be saved in your home directory for all additional requests.
+They are saved to the active profile, which can be chosen with --profile. See 'hashstack context'.
+The token is saved to the keyring, or to a passphrase encrypted file when there is no keyring, and the
+configuration file only records where it is. See 'hashstack context migrate -h' for the token_store setting.
+
+The --ca-cert, --client-cert, --client-key, and --pin options are saved to the profile with the token.
+Logging in to a different server with an existing profile removes its saved pins, CA certificate,
+client certificate, and --insecure setting, unless they are given again.
+When the server's certificate is not trusted you are shown it and asked whether to pin its public key
+instead, which is checked on every later request.
+
+Use --password-stdin to read the password from the first line of stdin in scripts, or create an API
+token with 'hashstack api-tokens create' and set HASHSTACK_SERVER_URL and HASHSTACK_TOKEN instead of
+logging in:
+
+    hashstack login --password-stdin https://hashstack.example.com alice < password.txt
+
+Without a keyring, or with token_store = "file", --password-stdin also needs HASHSTACK_PASSPHRASE to be
+set to the passphrase of the encrypted file, since it can not be asked for. Set token_store = "plaintext"
+in the configuration file instead to keep the token in the file itself.
     `,
 	Run: func(cmd *cobra.Command, args []string) {
 		if len(args) != 2 {
 			writeStdErrAndExit("server_url and username are required.")
 		}
+		if envOverridden() {
+			writeStdErrAndExit(envOverrideMessage)
+		}
 		serverURL := args[0]
 		username := args[1]
 
@@ -52,56 +76,94 @@ The token returned along with the server_url will be saved in your home director
 			debug(fmt.Sprintf("Error: %s", err.Error()))
 			writeStdErrAndExit("The provided URL is not valid. It is possible that you provided the arguments our of order.")
 		}
+		// The passphrase can not be asked for once stdin has been read.
+		if flPasswordStdin && os.Getenv("HASHSTACK_PASSPHRASE") == "" && usesTokenFile() {
+			writeStdErrAndExit(fmt.Sprintf("The session token would be saved to the encrypted file %s, and its passphrase can not be asked for with --password-stdin. Set HASHSTACK_PASSPHRASE, or set token_store = \"plaintext\" in %s.", tokenFilePath(), flCfgFile))
+		}
 
-		fmt.Printf("Password: ")
-		pass, err := gopass.GetPasswdMasked()
+		pass, err := readPassword("Password: ")
 		if err != nil {
 			debug(fmt.Sprintf("Error: %s", err.Error()))
 			writeStdErrAndExit("There was an error reading your password.")
 		}
 
-		data, err := json.Marshal(tokenRequest{
-			Username: username,
-			Password: string(pass),
-		})
-		if err != nil {
-			debug(fmt.Sprintf("Error: %s", err.Error()))
-			writeStdErrAndExit("There was an error preparing your request for the server")
-		}
 		serverURL = strings.TrimRight(serverURL, "/")
-		path := fmt.Sprintf("%s/token", serverURL)
-		debug(fmt.Sprintf("HTTP: POST %s", path))
-		resp, err := http.Post(path, "application/json", bytes.NewBuffer(data))
+		forgetServerTrust(cmd, serverURL)
+		session, err := newClient(serverURL, "").LoginSession(ctx, username, string(pass))
+		if _, ok := err.(*client.InvalidCertError); ok && trustServer(serverURL) {
+			session, err = newClient(serverURL, "").LoginSession(ctx, username, string(pass))
+		}
 		if err != nil {
-			debug(fmt.Sprintf("Error: %s", err.Error()))
-			if strings.Contains(err.Error(), "x509") {
-				writeStdErrAndExit(new(invalidCertError).Error())
-			}
-			writeStdErrAndExit(new(requestError).Error())
+			writeStdErrAndExit(err.Error())
 		}
-		var response tokenResponse
-		switch resp.StatusCode {
-		case 401:
-			writeStdErrAndExit(new(authError).Error())
-		case 500:
-			writeStdErrAndExit(new(internalServerError).Error())
-		case 201:
-			if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
-				debug(fmt.Sprintf("Error: %s", err.Error()))
-				writeStdErrAndExit(new(invalidResponseError).Error())
-			}
-		default:
-			debug(fmt.Sprintf("HTTP: unexpected response code - %d", resp.StatusCode))
-			writeStdErrAndExit("There was an unexpected response from the server.")
+		p, ok := cfg.Profiles[activeProfile]
+		if !ok {
+			p = &profile{}
+			cfg.Profiles[activeProfile] = p
 		}
+		p.Username = username
 		flServerURL = serverURL
-		flToken = response.Token
-		writecfg()
-		fmt.Printf("Authentication credentials cached in %s.\n", flCfgFile)
+		refreshToken = ""
+		saveSession(session)
+		fmt.Printf("Authentication credentials cached in %s for the profile %s.\n", flCfgFile, activeProfile)
 	},
 }
 
+// forgetServerTrust clears the TLS settings loaded from the active profile
+// when it is logging in to a different server, so that they are not used for
+// it. Settings given as flags are kept.
+func forgetServerTrust(cmd *cobra.Command, serverURL string) {
+	p, ok := cfg.Profiles[activeProfile]
+	if !ok || p.ServerURL == "" || p.ServerURL == serverURL {
+		return
+	}
+	debug(fmt.Sprintf("AUTH: the profile %s is changing from %s to %s", activeProfile, p.ServerURL, serverURL))
+	if !cmd.Flags().Changed("pin") {
+		flPins = nil
+	}
+	if !cmd.Flags().Changed("ca-cert") {
+		flCACert = ""
+	}
+	if !cmd.Flags().Changed("insecure") {
+		flInsecure = false
+	}
+	if !cmd.Flags().Changed("client-cert") {
+		flClientCert = ""
+	}
+	if !cmd.Flags().Changed("client-key") {
+		flClientKey = ""
+	}
+	httpClient = nil
+}
+
+// trustServer shows the certificate of the server at serverURL and asks
+// whether to trust it. When it is trusted the key is pinned and saved to the
+// profile with the token.
+func trustServer(serverURL string) bool {
+	if flInsecure || len(flPins) > 0 || flCACert != "" || flPasswordStdin {
+		return false
+	}
+	cert, err := client.ServerCertificate(serverURL)
+	if err != nil {
+		debug(fmt.Sprintf("Error: %s", err.Error()))
+		return false
+	}
+	pin := client.SPKIPin(cert)
+	fmt.Println("The server's TLS certificate is not trusted.")
+	fmt.Printf("Subject.....: %s\n", cert.Subject)
+	fmt.Printf("Issuer......: %s\n", cert.Issuer)
+	fmt.Printf("Expires.....: %s\n", cert.NotAfter.Format(time.RFC3339))
+	fmt.Printf("Pin.........: %s\n", pin)
+	if !prompt.Confirm("Trust this certificate and pin its key for the profile %s? [yY/nN]", activeProfile) {
+		return false
+	}
+	flPins = []string{pin}
+	httpClient = nil
+	return true
+}
+
 func init() {
+	loginCmd.Flags().BoolVar(&flPasswordStdin, "password-stdin", false, "Read the password from stdin")
 	RootCmd.AddCommand(loginCmd)
 	RootCmd.AddCommand(logoutCmd)
 }
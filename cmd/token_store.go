@@ -0,0 +1,186 @@
+package cmd
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/howeyc/gopass"
+	"github.com/stricture/hashstack-cli/secret"
+)
+
+// Token stores, set with token_store in the configuration file.
+const (
+	tokenStoreKeyring   = "keyring"
+	tokenStoreFile      = "file"
+	tokenStorePlaintext = "plaintext"
+)
+
+// keyringService is the name the tokens are saved under in the keyring.
+const keyringService = "hashstack-cli"
+
+// tokenFile is kept so that the passphrase is only asked for once.
+var tokenFile *secret.File
+
+// tokenFilePath returns the location of the encrypted token file.
+func tokenFilePath() string {
+	return filepath.Join(filepath.Dir(flCfgFile), "tokens.enc")
+}
+
+func secretStore(name string) secret.Store {
+	if name == tokenStoreFile {
+		if tokenFile == nil {
+			tokenFile = &secret.File{Path: tokenFilePath(), Passphrase: tokenPassphrase}
+		}
+		return tokenFile
+	}
+	return secret.Keyring{Service: keyringService}
+}
+
+// tokenPassphrase reads the passphrase of the encrypted token file from
+// HASHSTACK_PASSPHRASE or the terminal. A new passphrase is entered twice.
+func tokenPassphrase(exists bool) ([]byte, error) {
+	if pass := os.Getenv("HASHSTACK_PASSPHRASE"); pass != "" {
+		return []byte(pass), nil
+	}
+	if flPasswordStdin {
+		return nil, fmt.Errorf("set HASHSTACK_PASSPHRASE to use the encrypted file with --password-stdin")
+	}
+	if exists {
+		fmt.Printf("Passphrase for %s: ", tokenFilePath())
+		return gopass.GetPasswdMasked()
+	}
+	fmt.Printf("There is no keyring, so session tokens are saved to %s.\n", tokenFilePath())
+	fmt.Printf("New passphrase: ")
+	pass, err := gopass.GetPasswdMasked()
+	if err != nil {
+		return nil, err
+	}
+	fmt.Printf("Repeat passphrase: ")
+	again, err := gopass.GetPasswdMasked()
+	if err != nil {
+		return nil, err
+	}
+	if len(pass) == 0 || !bytes.Equal(pass, again) {
+		return nil, fmt.Errorf("the passphrases are empty or do not match")
+	}
+	return pass, nil
+}
+
+// loadToken reads the token of the active profile from its store.
+func loadToken() {
+	p, ok := cfg.Profiles[activeProfile]
+	if !ok || p.TokenRef == "" {
+		return
+	}
+	token, err := secretStore(p.TokenRef).Get(tokenKey(activeProfile))
+	switch err {
+	case nil:
+		flToken = token
+		if refresh, err := secretStore(p.TokenRef).Get(refreshKey(activeProfile)); err == nil {
+			refreshToken = refresh
+		}
+	case secret.ErrNotFound:
+		debug(fmt.Sprintf("CONFIG: no token for %s in the %s", activeProfile, p.TokenRef))
+	case secret.ErrPassphrase:
+		writeStdErrAndExit("The passphrase is not correct.")
+	default:
+		debug(fmt.Sprintf("Error: %s", err.Error()))
+		writeStdErrAndExit(fmt.Sprintf("There was an error reading your session token from the %s.", p.TokenRef))
+	}
+}
+
+// saveToken saves token for the profile name to the store chosen by
+// token_store, removing any token saved before. An empty token is only removed.
+// usesTokenFile reports whether the token of the active profile will be saved
+// to the encrypted file, because token_store is file or there is no keyring.
+func usesTokenFile() bool {
+	switch cfg.TokenStore {
+	case tokenStoreFile:
+		return true
+	case "":
+		_, err := secretStore(tokenStoreKeyring).Get(tokenKey(activeProfile))
+		if err != nil && err != secret.ErrNotFound {
+			debug(fmt.Sprintf("Error: %s", err.Error()))
+			return true
+		}
+	}
+	return false
+}
+
+func saveToken(name string, p *profile, token string) {
+	deleteToken(name, p)
+	if token == "" {
+		return
+	}
+	switch cfg.TokenStore {
+	case tokenStorePlaintext:
+		p.Token = token
+		return
+	case tokenStoreKeyring, tokenStoreFile:
+		p.TokenRef = cfg.TokenStore
+	case "":
+		err := secretStore(tokenStoreKeyring).Set(tokenKey(name), token)
+		if err == nil {
+			p.TokenRef = tokenStoreKeyring
+			return
+		}
+		debug(fmt.Sprintf("Error: %s", err.Error()))
+		p.TokenRef = tokenStoreFile
+	default:
+		writeStdErrAndExit(fmt.Sprintf("The token_store in %s must be keyring, file, or plaintext.", flCfgFile))
+	}
+	if err := secretStore(p.TokenRef).Set(tokenKey(name), token); err != nil {
+		debug(fmt.Sprintf("Error: %s", err.Error()))
+		writeStdErrAndExit(fmt.Sprintf("There was an error saving your session token to the %s: %s.", p.TokenRef, err.Error()))
+	}
+}
+
+// tokenKey is the name the token of the profile name is saved under. It
+// includes a hash of the configuration file, so that profiles with the same
+// name in other configuration files do not replace it.
+func tokenKey(name string) string {
+	path := flCfgFile
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	sum := sha256.Sum256([]byte(path))
+	return hex.EncodeToString(sum[:8]) + "/" + name
+}
+
+// refreshKey is the name the refresh token of the profile name is saved under.
+func refreshKey(name string) string {
+	return tokenKey(name) + "/refresh"
+}
+
+// saveRefreshToken saves token next to the session token of the profile name,
+// so it must be called after saveToken.
+func saveRefreshToken(name string, p *profile, token string) {
+	if token == "" {
+		return
+	}
+	if p.TokenRef == "" {
+		p.RefreshToken = token
+		return
+	}
+	if err := secretStore(p.TokenRef).Set(refreshKey(name), token); err != nil {
+		debug(fmt.Sprintf("Error: %s", err.Error()))
+		writeStdErrAndExit(fmt.Sprintf("There was an error saving your refresh token to the %s: %s.", p.TokenRef, err.Error()))
+	}
+}
+
+// deleteToken removes the session and refresh tokens of the profile name from
+// their store.
+func deleteToken(name string, p *profile) {
+	if p.TokenRef != "" {
+		for _, key := range []string{tokenKey(name), refreshKey(name)} {
+			if err := secretStore(p.TokenRef).Delete(key); err != nil && err != secret.ErrNotFound {
+				debug(fmt.Sprintf("Error: %s", err.Error()))
+			}
+		}
+	}
+	p.Token, p.RefreshToken, p.TokenRef = "", "", ""
+}
@@ -0,0 +1,241 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/nsf/termbox-go"
+	"github.com/spf13/cobra"
+	"github.com/stricture/hashstack-cli/client"
+	hashstack "github.com/stricture/hashstack-server-core-ng"
+)
+
+// topDashboard is the full-screen view of the cluster and every unfinished job.
+type topDashboard struct {
+	stats   hashstack.ClusterStats
+	agents  []hashstack.Agent
+	devices map[deviceKey]deviceView
+	jobs    []jobView
+	// cracked is the number of cracked hashes for each job's list when the
+	// job was first shown.
+	cracked  map[int64]int64
+	selected int
+	message  string
+	// updateErr is the message of the last failed update, shown until an
+	// update succeeds.
+	updateErr string
+	control   jobControl
+}
+
+// update fetches the latest state from the server. When a request fails the
+// error is shown and the last state is kept, so that the dashboard stays open.
+func (d *topDashboard) update() {
+	if err := d.fetch(); err != nil {
+		debug(fmt.Sprintf("Error: %s", err.Error()))
+		d.updateErr = err.Error()
+		d.message = d.updateErr
+		return
+	}
+	if d.message == d.updateErr {
+		d.message = ""
+	}
+	d.updateErr = ""
+}
+
+// fetch replaces the state of the dashboard. The tasks and errors of jobs are
+// only fetched for the selected job and for jobs that are shown for the first
+// time, the other jobs keep the tasks fetched before.
+func (d *topDashboard) fetch() error {
+	c := apiClient()
+	stats, err := c.Stats(ctx)
+	if err != nil {
+		return err
+	}
+	agents, err := c.Agents(ctx)
+	if err != nil {
+		return err
+	}
+	projects, err := c.Projects(ctx)
+	if err != nil {
+		return err
+	}
+	sort.Slice(projects, func(i, j int) bool {
+		return projects[i].Name < projects[j].Name
+	})
+	previous := make(map[int64]jobView)
+	for _, j := range d.jobs {
+		previous[j.ID] = j
+	}
+	var selectedID int64
+	if d.selected < len(d.jobs) {
+		selectedID = d.jobs[d.selected].ID
+	}
+
+	var views []jobView
+	selected := 0
+	for _, p := range projects {
+		jobs, err := c.Jobs(ctx, p.ID)
+		if err != nil {
+			return err
+		}
+		sort.Slice(jobs, func(i, j int) bool {
+			return jobs[i].CreatedAt < jobs[j].CreatedAt
+		})
+		var lists map[int64]hashstack.List
+		for _, j := range jobs {
+			if j.IsExhausted {
+				continue
+			}
+			if lists == nil {
+				if lists, err = projectLists(c, p.ID); err != nil {
+					return err
+				}
+			}
+			list, ok := lists[j.ListID]
+			if !ok {
+				list = hashstack.List{ProjectID: j.ProjectID, ID: j.ListID}
+			}
+			prev, seen := previous[j.ID]
+			tasks, events := prev.tasks, prev.events
+			if !seen || j.ID == selectedID {
+				if tasks, err = c.Tasks(ctx, j.ProjectID, j.ID); err != nil {
+					return err
+				}
+				if events, err = c.Events(ctx, j.ProjectID, j.ID); err != nil {
+					return err
+				}
+			}
+			if j.ID == selectedID {
+				selected = len(views)
+			}
+			views = append(views, computeJobView(j, list, prev.Mode, tasks, events))
+		}
+	}
+
+	d.stats, d.agents, d.jobs = stats, agents, views
+	d.devices = agentDevices(agents)
+	for _, j := range d.jobs {
+		if _, ok := d.cracked[j.ID]; !ok {
+			d.cracked[j.ID] = j.List.RecoveredCount
+		}
+	}
+	d.selected = selected
+	return nil
+}
+
+// projectLists returns the lists of a project by id.
+func projectLists(c *client.Client, projectID int64) (map[int64]hashstack.List, error) {
+	lists, err := c.Lists(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[int64]hashstack.List, len(lists))
+	for _, l := range lists {
+		byID[l.ID] = l
+	}
+	return byID, nil
+}
+
+func (d *topDashboard) draw(c *canvas) {
+	s := d.stats
+	c.highlight(" Hashstack: %d active jobs, %d paused, %d nodes, %d GPUs, %d CPUs", s.ActiveJobCount, s.PausedJobCount, s.AgentCount, s.GPUCount, s.CPUCount)
+	c.y++
+	c.line(termbox.AttrBold, "%-6s %-16s %-20s %-8s %4s %8s %12s %12s %6s", "Job", "Target", "Name", "Status", "Pri", "Progress", "Speed", "Cracked", "Errors")
+	if len(d.jobs) == 0 {
+		c.line(termbox.ColorDefault, "There are no running or paused jobs.")
+	}
+	for i, j := range d.jobs {
+		row := fmt.Sprintf("%-6d %-16.16s %-20.20s %-8s %4d %7.2f%% %12s %12s %6d", j.ID, j.List.Name, j.Name, j.Status, j.Priority, j.ProgressPercent, formatHashRate(j.Speed), fmt.Sprintf("%d (+%d)", j.List.RecoveredCount, j.List.RecoveredCount-d.cracked[j.ID]), j.ErrorCount)
+		if i == d.selected {
+			c.highlight("%s", row)
+			continue
+		}
+		c.line(termbox.ColorDefault, "%s", row)
+	}
+	if d.selected < len(d.jobs) {
+		c.y++
+		j := d.jobs[d.selected]
+		c.bar(fmt.Sprintf("Job %d: ", j.ID), j.ProgressPercent)
+		for i, task := range j.tasks {
+			c.bar(fmt.Sprintf("  Task %d: ", i+1), taskPercent(task))
+		}
+	}
+	c.y++
+	// Only the tasks of the selected job are fetched on each update.
+	var speeds map[deviceKey]uint64
+	if d.selected < len(d.jobs) {
+		speeds = deviceSpeeds(d.jobs[d.selected].tasks)
+		c.line(termbox.ColorDefault, "Device speeds are for job %d.", d.jobs[d.selected].ID)
+	}
+	var devices []deviceView
+	for _, a := range d.agents {
+		if !isOnline(a) {
+			continue
+		}
+		for _, dev := range a.Devices {
+			devices = append(devices, deviceView{Device: dev, Hostname: a.Hostname, Speed: speeds[deviceKey{a.ID, dev.ID}]})
+		}
+	}
+	drawDevices(c, devices)
+	c.footer("q quit  up/down select  "+jobControlHelp, d.message)
+}
+
+func (d *topDashboard) key(ev termbox.Event) bool {
+	if !d.control.confirming() {
+		switch {
+		case ev.Ch == 'q' || ev.Key == termbox.KeyEsc:
+			return false
+		case ev.Key == termbox.KeyArrowUp || ev.Ch == 'k':
+			if d.selected > 0 {
+				d.selected--
+			}
+			d.message = ""
+			return true
+		case ev.Key == termbox.KeyArrowDown || ev.Ch == 'j':
+			if d.selected < len(d.jobs)-1 {
+				d.selected++
+			}
+			d.message = ""
+			return true
+		}
+	}
+	var job hashstack.Job
+	if d.selected < len(d.jobs) {
+		job = d.jobs[d.selected].Job
+	} else if !d.control.confirming() {
+		return true
+	}
+	message, handled, _ := d.control.key(job, ev)
+	if handled {
+		d.message = message
+		if !d.control.confirming() {
+			d.update()
+		}
+	}
+	return true
+}
+
+var topCmd = &cobra.Command{
+	Use:   "top",
+	Short: "Display the cluster and every running or paused job in a full-screen dashboard.",
+	Long: `
+Display the cluster and every running or paused job in a full-screen dashboard that refreshes every
+5 seconds. Use the arrow keys to select a job, then p to pause it, s to start it, + or - to change its
+priority, or d to delete it. Press q to quit.
+
+The progress, speed, and errors of the selected job are updated on every refresh, and those of the other
+jobs when they are selected. Device speeds are for the selected job. When the server can not be reached
+the error is shown and the dashboard keeps showing the last data until the next refresh succeeds.
+`,
+	PreRun: ensureAuth,
+	Run: func(cmd *cobra.Command, args []string) {
+		if isStructuredOutput() {
+			writeStdErrAndExit("hashstack top does not support --output. Use 'hashstack status' or 'hashstack jobs' instead.")
+		}
+		runDashboard(&topDashboard{cracked: make(map[int64]int64)})
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(topCmd)
+}
@@ -8,6 +8,10 @@ import (
 )
 
 func displayUser(user hashstack.User) {
+	if isStructuredOutput() {
+		renderOutput(user)
+		return
+	}
 	fmt.Printf("ID.......: %d\n", user.ID)
 	fmt.Printf("Username.: %s\n", user.Username)
 	if len(user.Roles) > 0 {
@@ -17,26 +21,34 @@ func displayUser(user hashstack.User) {
 }
 
 func displayUsers(users []hashstack.User) {
+	if isStructuredOutput() {
+		renderOutput(users)
+		return
+	}
 	for _, u := range users {
 		displayUser(u)
 	}
 }
 
 func getUser(user *hashstack.User) {
-	var path string
+	var (
+		u   hashstack.User
+		err error
+	)
 	if user.ID != 0 {
-		path = fmt.Sprintf("/api/users/%d", user.ID)
+		u, err = apiClient().User(ctx, user.ID)
 	} else {
-		path = fmt.Sprintf("/api/users?username=%s", user.Username)
+		u, err = apiClient().UserByUsername(ctx, user.Username)
 	}
-	if err := getJSON(path, user); err != nil {
+	if err != nil {
 		writeStdErrAndExit(err.Error())
 	}
+	*user = u
 }
 
 func getUsers() []hashstack.User {
-	var users []hashstack.User
-	if err := getRangeJSON("/api/users", &users); err != nil {
+	users, err := apiClient().Users(ctx)
+	if err != nil {
 		writeStdErrAndExit(err.Error())
 	}
 	return users
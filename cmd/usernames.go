@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	hashstack "github.com/stricture/hashstack-server-core-ng"
+)
+
+// usernamePair is a username removed from a user:hash line before upload.
+type usernamePair struct {
+	Username string
+	Hash     string
+}
+
+// usernameMapPath returns the location of the usernames saved for the list, or
+// group of lists, named name in a project on the current server. Usernames are
+// only stored locally and are never sent to the server.
+func usernameMapPath(projectID int64, name string) string {
+	return filepath.Join(serverDataDir("usernames"), fmt.Sprintf("%d", projectID), name+".tsv")
+}
+
+// serverDataDir returns the directory for local data of kind that belongs to
+// the current server, so that data for lists on different servers is kept apart.
+func serverDataDir(kind string) string {
+	sum := sha256.Sum256([]byte(flServerURL))
+	return filepath.Join(filepath.Dir(flCfgFile), kind, hex.EncodeToString(sum[:8]))
+}
+
+// saveUsernameMap writes each pair as a tab separated line.
+func saveUsernameMap(projectID int64, name string, pairs []usernamePair) (string, error) {
+	filename := usernameMapPath(projectID, name)
+	if err := os.MkdirAll(filepath.Dir(filename), 0700); err != nil {
+		return filename, err
+	}
+	fh, err := os.OpenFile(filename, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return filename, err
+	}
+	defer fh.Close()
+	w := bufio.NewWriter(fh)
+	for _, p := range pairs {
+		fmt.Fprintf(w, "%s\t%s\n", p.Username, p.Hash)
+	}
+	return filename, w.Flush()
+}
+
+// loadUsernameMap returns the usernames saved for the list, or group of lists,
+// named name keyed by hash. Accounts that share a hash are all returned.
+func loadUsernameMap(projectID int64, name string) (map[string][]string, error) {
+	fh, err := os.Open(usernameMapPath(projectID, name))
+	if err != nil {
+		return nil, err
+	}
+	defer fh.Close()
+	users := make(map[string][]string)
+	seen := make(map[usernamePair]bool)
+	scanner := bufio.NewScanner(fh)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		parts := strings.SplitN(scanner.Text(), "\t", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		p := usernamePair{Username: parts[0], Hash: parts[1]}
+		if seen[p] {
+			continue
+		}
+		seen[p] = true
+		users[p.Hash] = append(users[p.Hash], p.Username)
+	}
+	return users, scanner.Err()
+}
+
+// listGroupName returns the name the usernames for lists were saved under.
+func listGroupName(lists []hashstack.List) string {
+	return listShardSuffix.ReplaceAllString(lists[0].Name, "")
+}
@@ -0,0 +1,28 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestJoinPlains(t *testing.T) {
+	Convey("Given usernames for a salted hash shared by two accounts", t, func() {
+		users := map[string][]string{
+			"5f4dcc3b5aa765d61d8327deb882cf99:salt": {"alice", "bob"},
+		}
+		plains := "5f4dcc3b5aa765d61d8327deb882cf99:salt:pass:word\n0a0a:s:other\n"
+		var out bytes.Buffer
+
+		Convey("A line is printed for each account and unknown hashes have an empty username", func() {
+			invalid, err := writeCracked(strings.NewReader(plains), &out, crackedHashcat, 2, users, true)
+			So(err, ShouldBeNil)
+			So(invalid, ShouldEqual, 0)
+			So(out.String(), ShouldEqual, "alice:5f4dcc3b5aa765d61d8327deb882cf99:salt:pass:word\n"+
+				"bob:5f4dcc3b5aa765d61d8327deb882cf99:salt:pass:word\n"+
+				":0a0a:s:other\n")
+		})
+	})
+}
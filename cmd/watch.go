@@ -0,0 +1,340 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/stricture/hashstack-cli/client"
+	"github.com/stricture/hashstack-cli/notify"
+	hashstack "github.com/stricture/hashstack-server-core-ng"
+)
+
+var (
+	flWatchInterval time.Duration
+	flWatchTest     bool
+)
+
+// defaultCrackedPercent are the thresholds used when the configuration file
+// does not set cracked_percent.
+var defaultCrackedPercent = []float64{25, 50, 75, 100}
+
+// watchConfig is the [watch] section of the configuration file.
+type watchConfig struct {
+	Interval       string          `toml:"interval,omitempty"`
+	CrackedPercent []float64       `toml:"cracked_percent,omitempty"`
+	Notifiers      []notify.Config `toml:"notifiers,omitempty"`
+}
+
+// jobState is what hashstack watch remembers about a job between polls.
+type jobState struct {
+	Active     bool
+	Exhausted  bool
+	Cracked    int64
+	FirstCrack bool
+	// Percent is the highest cracked_percent threshold that has been reached.
+	Percent float64
+	// LastEvent is the ID of the newest agent event that has been seen.
+	LastEvent int64
+}
+
+// newJobState returns the state of a job the first time it is seen. Nothing
+// that has already happened is reported.
+func newJobState(job hashstack.Job, list hashstack.List, events []hashstack.AgentEvent, thresholds []float64) jobState {
+	s := jobState{
+		Active:    job.IsActive,
+		Exhausted: job.IsExhausted,
+		Cracked:   list.RecoveredCount,
+	}
+	for _, t := range thresholds {
+		if crackedPercent(list) >= t && t > s.Percent {
+			s.Percent = t
+		}
+	}
+	for _, e := range events {
+		if e.ID > s.LastEvent {
+			s.LastEvent = e.ID
+		}
+	}
+	return s
+}
+
+func crackedPercent(list hashstack.List) float64 {
+	if list.DigestCount < 1 {
+		return 0
+	}
+	return float64(list.RecoveredCount) / float64(list.DigestCount) * 100
+}
+
+// detectEvents compares a job with its state at the last poll and returns the
+// events that happened in between along with the new state.
+func detectEvents(prev jobState, p hashstack.Project, job hashstack.Job, list hashstack.List, events []hashstack.AgentEvent, thresholds []float64) ([]notify.Event, jobState) {
+	var (
+		next   = prev
+		out    []notify.Event
+		now    = time.Now().Unix()
+		cur    = crackedPercent(list)
+		newEvt = func(kind, msg string) notify.Event {
+			return notify.Event{
+				Kind:      kind,
+				Time:      now,
+				ProjectID: p.ID,
+				Project:   p.Name,
+				JobID:     job.ID,
+				Job:       job.Name,
+				List:      list.Name,
+				Cracked:   list.RecoveredCount,
+				Digests:   list.DigestCount,
+				Percent:   cur,
+				Message:   msg,
+			}
+		}
+	)
+	next.Active = job.IsActive
+	next.Exhausted = job.IsExhausted
+	next.Cracked = list.RecoveredCount
+
+	if list.RecoveredCount > prev.Cracked && !prev.FirstCrack {
+		next.FirstCrack = true
+		out = append(out, newEvt(notify.FirstCrack, fmt.Sprintf("Job %s cracked its first hashes in list %s: %d of %d recovered.", job.Name, list.Name, list.RecoveredCount, list.DigestCount)))
+	}
+	var reached float64
+	for _, t := range thresholds {
+		if cur >= t && t > prev.Percent && t > reached {
+			reached = t
+		}
+	}
+	if reached > 0 {
+		next.Percent = reached
+		out = append(out, newEvt(notify.CrackedPercent, fmt.Sprintf("List %s is %.0f%% cracked: %d of %d recovered.", list.Name, reached, list.RecoveredCount, list.DigestCount)))
+	}
+	if job.IsExhausted && !prev.Exhausted {
+		out = append(out, newEvt(notify.JobExhausted, fmt.Sprintf("Job %s exhausted its keyspace with %d of %d hashes in list %s recovered.", job.Name, list.RecoveredCount, list.DigestCount, list.Name)))
+	}
+	if !job.IsActive && !job.IsExhausted && prev.Active {
+		out = append(out, newEvt(notify.JobPaused, fmt.Sprintf("Job %s was paused.", job.Name)))
+	}
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].ID < events[j].ID
+	})
+	for _, e := range events {
+		if e.ID <= prev.LastEvent {
+			continue
+		}
+		next.LastEvent = e.ID
+		out = append(out, newEvt(notify.AgentError, fmt.Sprintf("An agent reported an error for job %s: %s", job.Name, strings.TrimSpace(e.Buffer))))
+	}
+	return out, next
+}
+
+// watcher polls the server and sends the events it finds to every notifier.
+type watcher struct {
+	projects   []string
+	named      []hashstack.Project
+	thresholds []float64
+	notifiers  []notify.Notifier
+	jobs       map[int64]jobState
+}
+
+// poll checks every job once. Errors are reported and the job is checked
+// again at the next poll, so that a brief outage does not stop the watch.
+func (w *watcher) poll(c *client.Client) {
+	projects, err := w.watchedProjects(c)
+	if err != nil {
+		w.warn(err)
+		return
+	}
+	for _, p := range projects {
+		jobs, err := c.Jobs(ctx, p.ID)
+		if err != nil {
+			w.warn(err)
+			continue
+		}
+		for _, job := range jobs {
+			list, err := c.List(ctx, p.ID, job.ListID)
+			if err != nil {
+				w.warn(err)
+				continue
+			}
+			events, err := c.Events(ctx, p.ID, job.ID)
+			if err != nil {
+				w.warn(err)
+				continue
+			}
+			prev, ok := w.jobs[job.ID]
+			if !ok {
+				w.jobs[job.ID] = newJobState(job, list, events, w.thresholds)
+				continue
+			}
+			found, next := detectEvents(prev, p, job, list, events, w.thresholds)
+			w.jobs[job.ID] = next
+			for _, e := range found {
+				w.send(e)
+			}
+		}
+	}
+}
+
+// watchedProjects returns the projects named on the command line, or every
+// project when none were named. The named projects are looked up once.
+func (w *watcher) watchedProjects(c *client.Client) ([]hashstack.Project, error) {
+	if len(w.projects) == 0 {
+		return c.Projects(ctx)
+	}
+	if w.named != nil {
+		return w.named, nil
+	}
+	var named []hashstack.Project
+	for _, arg := range w.projects {
+		var (
+			p   hashstack.Project
+			err error
+		)
+		if id, converr := strconv.ParseInt(arg, 10, 64); converr == nil {
+			p, err = c.Project(ctx, id)
+		} else {
+			p, err = c.ProjectByName(ctx, arg)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", arg, err.Error())
+		}
+		named = append(named, p)
+	}
+	w.named = named
+	return w.named, nil
+}
+
+func (w *watcher) send(e notify.Event) {
+	fmt.Printf("%s  %-15s  %s\n", time.Unix(e.Time, 0).Format("2006-01-02 15:04:05"), e.Kind, e.Message)
+	for _, n := range w.notifiers {
+		if err := n.Notify(e); err != nil {
+			debug(fmt.Sprintf("Error: %s", err.Error()))
+			fmt.Fprintf(os.Stderr, "There was an error sending a notification: %s\n", err.Error())
+		}
+	}
+}
+
+func (w *watcher) warn(err error) {
+	debug(fmt.Sprintf("Error: %s", err.Error()))
+	fmt.Fprintf(os.Stderr, "%s  There was an error polling the server: %s\n", time.Now().Format("2006-01-02 15:04:05"), err.Error())
+}
+
+// newWatcher builds the notifiers and thresholds from the configuration file.
+func newWatcher(projects []string) *watcher {
+	w := &watcher{
+		projects:   projects,
+		thresholds: defaultCrackedPercent,
+		jobs:       make(map[int64]jobState),
+	}
+	if cfg.Watch == nil {
+		return w
+	}
+	if len(cfg.Watch.CrackedPercent) > 0 {
+		w.thresholds = cfg.Watch.CrackedPercent
+	}
+	for i, c := range cfg.Watch.Notifiers {
+		n, err := notify.New(c)
+		if err != nil {
+			writeStdErrAndExit(fmt.Sprintf("Notifier %d in %s is not valid: %s.", i+1, flCfgFile, err.Error()))
+		}
+		w.notifiers = append(w.notifiers, n)
+	}
+	return w
+}
+
+var watchCmd = &cobra.Command{
+	Use:   "watch [project...]",
+	Short: "Watch jobs and send notifications when hashes are cracked or jobs stop.",
+	Long: `
+Poll the server for changes to the jobs in each project, or every project, and send a notification
+for each of these events:
+
+    first_crack       a job cracked its first hash since the watch started
+    cracked_percent   a list reached one of the cracked_percent thresholds
+    job_exhausted     a job finished its keyspace
+    job_paused        a job was paused
+    agent_error       an agent reported an error for a job
+
+Events are printed as they happen and sent to the notifiers in the [watch] section of the
+configuration file. Run hashstack watch in the background, with nohup or a service manager, to be
+notified while you are away.
+
+    [watch]
+    interval = "1m"
+    cracked_percent = [25, 50, 75, 100]
+
+    [[watch.notifiers]]
+    type = "desktop"
+
+    [[watch.notifiers]]
+    type = "command"
+    command = "echo \"$HASHSTACK_EVENT $HASHSTACK_MESSAGE\" >> ~/hashstack-events.log"
+
+    [[watch.notifiers]]
+    type = "webhook"
+    url = "https://hooks.example.com/hashstack"
+    events = ["first_crack", "job_exhausted"]
+    headers = { Authorization = "Bearer secret" }
+
+    [[watch.notifiers]]
+    type = "smtp"
+    host = "smtp.example.com"
+    port = 587
+    username = "alerts"
+    password_env = "HASHSTACK_SMTP_PASSWORD"
+    from = "alerts@example.com"
+    to = ["team@example.com"]
+
+Command notifiers receive the event as JSON on stdin and in HASHSTACK_EVENT, HASHSTACK_PROJECT,
+HASHSTACK_JOB, HASHSTACK_LIST, HASHSTACK_CRACKED, HASHSTACK_DIGESTS, and HASHSTACK_MESSAGE. Webhooks
+receive the same JSON in a POST. Each notifier can be limited to some events with events. The SMTP
+password is read from the environment variable named by password_env rather than saved in the file.
+
+Use --test to send a test notification to every notifier and exit.
+`,
+	PreRun: ensureAuth,
+	Run: func(cmd *cobra.Command, args []string) {
+		w := newWatcher(args)
+		if flWatchTest {
+			if len(w.notifiers) == 0 {
+				writeStdErrAndExit(fmt.Sprintf("There are no notifiers in %s.", flCfgFile))
+			}
+			w.send(notify.Event{
+				Kind:    notify.Test,
+				Time:    time.Now().Unix(),
+				Message: "This is a test notification from hashstack watch.",
+			})
+			return
+		}
+		interval := flWatchInterval
+		if !cmd.Flags().Changed("interval") && cfg.Watch != nil && cfg.Watch.Interval != "" {
+			d, err := time.ParseDuration(cfg.Watch.Interval)
+			if err != nil {
+				writeStdErrAndExit(fmt.Sprintf("The interval in %s is not a duration such as 30s or 5m.", flCfgFile))
+			}
+			interval = d
+		}
+		if interval < 5*time.Second {
+			writeStdErrAndExit("The interval must be at least 5s.")
+		}
+		if len(w.notifiers) == 0 {
+			fmt.Fprintf(os.Stderr, "There are no notifiers in %s, events will only be printed.\n", flCfgFile)
+		}
+		c := apiClient()
+		w.poll(c)
+		fmt.Printf("Watching %d jobs every %s. Press Ctrl+C to stop.\n", len(w.jobs), interval)
+		for range time.Tick(interval) {
+			w.poll(c)
+		}
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(watchCmd)
+	watchCmd.PersistentFlags().DurationVar(&flWatchInterval, "interval", time.Minute, "time between polls, overrides interval in the configuration file")
+	watchCmd.PersistentFlags().BoolVar(&flWatchTest, "test", false, "send a test notification to every notifier and exit")
+}
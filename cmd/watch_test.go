@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/stricture/hashstack-cli/client"
+	"github.com/stricture/hashstack-cli/notify"
+	hashstack "github.com/stricture/hashstack-server-core-ng"
+)
+
+func TestDetectEvents(t *testing.T) {
+	Convey("Given a running job with no cracked hashes", t, func() {
+		p := hashstack.Project{ID: 1, Name: "acme"}
+		job := hashstack.Job{ID: 2, Name: "rockyou", IsActive: true}
+		list := hashstack.List{Name: "ntlm", DigestCount: 100}
+		events := []hashstack.AgentEvent{{ID: 7, Buffer: "old error"}}
+		thresholds := []float64{25, 50}
+		prev := newJobState(job, list, events, thresholds)
+
+		Convey("Nothing is reported when nothing changed", func() {
+			found, _ := detectEvents(prev, p, job, list, events, thresholds)
+			So(found, ShouldBeEmpty)
+		})
+
+		Convey("Cracks past both thresholds report the first crack and the highest threshold once", func() {
+			list.RecoveredCount = 60
+			found, next := detectEvents(prev, p, job, list, events, thresholds)
+			So(len(found), ShouldEqual, 2)
+			So(found[0].Kind, ShouldEqual, notify.FirstCrack)
+			So(found[1].Kind, ShouldEqual, notify.CrackedPercent)
+			So(found[1].Message, ShouldContainSubstring, "50%")
+
+			list.RecoveredCount = 70
+			found, _ = detectEvents(next, p, job, list, events, thresholds)
+			So(found, ShouldBeEmpty)
+		})
+
+		Convey("Pausing, new agent events, and exhaustion are reported", func() {
+			job.IsActive = false
+			events = append(events, hashstack.AgentEvent{ID: 8, Buffer: "device overheated\n"})
+			found, next := detectEvents(prev, p, job, list, events, thresholds)
+			So(len(found), ShouldEqual, 2)
+			So(found[0].Kind, ShouldEqual, notify.JobPaused)
+			So(found[1].Kind, ShouldEqual, notify.AgentError)
+			So(found[1].Message, ShouldEndWith, "device overheated")
+
+			job.IsExhausted = true
+			found, _ = detectEvents(next, p, job, list, events, thresholds)
+			So(len(found), ShouldEqual, 1)
+			So(found[0].Kind, ShouldEqual, notify.JobExhausted)
+		})
+	})
+}
+
+func TestWatchedProjects(t *testing.T) {
+	Convey("Given a server that is down when the watch starts", t, func() {
+		down := true
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if down {
+				w.WriteHeader(503)
+				return
+			}
+			json.NewEncoder(w).Encode(hashstack.Project{ID: 1, Name: r.URL.Query().Get("name")})
+		}))
+		defer ts.Close()
+		w := &watcher{projects: []string{"acme"}}
+		c := client.New(ts.URL, "secret")
+
+		Convey("The error is returned and the project is looked up again at the next poll", func() {
+			_, err := w.watchedProjects(c)
+			So(err, ShouldNotBeNil)
+			down = false
+			projects, err := w.watchedProjects(c)
+			So(err, ShouldBeNil)
+			So(projects, ShouldResemble, []hashstack.Project{{ID: 1, Name: "acme"}})
+		})
+	})
+}
@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/stricture/hashstack-cli/client"
+)
+
+// whoamiView is the user that owns the session token and when it expires.
+type whoamiView struct {
+	Profile   string   `json:"profile"`
+	ServerURL string   `json:"server_url"`
+	ID        int64    `json:"id"`
+	Username  string   `json:"username"`
+	Roles     []string `json:"roles"`
+	// ExpiresAt is empty when the token is not a JWT.
+	ExpiresAt  string `json:"expires_at,omitempty"`
+	Refreshing bool   `json:"refreshing"`
+}
+
+var whoamiCmd = &cobra.Command{
+	Use:   "whoami",
+	Short: "Display the user and session of the active profile.",
+	Long: `
+Display the user that owns the session token of the active profile, their roles, and when the token
+expires. Commands warn when the token expires within an hour, and ask for your password again when
+the server rejects it, unless the server returned a refresh token at login that can be used instead.
+Full-screen dashboards only use the refresh token, since they read the terminal themselves.
+`,
+	PreRun: ensureAuth,
+	Run: func(cmd *cobra.Command, args []string) {
+		user, err := apiClient().Self(ctx)
+		if err != nil {
+			writeStdErrAndExit(err.Error())
+		}
+		v := whoamiView{
+			Profile:    activeProfile,
+			ServerURL:  flServerURL,
+			ID:         user.ID,
+			Username:   user.Username,
+			Roles:      []string{},
+			Refreshing: refreshToken != "",
+		}
+		for _, r := range user.Roles {
+			v.Roles = append(v.Roles, r.Role)
+		}
+		exp, ok := client.TokenExpiry(flToken)
+		if ok {
+			v.ExpiresAt = exp.Format(time.RFC3339)
+		}
+		if isStructuredOutput() {
+			renderOutput(v)
+			return
+		}
+		fmt.Printf("Profile.....: %s\n", v.Profile)
+		fmt.Printf("Server......: %s\n", v.ServerURL)
+		fmt.Printf("Username....: %s\n", v.Username)
+		fmt.Printf("ID..........: %d\n", v.ID)
+		fmt.Printf("Roles.......: %s\n", strings.Join(v.Roles, ", "))
+		if ok {
+			fmt.Printf("Expires.....: %s (in %s)\n", v.ExpiresAt, time.Until(exp).Round(time.Minute))
+		} else {
+			fmt.Println("Expires.....: unknown")
+		}
+		fmt.Printf("Refresh.....: %s\n", yesNo(v.Refreshing))
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(whoamiCmd)
+}
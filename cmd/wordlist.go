@@ -8,20 +8,26 @@ import (
 
 	humanize "github.com/dustin/go-humanize"
 	"github.com/spf13/cobra"
+	"github.com/stricture/hashstack-cli/client"
 	hashstack "github.com/stricture/hashstack-server-core-ng"
 )
 
 func getWordlist(f *hashstack.File) {
-	path := fmt.Sprintf("/api/wordlists?filename=%s", f.Filename)
-	if err := getJSON(path, &f); err != nil {
+	file, err := apiClient().Wordlist(ctx, f.Filename)
+	if err != nil {
 		writeStdErrAndExit(err.Error())
 	}
+	*f = file
 }
 
 func displayWordlist(f hashstack.File) {
 	if f.ID == 0 {
 		getWordlist(&f)
 	}
+	if isStructuredOutput() {
+		renderOutput(f)
+		return
+	}
 	fmt.Printf("Name.............: %s\n", f.Filename)
 	fmt.Printf("Words............: %d\n", f.Lines)
 	fmt.Printf("Size.............: %s\n", humanize.Bytes(uint64(f.Size)))
@@ -30,13 +36,17 @@ func displayWordlist(f hashstack.File) {
 }
 
 func displayWordlists() {
-	var wordlists []hashstack.File
-	if err := getRangeJSON("/api/wordlists", &wordlists); err != nil {
+	wordlists, err := apiClient().Files(ctx, client.WordlistFile)
+	if err != nil {
 		writeStdErrAndExit(err.Error())
 	}
 	sort.Slice(wordlists, func(i, j int) bool {
 		return wordlists[i].Filename < wordlists[j].Filename
 	})
+	if isStructuredOutput() {
+		renderOutput(wordlists)
+		return
+	}
 	for _, w := range wordlists {
 		displayWordlist(w)
 	}
@@ -62,16 +72,23 @@ Wordlists can be used in jobs. Additional subcommands are available to add and d
 }
 
 var addWordlistCmd = &cobra.Command{
-	Use:    "add <file>",
-	Short:  "Upload the provided file to the server.",
-	Long:   "Upload the provided file to the server.",
+	Use:   "add <file>",
+	Short: "Upload the provided file to the server.",
+	Long: `
+Upload the provided file to the server. Files are sent in chunks and the checksum of the
+server copy is verified once the upload finishes. If the upload is interrupted, run the same
+command again to resume it.
+
+Files ending in .gz, .zst, or .bz2 are decompressed before they are sent. Uploads are compressed
+with zstd or gzip when the server supports it. Use --compress to choose the compression.
+`,
 	PreRun: ensureAuth,
 	Run: func(cmd *cobra.Command, args []string) {
 		if len(args) < 1 {
 			writeStdErrAndExit("file is required.")
 		}
 		file := args[0]
-		uploadFile("/api/wordlists", file)
+		uploadFile(client.WordlistFile, file)
 	},
 }
 
@@ -80,8 +97,7 @@ func deleteWordlist(filename string) {
 		Filename: filename,
 	}
 	getWordlist(&f)
-	path := fmt.Sprintf("/api/wordlists/%d", f.ID)
-	if err := deleteHTTP(path); err != nil {
+	if err := apiClient().DeleteFile(ctx, client.WordlistFile, f.ID); err != nil {
 		writeStdErrAndExit(err.Error())
 	}
 	fmt.Println("The wordlist was deleted successfully.")
@@ -104,6 +120,7 @@ var delWordlistCmd = &cobra.Command{
 }
 
 func init() {
+	addWordlistCmd.PersistentFlags().StringVar(&flCompress, "compress", compressAuto, "upload compression: auto, none, gzip, or zstd")
 	wordlistCmd.AddCommand(addWordlistCmd)
 	wordlistCmd.AddCommand(delWordlistCmd)
 	RootCmd.AddCommand(wordlistCmd)
@@ -0,0 +1,144 @@
+// Package hashfmt validates the text representation of hashes for a hash mode
+// so that lists can be checked locally before they are sent to the server.
+package hashfmt
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// Format describes how hashes for a single hash mode are written.
+type Format struct {
+	Mode int
+	Name string
+	// Prefix, when set, starts every hash, e.g. $2a$.
+	Prefix string
+	// Length is the number of hex characters in the digest. It is ignored when
+	// Pattern is set.
+	Length int
+	// Pattern, when set, must match the entire hash instead of Length.
+	Pattern *regexp.Regexp
+	// Salted formats are written as hash:salt.
+	Salted bool
+	// Fields is the number of ':' separated fields in a hash when it is more
+	// than the one, or two for a salted format, that is assumed otherwise.
+	Fields int
+}
+
+// HashFields returns the number of ':' separated fields in a hash, which is
+// where the plain starts in a hash:plain line.
+func (f Format) HashFields() int {
+	switch {
+	case f.Fields > 0:
+		return f.Fields
+	case f.Salted:
+		return 2
+	}
+	return 1
+}
+
+// LineError is an invalid line in a list.
+type LineError struct {
+	Line int    `json:"line"`
+	Text string `json:"text"`
+	Err  string `json:"error"`
+}
+
+func (e LineError) Error() string {
+	return fmt.Sprintf("Line %d: %s", e.Line, e.Err)
+}
+
+// Result summarizes the validation of a list.
+type Result struct {
+	Lines   int         `json:"lines"`
+	Valid   int         `json:"valid"`
+	Invalid int         `json:"invalid"`
+	Errors  []LineError `json:"errors"`
+}
+
+// Lookup returns the format for mode. ok is false when there are no local
+// rules for mode.
+func Lookup(mode int) (f Format, ok bool) {
+	f, ok = formats[mode]
+	return f, ok
+}
+
+// Validate returns an error describing why line is not a valid hash for f.
+// When hexSalt is true the salt of a salted format must be hex encoded.
+func (f Format) Validate(line string, hexSalt bool) error {
+	hash, salt := line, ""
+	if f.Salted {
+		parts := strings.SplitN(line, ":", 2)
+		if len(parts) != 2 || parts[1] == "" {
+			return fmt.Errorf("missing the salt, expected hash:salt")
+		}
+		hash, salt = parts[0], parts[1]
+	}
+	if f.Prefix != "" && !strings.HasPrefix(hash, f.Prefix) {
+		return fmt.Errorf("missing the %s prefix", f.Prefix)
+	}
+	switch {
+	case f.Pattern != nil:
+		if !f.Pattern.MatchString(hash) {
+			return fmt.Errorf("not a valid %s hash", f.Name)
+		}
+	case f.Length > 0:
+		if len(hash) != f.Length {
+			return fmt.Errorf("expected %d hex characters, found %d", f.Length, len(hash))
+		}
+		if !isHex(hash) {
+			return fmt.Errorf("contains characters that are not hex")
+		}
+	}
+	if hexSalt && f.Salted {
+		if len(salt)%2 != 0 || !isHex(salt) {
+			return fmt.Errorf("the salt is not valid hex")
+		}
+	}
+	return nil
+}
+
+// ValidateReader checks every non-empty line of r. When normalize is not nil,
+// each line is passed through it before it is checked. When valid is not nil,
+// each valid line is written to it, which can be used to strip invalid lines.
+func (f Format) ValidateReader(r io.Reader, hexSalt bool, normalize func(string) string, valid io.Writer) (Result, error) {
+	var result Result
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if normalize != nil {
+			line = normalize(line)
+		}
+		if line == "" {
+			continue
+		}
+		result.Lines++
+		if err := f.Validate(line, hexSalt); err != nil {
+			result.Invalid++
+			result.Errors = append(result.Errors, LineError{Line: n, Text: line, Err: err.Error()})
+			continue
+		}
+		result.Valid++
+		if valid != nil {
+			if _, err := fmt.Fprintln(valid, line); err != nil {
+				return result, err
+			}
+		}
+	}
+	return result, scanner.Err()
+}
+
+func isHex(s string) bool {
+	for _, c := range s {
+		switch {
+		case c >= '0' && c <= '9', c >= 'a' && c <= 'f', c >= 'A' && c <= 'F':
+		default:
+			return false
+		}
+	}
+	return true
+}
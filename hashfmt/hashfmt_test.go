@@ -0,0 +1,137 @@
+package hashfmt
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestValidate(t *testing.T) {
+	Convey("Given the MD5 format", t, func() {
+		f, ok := Lookup(0)
+		So(ok, ShouldBeTrue)
+
+		Convey("A 32 character hex digest is valid", func() {
+			So(f.Validate("5f4dcc3b5aa765d61d8327deb882cf99", false), ShouldBeNil)
+		})
+
+		Convey("Short digests and non-hex characters are invalid", func() {
+			So(f.Validate("5f4dcc3b5aa765d61d8327deb882cf9", false), ShouldNotBeNil)
+			So(f.Validate("5f4dcc3b5aa765d61d8327deb882cfzz", false), ShouldNotBeNil)
+		})
+	})
+
+	Convey("Given a salted format with --hex-salt", t, func() {
+		f, _ := Lookup(10)
+
+		Convey("The salt must be hex", func() {
+			So(f.Validate("5f4dcc3b5aa765d61d8327deb882cf99:abcd", true), ShouldBeNil)
+			So(f.Validate("5f4dcc3b5aa765d61d8327deb882cf99:salt", true), ShouldNotBeNil)
+			So(f.Validate("5f4dcc3b5aa765d61d8327deb882cf99", true), ShouldNotBeNil)
+		})
+	})
+
+	Convey("Given the bcrypt format", t, func() {
+		f, _ := Lookup(3200)
+
+		Convey("The $2a$ format is valid", func() {
+			So(f.Validate("$2a$05$LhayLxezLhK1LhWvKxCyLOj0j1u.Kj0jZ0pEmm134uzrQlFvQJLF6", false), ShouldBeNil)
+		})
+	})
+
+	Convey("Given formats whose hashes contain ':'", t, func() {
+		md5, _ := Lookup(0)
+		salted, _ := Lookup(10)
+		netntlm, _ := Lookup(5600)
+
+		Convey("The plain of a hash:plain line starts after every field of the hash", func() {
+			So(md5.HashFields(), ShouldEqual, 1)
+			So(salted.HashFields(), ShouldEqual, 2)
+			So(netntlm.HashFields(), ShouldEqual, 6)
+		})
+	})
+
+	Convey("Given a list with invalid lines", t, func() {
+		f, _ := Lookup(1000)
+		list := "b4b9b02e6f09a9bd760f388b67351e2b\r\n\nnot-a-hash\n8846f7eaee8fb117ad06bdd830b7586c\n"
+		var valid bytes.Buffer
+		result, err := f.ValidateReader(strings.NewReader(list), false, nil, &valid)
+
+		Convey("Invalid lines are reported with their line number and stripped", func() {
+			So(err, ShouldBeNil)
+			So(result.Lines, ShouldEqual, 3)
+			So(result.Valid, ShouldEqual, 2)
+			So(result.Errors[0].Line, ShouldEqual, 3)
+			So(valid.String(), ShouldEqual, "b4b9b02e6f09a9bd760f388b67351e2b\n8846f7eaee8fb117ad06bdd830b7586c\n")
+		})
+	})
+}
+
+func TestIdentify(t *testing.T) {
+	Convey("Given samples of NTLM hashes", t, func() {
+		candidates := Identify([]string{"b4b9b02e6f09a9bd760f388b67351e2b", "8846f7eaee8fb117ad06bdd830b7586c"})
+
+		Convey("Every raw 32 character format is a candidate with NTLM first", func() {
+			So(len(candidates), ShouldBeGreaterThan, 1)
+			So(candidates[0].Mode, ShouldEqual, 1000)
+			So(candidates[0].Score(), ShouldEqual, 1)
+		})
+	})
+
+	Convey("Given samples prefixed with a filename", t, func() {
+		candidates := Identify([]string{"doc2.doc:$oldoffice$0*57428444884348332005656085237182*41f57686549e87e3bf46233766e8fb52*8938d3649960ee34fe340ccbb49f4b6f"})
+
+		Convey("The format is found after removing the filename", func() {
+			So(candidates[0].Mode, ShouldEqual, 9700)
+			So(candidates[0].Prefixed, ShouldBeTrue)
+		})
+	})
+}
+
+func TestNormalize(t *testing.T) {
+	Convey("Given a dump of NTLM hashes with usernames", t, func() {
+		f, _ := Lookup(1000)
+		users := make(map[string]string)
+		n := &Normalizer{Format: f, Username: func(user, hash string) { users[user] = hash }}
+
+		Convey("Usernames are removed, hex is lower cased, and whitespace is trimmed", func() {
+			So(n.Normalize("Administrator:B4B9B02E6F09A9BD760F388B67351E2B "), ShouldEqual, "b4b9b02e6f09a9bd760f388b67351e2b")
+			So(users["Administrator"], ShouldEqual, "b4b9b02e6f09a9bd760f388b67351e2b")
+			So(n.Stats, ShouldResemble, NormalizeStats{Trimmed: 1, Lowercased: 1, Usernames: 1})
+		})
+	})
+
+	Convey("Given usernames for a format that also matches user:hash", t, func() {
+		f, _ := Lookup(10)
+		users := make(map[string]string)
+		n := &Normalizer{Format: f, Username: func(user, hash string) { users[user] = hash }}
+
+		Convey("The username is kept unless SplitUsernames is set", func() {
+			So(n.Normalize("alice:5f4dcc3b5aa765d61d8327deb882cf99"), ShouldEqual, "alice:5f4dcc3b5aa765d61d8327deb882cf99")
+			n.SplitUsernames = true
+			So(n.Normalize("alice:5f4dcc3b5aa765d61d8327deb882cf99:salt"), ShouldEqual, "5f4dcc3b5aa765d61d8327deb882cf99:salt")
+			So(users["alice"], ShouldEqual, "5f4dcc3b5aa765d61d8327deb882cf99:salt")
+		})
+	})
+
+	Convey("Given a salted format", t, func() {
+		f, _ := Lookup(10)
+		n := &Normalizer{Format: f}
+
+		Convey("The salt keeps its case", func() {
+			So(n.Normalize("5F4DCC3B5AA765D61D8327DEB882CF99:SaLt"), ShouldEqual, "5f4dcc3b5aa765d61d8327deb882cf99:SaLt")
+		})
+	})
+
+	Convey("Given a format that is case sensitive", t, func() {
+		f, _ := Lookup(3200)
+		n := &Normalizer{Format: f}
+		hash := "$2a$05$LhayLxezLhK1LhWvKxCyLOj0j1u.Kj0jZ0pEmm134uzrQlFvQJLF6"
+
+		Convey("The hash is not changed", func() {
+			So(n.Normalize(hash), ShouldEqual, hash)
+		})
+	})
+}
@@ -0,0 +1,106 @@
+package hashfmt
+
+import (
+	"bufio"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Candidate is a format that matched one or more of the samples given to Identify.
+type Candidate struct {
+	Format
+	// Matched is the number of samples that are valid for the format.
+	Matched int
+	// Samples is the number of samples that were checked.
+	Samples int
+	// Prefixed is true when the samples only matched after removing a leading
+	// name: field, e.g. a filename or username.
+	Prefixed bool
+}
+
+// Score is the fraction of samples that matched the format.
+func (c Candidate) Score() float64 {
+	if c.Samples == 0 {
+		return 0
+	}
+	return float64(c.Matched) / float64(c.Samples)
+}
+
+// specific is true for formats with a fixed structure, which are less likely
+// to match by accident than a raw hex digest.
+func (f Format) specific() bool {
+	return f.Pattern != nil && f.Mode != 3000 && f.Mode != 1500 && f.Mode != 5700
+}
+
+// Sample returns up to n non-empty lines from the start of r.
+func Sample(r io.Reader, n int) ([]string, error) {
+	var samples []string
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for len(samples) < n && scanner.Scan() {
+		if line := strings.TrimRight(scanner.Text(), "\r"); line != "" {
+			samples = append(samples, line)
+		}
+	}
+	return samples, scanner.Err()
+}
+
+// Identify checks samples against every known format and returns the formats
+// that matched at least one sample, best match first. Candidates that match
+// more samples rank higher, followed by formats with a fixed structure and
+// then the most common modes.
+func Identify(samples []string) []Candidate {
+	var candidates []Candidate
+	for _, f := range formats {
+		c := Candidate{Format: f, Samples: len(samples)}
+		for _, s := range samples {
+			if f.Validate(s, false) == nil {
+				c.Matched++
+			}
+		}
+		if c.Matched == 0 {
+			c.Prefixed = true
+			for _, s := range samples {
+				parts := strings.SplitN(s, ":", 2)
+				if len(parts) == 2 && f.Validate(parts[1], false) == nil {
+					c.Matched++
+				}
+			}
+		}
+		if c.Matched > 0 {
+			candidates = append(candidates, c)
+		}
+	}
+	rank := make(map[int]int)
+	for i, mode := range common {
+		rank[mode] = i + 1
+	}
+	commonRank := func(mode int) int {
+		if r, ok := rank[mode]; ok {
+			return r
+		}
+		return len(common) + 1
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		switch {
+		case a.Matched != b.Matched:
+			return a.Matched > b.Matched
+		case a.Prefixed != b.Prefixed:
+			return !a.Prefixed
+		case a.specific() != b.specific():
+			return a.specific()
+		case commonRank(a.Mode) != commonRank(b.Mode):
+			return commonRank(a.Mode) < commonRank(b.Mode)
+		}
+		return a.Mode < b.Mode
+	})
+	return candidates
+}
+
+// Equivalent is true when c and o matched the samples equally well, so the
+// samples alone can not tell them apart.
+func (c Candidate) Equivalent(o Candidate) bool {
+	return c.Matched == o.Matched && c.Prefixed == o.Prefixed && c.specific() == o.specific()
+}
@@ -0,0 +1,67 @@
+package hashfmt
+
+import "regexp"
+
+// hexChars and cryptChars are the character classes used in the patterns below.
+const (
+	hexChars   = `[0-9a-fA-F]`
+	cryptChars = `[./0-9A-Za-z]`
+)
+
+var formats = map[int]Format{}
+
+// common lists modes that Identify ranks ahead of other modes that match the
+// same samples, most common first.
+var common = []int{1000, 0, 100, 1400, 1700, 3000, 300, 5600, 5500, 13100, 18200, 2100, 1100}
+
+func add(f Format) {
+	formats[f.Mode] = f
+}
+
+func init() {
+	add(Format{Mode: 0, Name: "MD5", Length: 32})
+	add(Format{Mode: 10, Name: "md5($pass.$salt)", Length: 32, Salted: true})
+	add(Format{Mode: 12, Name: "PostgreSQL", Length: 32, Salted: true})
+	add(Format{Mode: 20, Name: "md5($salt.$pass)", Length: 32, Salted: true})
+	add(Format{Mode: 100, Name: "SHA1", Length: 40})
+	add(Format{Mode: 110, Name: "sha1($pass.$salt)", Length: 40, Salted: true})
+	add(Format{Mode: 120, Name: "sha1($salt.$pass)", Length: 40, Salted: true})
+	add(Format{Mode: 132, Name: "MSSQL (2005)", Prefix: "0x0100", Pattern: regexp.MustCompile(`^0x0100` + hexChars + `{48}$`)})
+	add(Format{Mode: 300, Name: "MySQL4.1/MySQL5", Length: 40})
+	add(Format{Mode: 400, Name: "phpass", Pattern: regexp.MustCompile(`^\$[PH]\$` + cryptChars + `{31}$`)})
+	add(Format{Mode: 500, Name: "md5crypt", Prefix: "$1$", Pattern: regexp.MustCompile(`^\$1\$[^$]{0,8}\$` + cryptChars + `{22}$`)})
+	add(Format{Mode: 900, Name: "MD4", Length: 32})
+	add(Format{Mode: 1000, Name: "NTLM", Length: 32})
+	add(Format{Mode: 1100, Name: "Domain Cached Credentials (DCC)", Length: 32, Salted: true})
+	add(Format{Mode: 1300, Name: "SHA2-224", Length: 56})
+	add(Format{Mode: 1400, Name: "SHA2-256", Length: 64})
+	add(Format{Mode: 1410, Name: "sha256($pass.$salt)", Length: 64, Salted: true})
+	add(Format{Mode: 1420, Name: "sha256($salt.$pass)", Length: 64, Salted: true})
+	add(Format{Mode: 1500, Name: "descrypt", Pattern: regexp.MustCompile(`^` + cryptChars + `{13}$`)})
+	add(Format{Mode: 1700, Name: "SHA2-512", Length: 128})
+	add(Format{Mode: 1710, Name: "sha512($pass.$salt)", Length: 128, Salted: true})
+	add(Format{Mode: 1720, Name: "sha512($salt.$pass)", Length: 128, Salted: true})
+	add(Format{Mode: 1731, Name: "MSSQL (2012, 2014)", Prefix: "0x0200", Pattern: regexp.MustCompile(`^0x0200` + hexChars + `{136}$`)})
+	add(Format{Mode: 1800, Name: "sha512crypt", Prefix: "$6$", Pattern: regexp.MustCompile(`^\$6\$(rounds=\d+\$)?[^$]{0,16}\$` + cryptChars + `{86}$`)})
+	add(Format{Mode: 2100, Name: "Domain Cached Credentials 2 (DCC2)", Prefix: "$DCC2$", Pattern: regexp.MustCompile(`^\$DCC2\$\d+#[^#]+#` + hexChars + `{32}$`)})
+	add(Format{Mode: 3000, Name: "LM", Pattern: regexp.MustCompile(`^(` + hexChars + `{16}|` + hexChars + `{32})$`)})
+	add(Format{Mode: 3200, Name: "bcrypt", Pattern: regexp.MustCompile(`^\$2[abxy]?\$\d{2}\$` + cryptChars + `{53}$`)})
+	add(Format{Mode: 5500, Name: "NetNTLMv1", Pattern: regexp.MustCompile(`^[^:]+::[^:]*:` + hexChars + `{48}:` + hexChars + `{48}:` + hexChars + `{16}$`), Fields: 6})
+	add(Format{Mode: 5600, Name: "NetNTLMv2", Pattern: regexp.MustCompile(`^[^:]+::[^:]*:` + hexChars + `{16}:` + hexChars + `{32}:` + hexChars + `+$`), Fields: 6})
+	add(Format{Mode: 5700, Name: "Cisco-IOS type 4", Pattern: regexp.MustCompile(`^` + cryptChars + `{43}$`)})
+	add(Format{Mode: 6000, Name: "RIPEMD-160", Length: 40})
+	add(Format{Mode: 7400, Name: "sha256crypt", Prefix: "$5$", Pattern: regexp.MustCompile(`^\$5\$(rounds=\d+\$)?[^$]{0,16}\$` + cryptChars + `{43}$`)})
+	add(Format{Mode: 9200, Name: "Cisco-IOS type 8", Prefix: "$8$", Pattern: regexp.MustCompile(`^\$8\$` + cryptChars + `{14}\$` + cryptChars + `{43}$`)})
+	add(Format{Mode: 9300, Name: "Cisco-IOS type 9", Prefix: "$9$", Pattern: regexp.MustCompile(`^\$9\$` + cryptChars + `{14}\$` + cryptChars + `{43}$`)})
+	add(Format{Mode: 9400, Name: "MS Office 2007", Prefix: "$office$*2007*", Pattern: regexp.MustCompile(`^\$office\$\*2007\*20\*128\*16\*` + hexChars + `{32}\*` + hexChars + `{32}\*` + hexChars + `{40}$`)})
+	add(Format{Mode: 9500, Name: "MS Office 2010", Prefix: "$office$*2010*", Pattern: regexp.MustCompile(`^\$office\$\*2010\*\d+\*128\*16\*` + hexChars + `{32}\*` + hexChars + `{32}\*` + hexChars + `{64}$`)})
+	add(Format{Mode: 9600, Name: "MS Office 2013", Prefix: "$office$*2013*", Pattern: regexp.MustCompile(`^\$office\$\*2013\*\d+\*256\*16\*` + hexChars + `{32}\*` + hexChars + `{32}\*` + hexChars + `{64}$`)})
+	add(Format{Mode: 9700, Name: "MS Office <= 2003 $0/$1, MD5 + RC4", Pattern: regexp.MustCompile(`^\$oldoffice\$[01]\*` + hexChars + `{32}\*` + hexChars + `{32}\*` + hexChars + `{32}$`)})
+	add(Format{Mode: 9800, Name: "MS Office <= 2003 $3/$4, SHA1 + RC4", Pattern: regexp.MustCompile(`^\$oldoffice\$[34]\*` + hexChars + `{32}\*` + hexChars + `{32}\*` + hexChars + `{40}$`)})
+	add(Format{Mode: 10000, Name: "Django (PBKDF2-SHA256)", Prefix: "pbkdf2_sha256$", Pattern: regexp.MustCompile(`^pbkdf2_sha256\$\d+\$[^$]+\$[A-Za-z0-9+/]{43}=$`)})
+	add(Format{Mode: 10800, Name: "SHA2-384", Length: 96})
+	add(Format{Mode: 13100, Name: "Kerberos 5 TGS-REP etype 23", Prefix: "$krb5tgs$23$", Pattern: regexp.MustCompile(`^\$krb5tgs\$23\$(\*[^*]*\*\$)?` + hexChars + `{32}\$` + hexChars + `+$`)})
+	add(Format{Mode: 17400, Name: "SHA3-256", Length: 64})
+	add(Format{Mode: 18200, Name: "Kerberos 5 AS-REP etype 23", Prefix: "$krb5asrep$23$", Pattern: regexp.MustCompile(`^\$krb5asrep\$23\$[^:$]+:` + hexChars + `{32}\$` + hexChars + `+$`), Fields: 2})
+	add(Format{Mode: 22000, Name: "WPA-PBKDF2-PMKID+EAPOL", Pattern: regexp.MustCompile(`^WPA\*0[12]\*` + hexChars + `{32}\*` + hexChars + `{12}\*` + hexChars + `{12}\*` + hexChars + `*\*` + hexChars + `*\*` + hexChars + `*\*` + hexChars + `*$`)})
+}
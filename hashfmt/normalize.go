@@ -0,0 +1,70 @@
+package hashfmt
+
+import "strings"
+
+// NormalizeStats counts the changes made by a Normalizer.
+type NormalizeStats struct {
+	Trimmed    int `json:"trimmed"`
+	Lowercased int `json:"lowercased"`
+	Usernames  int `json:"usernames"`
+}
+
+// Normalizer cleans up lines from a hash dump before they are validated.
+type Normalizer struct {
+	Format  Format
+	HexSalt bool
+	// Username, when set, is called for every user:hash line. The username is
+	// removed from the line and the hash is validated on its own.
+	Username func(user, hash string)
+	// SplitUsernames treats the first field of every line as a username, even
+	// when the whole line is also a valid hash.
+	SplitUsernames bool
+	Stats          NormalizeStats
+}
+
+// Normalize removes surrounding whitespace from line, removes a leading
+// username when the rest of the line is a valid hash, and lower cases hex
+// digests for formats where case does not matter.
+func (n *Normalizer) Normalize(line string) string {
+	if trimmed := strings.TrimSpace(line); trimmed != line {
+		n.Stats.Trimmed++
+		line = trimmed
+	}
+	if n.Username != nil {
+		parts := strings.SplitN(line, ":", 2)
+		if len(parts) == 2 && parts[0] != "" && (n.SplitUsernames || n.Format.Validate(line, n.HexSalt) != nil && n.Format.Validate(parts[1], n.HexSalt) == nil) {
+			n.Stats.Usernames++
+			line = parts[1]
+			n.Username(parts[0], n.lower(line))
+		}
+	}
+	if lowered := n.lower(line); lowered != line {
+		n.Stats.Lowercased++
+		line = lowered
+	}
+	return line
+}
+
+// lower returns line with its hex digest, and its salt when --hex-salt is
+// used, in lower case. Only formats with a raw hex digest are changed.
+func (n *Normalizer) lower(line string) string {
+	if n.Format.Pattern != nil || n.Format.Length == 0 {
+		return line
+	}
+	digest, salt := line, ""
+	if n.Format.Salted {
+		parts := strings.SplitN(line, ":", 2)
+		if len(parts) != 2 {
+			return line
+		}
+		digest, salt = parts[0], parts[1]
+		if n.HexSalt && isHex(salt) {
+			salt = strings.ToLower(salt)
+		}
+		salt = ":" + salt
+	}
+	if !isHex(digest) {
+		return line
+	}
+	return strings.ToLower(digest) + salt
+}
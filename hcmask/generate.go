@@ -0,0 +1,193 @@
+package hcmask
+
+import (
+	"math/big"
+	"sort"
+	"strings"
+)
+
+// classes are the charsets used by generated masks along with the class name
+// used by a Policy.
+var classes = []struct {
+	name  string
+	token string
+}{
+	{"lower", "?l"},
+	{"upper", "?u"},
+	{"digit", "?d"},
+	{"special", "?s"},
+}
+
+// ClassNames returns the names of the classes a Policy can require.
+func ClassNames() []string {
+	var names []string
+	for _, c := range classes {
+		names = append(names, c.name)
+	}
+	return names
+}
+
+// Policy is a password policy masks are generated for. A zero MaxLength has
+// no upper limit, which is only valid when ranking masks from a corpus.
+type Policy struct {
+	MinLength int
+	MaxLength int
+	// Required are the class names, e.g. lower and digit, every password
+	// must contain.
+	Required []string
+}
+
+// allows reports whether a mask made of tokens meets the policy.
+func (p Policy) allows(tokens []string) bool {
+	if len(tokens) < p.MinLength || (p.MaxLength > 0 && len(tokens) > p.MaxLength) {
+		return false
+	}
+	for _, name := range p.Required {
+		found := false
+		for _, c := range classes {
+			if c.name != name {
+				continue
+			}
+			for _, t := range tokens {
+				if t == c.token {
+					found = true
+				}
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// Candidate is a generated mask. Hits is the number of passwords in the
+// corpus it matches, which is zero for masks generated from a policy.
+type Candidate struct {
+	Line     Line
+	Hits     int
+	Keyspace *big.Int
+}
+
+// FromPolicy returns up to max masks, smallest keyspace first, that only
+// generate passwords allowed by p. Masks are made of ?l, ?u, ?d, and ?s.
+func FromPolicy(p Policy, max int) []Candidate {
+	type composition struct {
+		counts   [4]int
+		keyspace *big.Int
+	}
+	var comps []composition
+	for length := p.MinLength; length <= p.MaxLength; length++ {
+		var counts [4]int
+		var walk func(i, left int)
+		walk = func(i, left int) {
+			if i == len(counts)-1 {
+				counts[i] = left
+				var tokens []string
+				keyspace := big.NewInt(1)
+				for j, n := range counts {
+					for k := 0; k < n; k++ {
+						tokens = append(tokens, classes[j].token)
+					}
+					size := new(big.Int).Exp(big.NewInt(builtin[classes[j].token[1]]), big.NewInt(int64(n)), nil)
+					keyspace.Mul(keyspace, size)
+				}
+				if p.allows(tokens) {
+					comps = append(comps, composition{counts: counts, keyspace: keyspace})
+				}
+				return
+			}
+			for n := 0; n <= left; n++ {
+				counts[i] = n
+				walk(i+1, left-n)
+			}
+		}
+		if length > 0 {
+			walk(0, length)
+		}
+	}
+	sort.SliceStable(comps, func(i, j int) bool {
+		return comps[i].keyspace.Cmp(comps[j].keyspace) < 0
+	})
+
+	var candidates []Candidate
+	for _, comp := range comps {
+		var tokens []int
+		for j, n := range comp.counts {
+			for k := 0; k < n; k++ {
+				tokens = append(tokens, j)
+			}
+		}
+		for {
+			if max > 0 && len(candidates) == max {
+				return candidates
+			}
+			var b strings.Builder
+			for _, t := range tokens {
+				b.WriteString(classes[t].token)
+			}
+			candidates = append(candidates, Candidate{Line: Line{Mask: b.String()}, Keyspace: comp.keyspace})
+			if !nextPermutation(tokens) {
+				break
+			}
+		}
+	}
+	return candidates
+}
+
+// nextPermutation rearranges s into its next lexicographic permutation and
+// reports whether there was one.
+func nextPermutation(s []int) bool {
+	i := len(s) - 2
+	for i >= 0 && s[i] >= s[i+1] {
+		i--
+	}
+	if i < 0 {
+		return false
+	}
+	j := len(s) - 1
+	for s[j] <= s[i] {
+		j--
+	}
+	s[i], s[j] = s[j], s[i]
+	for l, r := i+1, len(s)-1; l < r; l, r = l+1, r-1 {
+		s[l], s[r] = s[r], s[l]
+	}
+	return true
+}
+
+// FromCorpus ranks the masks of the passwords in a corpus, given as the
+// number of passwords matching each mask, by hits per candidate so that the
+// masks most likely to crack a password are tried first. Masks not allowed
+// by p are skipped. Up to max masks are returned when max is not zero.
+func FromCorpus(masks map[string]int, p Policy, max int) []Candidate {
+	var candidates []Candidate
+	for mask, hits := range masks {
+		var tokens []string
+		for i := 0; i+1 < len(mask); i += 2 {
+			tokens = append(tokens, mask[i:i+2])
+		}
+		if !p.allows(tokens) {
+			continue
+		}
+		l := Line{Mask: mask}
+		keyspace, err := l.Keyspace(false)
+		if err != nil {
+			continue
+		}
+		candidates = append(candidates, Candidate{Line: l, Hits: hits, Keyspace: keyspace})
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		// hits_i / keyspace_i > hits_j / keyspace_j
+		a := new(big.Int).Mul(big.NewInt(int64(candidates[i].Hits)), candidates[j].Keyspace)
+		b := new(big.Int).Mul(big.NewInt(int64(candidates[j].Hits)), candidates[i].Keyspace)
+		if c := a.Cmp(b); c != 0 {
+			return c > 0
+		}
+		return candidates[i].Line.Mask < candidates[j].Line.Mask
+	})
+	if max > 0 && len(candidates) > max {
+		candidates = candidates[:max]
+	}
+	return candidates
+}
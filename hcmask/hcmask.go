@@ -0,0 +1,225 @@
+// Package hcmask reads, writes, and generates hashcat .hcmask files. Each line
+// of an .hcmask file is a mask preceded by up to four custom charsets, e.g.
+// ?l?d,?u,?1?1?1?2.
+package hcmask
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math/big"
+	"strings"
+)
+
+// Line is a single mask and the custom charsets it uses.
+type Line struct {
+	Charsets [4]string
+	Mask     string
+}
+
+// builtin is the size of each built-in hashcat charset.
+var builtin = map[byte]int64{
+	'l': 26,
+	'u': 26,
+	'd': 10,
+	'h': 16,
+	'H': 16,
+	's': 33,
+	'a': 95,
+	'b': 256,
+}
+
+// Parse reads the lines of an .hcmask file. Empty lines and comments starting
+// with # are skipped. Use Keyspace to check that a mask is valid.
+func Parse(r io.Reader) ([]Line, error) {
+	var lines []Line
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		text := strings.TrimRight(scanner.Text(), "\r")
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		fields := split(text)
+		if len(fields) > 5 {
+			return nil, fmt.Errorf("Line %d: more than four custom charsets", n)
+		}
+		var l Line
+		copy(l.Charsets[:], fields[:len(fields)-1])
+		l.Mask = fields[len(fields)-1]
+		if l.Mask == "" {
+			return nil, fmt.Errorf("Line %d: missing the mask", n)
+		}
+		lines = append(lines, l)
+	}
+	return lines, scanner.Err()
+}
+
+// split splits an .hcmask line on commas that are not escaped with \.
+func split(text string) []string {
+	var (
+		fields []string
+		b      strings.Builder
+	)
+	for i := 0; i < len(text); i++ {
+		switch {
+		case text[i] == '\\' && i+1 < len(text) && text[i+1] == ',':
+			b.WriteByte(',')
+			i++
+		case text[i] == ',':
+			fields = append(fields, b.String())
+			b.Reset()
+		default:
+			b.WriteByte(text[i])
+		}
+	}
+	return append(fields, b.String())
+}
+
+// String returns l as a line of an .hcmask file.
+func (l Line) String() string {
+	n := 0
+	for i, c := range l.Charsets {
+		if c != "" {
+			n = i + 1
+		}
+	}
+	var fields []string
+	for _, c := range l.Charsets[:n] {
+		fields = append(fields, strings.Replace(c, ",", `\,`, -1))
+	}
+	return strings.Join(append(fields, strings.Replace(l.Mask, ",", `\,`, -1)), ",")
+}
+
+// Write writes lines as an .hcmask file.
+func Write(w io.Writer, lines []Line) error {
+	for _, l := range lines {
+		if _, err := fmt.Fprintln(w, l.String()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Keyspace returns the number of candidates generated by the mask. When
+// hexCharset is true the custom charsets are hex encoded.
+func (l Line) Keyspace(hexCharset bool) (*big.Int, error) {
+	return l.MarkovKeyspace(hexCharset, 0)
+}
+
+// MarkovKeyspace returns the number of candidates generated by the mask when
+// each position is limited to the threshold most likely characters, as done
+// by hashcat with --markov-threshold. A zero threshold is not limited.
+func (l Line) MarkovKeyspace(hexCharset bool, threshold int) (*big.Int, error) {
+	var sizes [4]int64
+	for i, c := range l.Charsets {
+		if c == "" {
+			continue
+		}
+		set, err := expand(c, hexCharset)
+		if err != nil {
+			return nil, fmt.Errorf("custom charset %d: %s", i+1, err.Error())
+		}
+		sizes[i] = int64(len(set))
+	}
+	keyspace := big.NewInt(1)
+	for i := 0; i < len(l.Mask); i++ {
+		size := int64(1)
+		if l.Mask[i] == '?' {
+			if i+1 == len(l.Mask) {
+				return nil, fmt.Errorf("the mask ends with ?")
+			}
+			i++
+			switch c := l.Mask[i]; {
+			case c == '?':
+			case c >= '1' && c <= '4':
+				if size = sizes[c-'1']; size == 0 {
+					return nil, fmt.Errorf("?%c is used but custom charset %c is not set", c, c)
+				}
+			default:
+				var ok bool
+				if size, ok = builtin[c]; !ok {
+					return nil, fmt.Errorf("?%c is not a valid charset", c)
+				}
+			}
+		}
+		if threshold > 0 && size > int64(threshold) {
+			size = int64(threshold)
+		}
+		keyspace.Mul(keyspace, big.NewInt(size))
+	}
+	return keyspace, nil
+}
+
+// expand returns the unique bytes in a custom charset.
+func expand(charset string, hexCharset bool) (map[byte]bool, error) {
+	set := make(map[byte]bool)
+	if hexCharset {
+		if len(charset)%2 != 0 {
+			return nil, fmt.Errorf("the charset is not valid hex")
+		}
+		for i := 0; i < len(charset); i += 2 {
+			var b byte
+			if _, err := fmt.Sscanf(charset[i:i+2], "%02x", &b); err != nil {
+				return nil, fmt.Errorf("the charset is not valid hex")
+			}
+			set[b] = true
+		}
+		return set, nil
+	}
+	for i := 0; i < len(charset); i++ {
+		if charset[i] != '?' || i+1 == len(charset) {
+			set[charset[i]] = true
+			continue
+		}
+		i++
+		bytes, err := builtinBytes(charset[i])
+		if err != nil {
+			return nil, err
+		}
+		for _, b := range bytes {
+			set[b] = true
+		}
+	}
+	return set, nil
+}
+
+// builtinBytes returns the bytes in a built-in charset. ? is the literal ?.
+func builtinBytes(c byte) ([]byte, error) {
+	var bytes []byte
+	add := func(from, to byte) {
+		for b := from; ; b++ {
+			bytes = append(bytes, b)
+			if b == to {
+				break
+			}
+		}
+	}
+	switch c {
+	case '?':
+		add('?', '?')
+	case 'l':
+		add('a', 'z')
+	case 'u':
+		add('A', 'Z')
+	case 'd':
+		add('0', '9')
+	case 'h':
+		add('0', '9')
+		add('a', 'f')
+	case 'H':
+		add('0', '9')
+		add('A', 'F')
+	case 's':
+		add(' ', '/')
+		add(':', '@')
+		add('[', '`')
+		add('{', '~')
+	case 'a':
+		add(' ', '~')
+	case 'b':
+		add(0, 255)
+	default:
+		return nil, fmt.Errorf("?%c is not a valid charset", c)
+	}
+	return bytes, nil
+}
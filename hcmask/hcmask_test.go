@@ -0,0 +1,69 @@
+package hcmask
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestParse(t *testing.T) {
+	Convey("Given an .hcmask file with custom charsets and comments", t, func() {
+		lines, err := Parse(strings.NewReader("# comment\n?l?d,?u,?1?1?2\n\n\\,.,?1?d\r\n?u?l?l\n"))
+		So(err, ShouldBeNil)
+
+		Convey("Each mask is read with its charsets", func() {
+			So(lines, ShouldResemble, []Line{
+				{Charsets: [4]string{"?l?d", "?u"}, Mask: "?1?1?2"},
+				{Charsets: [4]string{",."}, Mask: "?1?d"},
+				{Mask: "?u?l?l"},
+			})
+		})
+
+		Convey("The lines are written back unchanged", func() {
+			var b bytes.Buffer
+			So(Write(&b, lines), ShouldBeNil)
+			So(b.String(), ShouldEqual, "?l?d,?u,?1?1?2\n\\,.,?1?d\n?u?l?l\n")
+		})
+
+		Convey("The keyspace counts the unique characters in custom charsets", func() {
+			k, err := lines[0].Keyspace(false)
+			So(err, ShouldBeNil)
+			So(k.Int64(), ShouldEqual, 36*36*26)
+			k, err = Line{Charsets: [4]string{"616161"}, Mask: "?1?1"}.Keyspace(true)
+			So(err, ShouldBeNil)
+			So(k.Int64(), ShouldEqual, 1)
+		})
+
+		Convey("Undefined charsets are an error", func() {
+			_, err := Line{Mask: "?l?3"}.Keyspace(false)
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
+
+func TestGenerate(t *testing.T) {
+	Convey("Given a policy requiring a digit", t, func() {
+		p := Policy{MinLength: 2, MaxLength: 2, Required: []string{"digit"}}
+		masks := FromPolicy(p, 0)
+
+		Convey("Every mask meets the policy, smallest keyspace first", func() {
+			So(len(masks), ShouldEqual, 7)
+			So(masks[0].Line.Mask, ShouldEqual, "?d?d")
+			for _, m := range masks {
+				So(m.Line.Mask, ShouldContainSubstring, "?d")
+			}
+		})
+	})
+
+	Convey("Given masks from a corpus", t, func() {
+		masks := FromCorpus(map[string]int{"?l?l?l?l": 10, "?d?d": 1, "?u?l?l?l?d": 20}, Policy{MinLength: 3}, 0)
+
+		Convey("Masks are ranked by hits per candidate", func() {
+			So(len(masks), ShouldEqual, 2)
+			So(masks[0].Line.Mask, ShouldEqual, "?l?l?l?l")
+			So(masks[1].Hits, ShouldEqual, 20)
+		})
+	})
+}
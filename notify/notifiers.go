@@ -0,0 +1,133 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/smtp"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Desktop shows events as desktop notifications using notify-send on Linux,
+// osascript on macOS, and PowerShell on Windows.
+type Desktop struct{}
+
+// Notify shows e as a desktop notification.
+func (Desktop) Notify(e Event) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("osascript", "-e", fmt.Sprintf("display notification %s with title %s", strconv.Quote(e.Message), strconv.Quote(e.Title())))
+	case "windows":
+		script := `[void][Reflection.Assembly]::LoadWithPartialName('System.Windows.Forms');` +
+			`$n = New-Object System.Windows.Forms.NotifyIcon;` +
+			`$n.Icon = [System.Drawing.SystemIcons]::Information;` +
+			`$n.Visible = $true;` +
+			`$n.ShowBalloonTip(10000, $env:HASHSTACK_TITLE, $env:HASHSTACK_MESSAGE, 'Info');` +
+			`Start-Sleep -Seconds 10; $n.Dispose()`
+		cmd = exec.Command("powershell", "-NoProfile", "-Command", script)
+		cmd.Env = append(os.Environ(), "HASHSTACK_TITLE="+e.Title(), "HASHSTACK_MESSAGE="+e.Message)
+	default:
+		cmd = exec.Command("notify-send", e.Title(), e.Message)
+	}
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%s: %s", err.Error(), strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// Command runs a shell command for each event. The event is written to the
+// command's stdin as JSON and its fields are set as HASHSTACK_ environment
+// variables.
+type Command struct {
+	Command string
+}
+
+// Notify runs the command for e.
+func (c Command) Notify(e Event) error {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", "/C", c.Command)
+	} else {
+		cmd = exec.Command("sh", "-c", c.Command)
+	}
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	cmd.Stdin = bytes.NewReader(data)
+	cmd.Env = append(os.Environ(),
+		"HASHSTACK_EVENT="+e.Kind,
+		"HASHSTACK_PROJECT="+e.Project,
+		"HASHSTACK_PROJECT_ID="+strconv.FormatInt(e.ProjectID, 10),
+		"HASHSTACK_JOB="+e.Job,
+		"HASHSTACK_JOB_ID="+strconv.FormatInt(e.JobID, 10),
+		"HASHSTACK_LIST="+e.List,
+		"HASHSTACK_CRACKED="+strconv.FormatInt(e.Cracked, 10),
+		"HASHSTACK_DIGESTS="+strconv.FormatInt(e.Digests, 10),
+		"HASHSTACK_MESSAGE="+e.Message,
+	)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%s: %s", err.Error(), strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// Webhook POSTs each event to a URL as JSON.
+type Webhook struct {
+	URL     string
+	Headers map[string]string
+	Timeout time.Duration
+}
+
+// Notify posts e to the webhook.
+func (w Webhook) Notify(e Event) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest("POST", w.URL, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range w.Headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := (&http.Client{Timeout: w.Timeout}).Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("the webhook returned %s", resp.Status)
+	}
+	return nil
+}
+
+// SMTP emails each event.
+type SMTP struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+	To       []string
+}
+
+// Notify emails e.
+func (s SMTP) Notify(e Event) error {
+	var auth smtp.Auth
+	if s.Username != "" {
+		auth = smtp.PlainAuth("", s.Username, s.Password, s.Host)
+	}
+	msg := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: %s\r\nDate: %s\r\nContent-Type: text/plain; charset=utf-8\r\n\r\n%s\r\n",
+		s.From, strings.Join(s.To, ", "), e.Title(), time.Unix(e.Time, 0).Format(time.RFC1123Z), e.Message)
+	return smtp.SendMail(fmt.Sprintf("%s:%d", s.Host, s.Port), auth, s.From, s.To, []byte(msg))
+}
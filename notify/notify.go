@@ -0,0 +1,153 @@
+// Package notify sends notifications about job events to the desktop, a shell
+// command, a webhook, or an email address.
+package notify
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// Event kinds.
+const (
+	FirstCrack     = "first_crack"
+	CrackedPercent = "cracked_percent"
+	JobExhausted   = "job_exhausted"
+	JobPaused      = "job_paused"
+	AgentError     = "agent_error"
+	Test           = "test"
+)
+
+// Kinds are the event kinds that can be watched for.
+var Kinds = []string{FirstCrack, CrackedPercent, JobExhausted, JobPaused, AgentError}
+
+// Event is something that happened to a job.
+type Event struct {
+	Kind      string  `json:"kind"`
+	Time      int64   `json:"time"`
+	ProjectID int64   `json:"project_id"`
+	Project   string  `json:"project"`
+	JobID     int64   `json:"job_id"`
+	Job       string  `json:"job"`
+	List      string  `json:"list"`
+	Cracked   int64   `json:"cracked"`
+	Digests   int64   `json:"digests"`
+	Percent   float64 `json:"percent"`
+	Message   string  `json:"message"`
+}
+
+// Title returns a short summary of the event for use as a subject line.
+func (e Event) Title() string {
+	if e.Kind == Test {
+		return "Hashstack: test notification"
+	}
+	return fmt.Sprintf("Hashstack: %s (%s)", e.Job, strings.Replace(e.Kind, "_", " ", -1))
+}
+
+// Notifier delivers events.
+type Notifier interface {
+	Notify(e Event) error
+}
+
+// Config is a notifier as written in the [[watch.notifiers]] section of the
+// configuration file.
+type Config struct {
+	// Type is desktop, command, webhook, or smtp.
+	Type string `toml:"type"`
+	// Events limits the notifier to these event kinds. All events are sent
+	// when it is empty.
+	Events []string `toml:"events,omitempty"`
+
+	// Command is run by the shell for command notifiers.
+	Command string `toml:"command,omitempty"`
+
+	// URL and Headers are used by webhook notifiers.
+	URL     string            `toml:"url,omitempty"`
+	Headers map[string]string `toml:"headers,omitempty"`
+
+	// Host, Port, Username, PasswordEnv, From, and To are used by smtp
+	// notifiers. PasswordEnv is the name of the environment variable that
+	// holds the password, so that it is not saved in the configuration file.
+	Host        string   `toml:"host,omitempty"`
+	Port        int      `toml:"port,omitempty"`
+	Username    string   `toml:"username,omitempty"`
+	PasswordEnv string   `toml:"password_env,omitempty"`
+	From        string   `toml:"from,omitempty"`
+	To          []string `toml:"to,omitempty"`
+}
+
+// New returns the notifier described by c.
+func New(c Config) (Notifier, error) {
+	for _, kind := range c.Events {
+		if !validKind(kind) {
+			return nil, fmt.Errorf("%s is not an event, use %s", kind, strings.Join(Kinds, ", "))
+		}
+	}
+	var n Notifier
+	switch c.Type {
+	case "desktop":
+		n = Desktop{}
+	case "command":
+		if c.Command == "" {
+			return nil, fmt.Errorf("a command notifier requires command")
+		}
+		n = Command{Command: c.Command}
+	case "webhook":
+		if c.URL == "" {
+			return nil, fmt.Errorf("a webhook notifier requires url")
+		}
+		n = Webhook{URL: c.URL, Headers: c.Headers, Timeout: 10 * time.Second}
+	case "smtp":
+		if c.Host == "" || c.From == "" || len(c.To) < 1 {
+			return nil, fmt.Errorf("an smtp notifier requires host, from, and to")
+		}
+		var password string
+		if c.Username != "" {
+			if c.PasswordEnv == "" {
+				return nil, fmt.Errorf("an smtp notifier with a username requires password_env")
+			}
+			if password = os.Getenv(c.PasswordEnv); password == "" {
+				return nil, fmt.Errorf("the environment variable %s named by password_env is not set", c.PasswordEnv)
+			}
+		}
+		port := c.Port
+		if port == 0 {
+			port = 587
+		}
+		n = SMTP{Host: c.Host, Port: port, Username: c.Username, Password: password, From: c.From, To: c.To}
+	default:
+		return nil, fmt.Errorf("%q is not a notifier type, use desktop, command, webhook, or smtp", c.Type)
+	}
+	if len(c.Events) > 0 {
+		n = filter{Notifier: n, kinds: c.Events}
+	}
+	return n, nil
+}
+
+func validKind(kind string) bool {
+	for _, k := range Kinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// filter only passes on events of kinds, and test events.
+type filter struct {
+	Notifier
+	kinds []string
+}
+
+func (f filter) Notify(e Event) error {
+	for _, k := range f.kinds {
+		if k == e.Kind {
+			return f.Notifier.Notify(e)
+		}
+	}
+	if e.Kind == Test {
+		return f.Notifier.Notify(e)
+	}
+	return nil
+}
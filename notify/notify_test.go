@@ -0,0 +1,87 @@
+package notify
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestWebhook(t *testing.T) {
+	Convey("Given a local webhook stub", t, func() {
+		var (
+			received []Event
+			auth     string
+		)
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var e Event
+			if err := json.NewDecoder(r.Body).Decode(&e); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			auth = r.Header.Get("Authorization")
+			received = append(received, e)
+		}))
+		defer srv.Close()
+
+		Convey("Events are posted as JSON with the configured headers", func() {
+			n, err := New(Config{Type: "webhook", URL: srv.URL, Headers: map[string]string{"Authorization": "Bearer x"}})
+			So(err, ShouldBeNil)
+			So(n.Notify(Event{Kind: FirstCrack, Job: "rockyou", Cracked: 3}), ShouldBeNil)
+			So(len(received), ShouldEqual, 1)
+			So(received[0].Job, ShouldEqual, "rockyou")
+			So(received[0].Cracked, ShouldEqual, 3)
+			So(auth, ShouldEqual, "Bearer x")
+		})
+
+		Convey("Events filters everything but those kinds and test events", func() {
+			n, err := New(Config{Type: "webhook", URL: srv.URL, Events: []string{JobExhausted}})
+			So(err, ShouldBeNil)
+			So(n.Notify(Event{Kind: FirstCrack}), ShouldBeNil)
+			So(n.Notify(Event{Kind: JobExhausted}), ShouldBeNil)
+			So(n.Notify(Event{Kind: Test}), ShouldBeNil)
+			So(len(received), ShouldEqual, 2)
+		})
+
+		Convey("An error status is returned as an error", func() {
+			n, _ := New(Config{Type: "webhook", URL: srv.URL + "/bad"})
+			srv.Config.Handler = http.NotFoundHandler()
+			So(n.Notify(Event{Kind: Test}), ShouldNotBeNil)
+		})
+	})
+
+	Convey("Invalid notifiers are rejected", t, func() {
+		_, err := New(Config{Type: "pager"})
+		So(err, ShouldNotBeNil)
+		_, err = New(Config{Type: "webhook"})
+		So(err, ShouldNotBeNil)
+		_, err = New(Config{Type: "desktop", Events: []string{"cracked"}})
+		So(err, ShouldNotBeNil)
+	})
+}
+
+func TestSMTPPassword(t *testing.T) {
+	Convey("Given an smtp notifier with a username", t, func() {
+		c := Config{Type: "smtp", Host: "smtp.example.com", Username: "alerts", From: "a@example.com", To: []string{"b@example.com"}}
+
+		Convey("The password must come from an environment variable", func() {
+			_, err := New(c)
+			So(err, ShouldNotBeNil)
+			c.PasswordEnv = "HASHSTACK_TEST_SMTP_PASSWORD"
+			_, err = New(c)
+			So(err.Error(), ShouldContainSubstring, "HASHSTACK_TEST_SMTP_PASSWORD")
+		})
+
+		Convey("The password is read from the named variable", func() {
+			os.Setenv("HASHSTACK_TEST_SMTP_PASSWORD", "secret")
+			defer os.Unsetenv("HASHSTACK_TEST_SMTP_PASSWORD")
+			c.PasswordEnv = "HASHSTACK_TEST_SMTP_PASSWORD"
+			n, err := New(c)
+			So(err, ShouldBeNil)
+			So(n.(SMTP).Password, ShouldEqual, "secret")
+		})
+	})
+}
@@ -0,0 +1,166 @@
+package secret
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// ErrPassphrase is returned when the file can not be decrypted with the passphrase.
+var ErrPassphrase = errors.New("the passphrase is not correct")
+
+// The scrypt parameters used to derive the key from the passphrase.
+const (
+	scryptN   = 1 << 15
+	scryptR   = 8
+	scryptP   = 1
+	keyLength = 32
+)
+
+// fileData is the encrypted file as it is written to disk.
+type fileData struct {
+	Version int    `json:"version"`
+	Salt    []byte `json:"salt"`
+	Nonce   []byte `json:"nonce"`
+	Data    []byte `json:"data"`
+}
+
+// File stores secrets in a file encrypted with AES-256-GCM using a key
+// derived from a passphrase with scrypt. It is used where there is no keyring.
+type File struct {
+	Path string
+	// Passphrase is called once, the first time the file is read or written.
+	// exists is false when the file is about to be created.
+	Passphrase func(exists bool) ([]byte, error)
+
+	salt []byte
+	key  []byte
+}
+
+// load returns the decrypted secrets, or an empty map when the file does not exist.
+func (f *File) load() (map[string]string, error) {
+	secrets := make(map[string]string)
+	raw, err := ioutil.ReadFile(f.Path)
+	if os.IsNotExist(err) {
+		return secrets, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var d fileData
+	if err := json.Unmarshal(raw, &d); err != nil {
+		return nil, err
+	}
+	if f.key == nil {
+		if err := f.derive(d.Salt, true); err != nil {
+			return nil, err
+		}
+	}
+	gcm, err := f.gcm()
+	if err != nil {
+		return nil, err
+	}
+	plain, err := gcm.Open(nil, d.Nonce, d.Data, nil)
+	if err != nil {
+		f.key = nil
+		return nil, ErrPassphrase
+	}
+	return secrets, json.Unmarshal(plain, &secrets)
+}
+
+// save encrypts secrets with a new nonce and writes them to the file.
+func (f *File) save(secrets map[string]string) error {
+	if f.key == nil {
+		salt := make([]byte, 16)
+		if _, err := rand.Read(salt); err != nil {
+			return err
+		}
+		if err := f.derive(salt, false); err != nil {
+			return err
+		}
+	}
+	plain, err := json.Marshal(secrets)
+	if err != nil {
+		return err
+	}
+	gcm, err := f.gcm()
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+	raw, err := json.Marshal(fileData{Version: 1, Salt: f.salt, Nonce: nonce, Data: gcm.Seal(nil, nonce, plain, nil)})
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(f.Path), 0700); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(f.Path, raw, 0600)
+}
+
+func (f *File) derive(salt []byte, exists bool) error {
+	pass, err := f.Passphrase(exists)
+	if err != nil {
+		return err
+	}
+	key, err := scrypt.Key(pass, salt, scryptN, scryptR, scryptP, keyLength)
+	if err != nil {
+		return err
+	}
+	f.salt, f.key = salt, key
+	return nil
+}
+
+func (f *File) gcm() (cipher.AEAD, error) {
+	block, err := aes.NewCipher(f.key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// Get returns the secret for key.
+func (f *File) Get(key string) (string, error) {
+	secrets, err := f.load()
+	if err != nil {
+		return "", err
+	}
+	v, ok := secrets[key]
+	if !ok {
+		return "", ErrNotFound
+	}
+	return v, nil
+}
+
+// Set saves value as the secret for key.
+func (f *File) Set(key, value string) error {
+	secrets, err := f.load()
+	if err != nil {
+		return err
+	}
+	secrets[key] = value
+	return f.save(secrets)
+}
+
+// Delete removes the secret for key.
+func (f *File) Delete(key string) error {
+	secrets, err := f.load()
+	if err != nil {
+		return err
+	}
+	if _, ok := secrets[key]; !ok {
+		return ErrNotFound
+	}
+	delete(secrets, key)
+	return f.save(secrets)
+}
@@ -0,0 +1,54 @@
+package secret
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestFile(t *testing.T) {
+	Convey("Given an encrypted file", t, func() {
+		dir, _ := ioutil.TempDir("", "hashstack-secret-")
+		defer os.RemoveAll(dir)
+		path := filepath.Join(dir, "tokens.enc")
+		var asked []bool
+		passphrase := func(pass string) func(bool) ([]byte, error) {
+			return func(exists bool) ([]byte, error) {
+				asked = append(asked, exists)
+				return []byte(pass), nil
+			}
+		}
+		f := &File{Path: path, Passphrase: passphrase("correct horse")}
+		So(f.Set("lab", "token-one"), ShouldBeNil)
+		So(f.Set("prod", "token-two"), ShouldBeNil)
+
+		Convey("The passphrase is asked for once, to create the file", func() {
+			So(asked, ShouldResemble, []bool{false})
+		})
+
+		Convey("The tokens are not written in plain text", func() {
+			raw, _ := ioutil.ReadFile(path)
+			So(bytes.Contains(raw, []byte("token-one")), ShouldBeFalse)
+		})
+
+		Convey("The tokens can be read with the same passphrase", func() {
+			other := &File{Path: path, Passphrase: passphrase("correct horse")}
+			v, err := other.Get("prod")
+			So(err, ShouldBeNil)
+			So(v, ShouldEqual, "token-two")
+			So(other.Delete("prod"), ShouldBeNil)
+			_, err = other.Get("prod")
+			So(err, ShouldEqual, ErrNotFound)
+		})
+
+		Convey("Another passphrase is rejected", func() {
+			other := &File{Path: path, Passphrase: passphrase("wrong")}
+			_, err := other.Get("lab")
+			So(err, ShouldEqual, ErrPassphrase)
+		})
+	})
+}
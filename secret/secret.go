@@ -0,0 +1,49 @@
+// Package secret stores session tokens outside of the configuration file, in
+// the operating system keyring or in a file encrypted with a passphrase.
+package secret
+
+import (
+	"errors"
+
+	"github.com/zalando/go-keyring"
+)
+
+// ErrNotFound is returned by Get and Delete when there is no secret for the key.
+var ErrNotFound = errors.New("secret not found")
+
+// Store saves secrets by key.
+type Store interface {
+	Get(key string) (string, error)
+	Set(key, value string) error
+	Delete(key string) error
+}
+
+// Keyring stores secrets in the Secret Service on Linux, the Keychain on
+// macOS, or the Credential Manager on Windows.
+type Keyring struct {
+	// Service groups the secrets in the keyring.
+	Service string
+}
+
+// Get returns the secret for key.
+func (k Keyring) Get(key string) (string, error) {
+	v, err := keyring.Get(k.Service, key)
+	if err == keyring.ErrNotFound {
+		return "", ErrNotFound
+	}
+	return v, err
+}
+
+// Set saves value as the secret for key.
+func (k Keyring) Set(key, value string) error {
+	return keyring.Set(k.Service, key, value)
+}
+
+// Delete removes the secret for key.
+func (k Keyring) Delete(key string) error {
+	err := keyring.Delete(k.Service, key)
+	if err == keyring.ErrNotFound {
+		return ErrNotFound
+	}
+	return err
+}